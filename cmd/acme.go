@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"github.com/OverseedAI/overpork/internal/output"
+	"github.com/OverseedAI/overpork/internal/resolve"
+	"github.com/OverseedAI/overpork/internal/zone"
+	"github.com/spf13/cobra"
+)
+
+const acmePrefix = "_acme-challenge."
+
+var acmeCmd = &cobra.Command{
+	Use:   "acme",
+	Short: "ACME DNS-01 challenge hooks",
+	Long: `Create and remove the _acme-challenge TXT records used by ACME DNS-01
+validation, for use as hooks in certbot, lego or acme.sh.
+
+The FQDN may be the name being certified (www.example.com) or the challenge
+name itself (_acme-challenge.www.example.com). Without arguments, certbot's
+CERTBOT_DOMAIN and CERTBOT_VALIDATION environment variables are used.
+
+Examples:
+  certbot certonly --manual --preferred-challenges dns \
+    --manual-auth-hook "overpork acme present --wait" \
+    --manual-cleanup-hook "overpork acme cleanup" -d '*.example.com'
+
+  overpork acme present _acme-challenge.example.com. <token> --wait
+  overpork acme cleanup _acme-challenge.example.com. <token>`,
+}
+
+var acmePresentCmd = &cobra.Command{
+	Use:   "present [fqdn] [value]",
+	Short: "Create the challenge TXT record",
+	Args:  cobra.RangeArgs(0, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ch, err := acmeChallenge(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		existing, err := acmeRecords(cmd.Context(), ch)
+		if err != nil {
+			return err
+		}
+		status := "created"
+		if len(existing) > 0 {
+			status = "exists"
+		} else {
+			ttl, _ := cmd.Flags().GetString("ttl")
+			rec := zone.Record{Name: ch.Subdomain, Type: "TXT", Content: ch.Value, TTL: ttl}
+			if err := validateWrite(cmd, ch.Domain, rec, nil); err != nil {
+				return err
+			}
+			if _, err := apiClient.DNSCreateContext(cmd.Context(), ch.Domain, "TXT", ch.Value, api.DNSCreateOpts{Name: ch.Subdomain, TTL: ttl}); err != nil {
+				return err
+			}
+		}
+
+		if wait, _ := cmd.Flags().GetBool("wait"); wait {
+			if err := acmeWait(cmd, ch); err != nil {
+				return err
+			}
+		}
+
+		if output.Structured() {
+			output.PrintJSON(map[string]string{"domain": ch.Domain, "name": ch.Name, "status": status})
+		} else if status == "exists" {
+			output.Success("TXT %s already present", ch.Name)
+		} else {
+			output.Success("Created TXT %s", ch.Name)
+		}
+		return nil
+	},
+}
+
+var acmeCleanupCmd = &cobra.Command{
+	Use:   "cleanup [fqdn] [value]",
+	Short: "Delete the challenge TXT record",
+	Long: `Delete the challenge TXT record with exactly the given value. Other TXT
+records on the same name, such as a concurrent challenge for the wildcard
+and the apex, are left alone.`,
+	Args: cobra.RangeArgs(0, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ch, err := acmeChallenge(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		records, err := acmeRecords(cmd.Context(), ch)
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			if err := apiClient.DNSDeleteContext(cmd.Context(), ch.Domain, r.ID); err != nil {
+				return err
+			}
+		}
+
+		if output.Structured() {
+			output.PrintJSON(map[string]any{"domain": ch.Domain, "name": ch.Name, "deleted": len(records)})
+		} else if len(records) == 0 {
+			output.Success("No matching TXT record on %s", ch.Name)
+		} else {
+			output.Success("Deleted TXT %s", ch.Name)
+		}
+		return nil
+	},
+}
+
+// challenge is a DNS-01 TXT record in the registered domain that hosts it.
+type challenge struct {
+	Name      string // _acme-challenge.www.example.com
+	Domain    string // example.com
+	Subdomain string // _acme-challenge.www
+	Value     string
+}
+
+// acmeChallenge resolves the hook arguments (or certbot's environment) into
+// the record to manage.
+func acmeChallenge(cmd *cobra.Command, args []string) (*challenge, error) {
+	var fqdn, value string
+	switch len(args) {
+	case 2:
+		fqdn, value = args[0], args[1]
+	case 0:
+		fqdn, value = os.Getenv("CERTBOT_DOMAIN"), os.Getenv("CERTBOT_VALIDATION")
+		if fqdn == "" || value == "" {
+			return nil, fmt.Errorf("expected <fqdn> <value> or CERTBOT_DOMAIN and CERTBOT_VALIDATION")
+		}
+	default:
+		return nil, fmt.Errorf("expected <fqdn> <value>")
+	}
+
+	name := strings.ToLower(strings.TrimSuffix(fqdn, "."))
+	name = strings.TrimPrefix(name, "*.")
+	if !strings.HasPrefix(name, acmePrefix) {
+		name = acmePrefix + name
+	}
+
+	domain, _ := cmd.Flags().GetString("domain")
+	if domain == "" {
+		var err error
+		if domain, err = registeredDomain(cmd.Context(), strings.TrimPrefix(name, acmePrefix)); err != nil {
+			return nil, err
+		}
+	}
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	if name != acmePrefix+domain && !strings.HasSuffix(name, "."+domain) {
+		return nil, fmt.Errorf("%s is not in domain %s", name, domain)
+	}
+
+	return &challenge{
+		Name:      name,
+		Domain:    domain,
+		Subdomain: zone.RelativeName(name, domain),
+		Value:     value,
+	}, nil
+}
+
+// registeredDomain finds the domain in the account that contains host,
+// preferring the longest match.
+func registeredDomain(ctx context.Context, host string) (string, error) {
+	domains, err := apiClient.DomainListAllContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	best := ""
+	for _, d := range domains {
+		name := strings.ToLower(d.Domain)
+		if (host == name || strings.HasSuffix(host, "."+name)) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("no domain in this account contains %s (use --domain)", host)
+	}
+	return best, nil
+}
+
+// acmeRecords returns the challenge's TXT records that carry its value.
+func acmeRecords(ctx context.Context, ch *challenge) ([]api.DNSRecord, error) {
+	records, err := apiClient.DNSListByTypeAndSubdomainContext(ctx, ch.Domain, "TXT", ch.Subdomain)
+	if err != nil {
+		return nil, err
+	}
+	var matching []api.DNSRecord
+	for _, r := range records {
+		if strings.Trim(r.Content, `"`) == ch.Value {
+			matching = append(matching, r)
+		}
+	}
+	return matching, nil
+}
+
+// acmeWait polls the domain's authoritative nameservers until all of them
+// serve the challenge value.
+func acmeWait(cmd *cobra.Command, ch *challenge) error {
+	timeout, _ := cmd.Flags().GetDuration("wait-timeout")
+	interval, _ := cmd.Flags().GetDuration("interval")
+	recursive, _ := cmd.Flags().GetString("resolver")
+	r := &resolve.Resolver{Recursive: recursive}
+
+	servers, err := r.Nameservers(cmd.Context(), ch.Domain)
+	if err != nil {
+		return err
+	}
+
+	pending := servers
+	err = pollUntil(cmd.Context(), timeout, interval, func(ctx context.Context) (bool, error) {
+		var still []resolve.Server
+		for _, s := range pending {
+			records, err := r.Lookup(ctx, s.Addr, ch.Name, "TXT")
+			if err != nil && ctx.Err() != nil {
+				return false, nil
+			}
+			if !slices.ContainsFunc(records, func(rec resolve.Record) bool { return rec.Content == ch.Value }) {
+				still = append(still, s)
+			}
+		}
+		pending = still
+		if len(pending) > 0 && !output.Structured() {
+			fmt.Fprintf(output.Stderr, "Waiting for %s on %d of %d nameservers...\n", ch.Name, len(pending), len(servers))
+		}
+		return len(pending) == 0, nil
+	})
+	if err != nil {
+		names := make([]string, len(pending))
+		for i, s := range pending {
+			names[i] = s.Name
+		}
+		return fmt.Errorf("TXT %s not visible on %s: %w", ch.Name, strings.Join(names, ", "), err)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(acmeCmd)
+	acmeCmd.AddCommand(acmePresentCmd)
+	acmeCmd.AddCommand(acmeCleanupCmd)
+
+	acmeCmd.PersistentFlags().String("domain", "", "Registered domain (default: found in the account)")
+	acmePresentCmd.Flags().String("ttl", "600", "TTL for the TXT record")
+	acmePresentCmd.Flags().Bool("wait", false, "Wait until the record is visible on the authoritative nameservers")
+	acmePresentCmd.Flags().Duration("wait-timeout", 5*time.Minute, "How long to wait with --wait")
+	acmePresentCmd.Flags().Duration("interval", 10*time.Second, "Time between checks with --wait")
+	acmePresentCmd.Flags().String("resolver", "", "Recursive resolver (host:port) for nameserver lookups (default: system)")
+	addValidationFlag(acmePresentCmd)
+}
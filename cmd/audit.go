@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"github.com/OverseedAI/overpork/internal/audit"
+	"github.com/OverseedAI/overpork/internal/config"
+	"github.com/OverseedAI/overpork/internal/expiry"
+	"github.com/OverseedAI/overpork/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect the local log of changes made through the API",
+}
+
+var auditLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Show the audit log",
+	Long: `Show the audit log: every state-changing API call made from this machine,
+with the time, profile, OS user, command line, endpoint, parameters
+(secrets redacted), the state before the change and any error.
+
+The log is audit.jsonl in the config directory, or PORKBUN_AUDIT_LOG
+(set it to "off" to stop logging).
+
+Examples:
+  overpork audit log
+  overpork audit log --domain example.com --since 7d
+  overpork audit log --since 2025-01-01 --json
+  overpork audit log --file /shared/alice-audit.jsonl`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, _ := cmd.Flags().GetString("file")
+		if path == "" {
+			var err error
+			path, err = config.AuditLogPath()
+			if err != nil {
+				return fmt.Errorf("failed to locate audit log: %w", err)
+			}
+			if path == "" {
+				return fmt.Errorf("audit log is off (PORKBUN_AUDIT_LOG=off)")
+			}
+		}
+
+		var filter audit.Filter
+		filter.Domain, _ = cmd.Flags().GetString("domain")
+		if since, _ := cmd.Flags().GetString("since"); since != "" {
+			t, err := parseSince(since, time.Now())
+			if err != nil {
+				return err
+			}
+			filter.Since = t
+		}
+
+		entries, err := audit.Read(path, filter)
+		if err != nil {
+			return err
+		}
+
+		if output.Structured() {
+			if entries == nil {
+				entries = []audit.Entry{}
+			}
+			output.PrintJSON(entries)
+			return nil
+		}
+		if len(entries) == 0 {
+			output.Print("No audit entries found")
+			return nil
+		}
+		headers := []string{"TIME", "USER", "PROFILE", "ENDPOINT", "STATUS", "COMMAND"}
+		rows := make([][]string, len(entries))
+		for i, e := range entries {
+			status := "ok"
+			if e.Error != "" {
+				status = "failed: " + e.Error
+			}
+			rows[i] = []string{e.Time.Local().Format("2006-01-02 15:04:05"), e.User, e.Profile, e.Endpoint, status, e.Command}
+		}
+		output.PrintTable(headers, rows)
+		return nil
+	},
+}
+
+// parseSince parses --since: a date, an RFC 3339 time, or a window back
+// from now such as "7d" or "12h".
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	d, err := expiry.ParseWithin(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q (use e.g. 7d, 12h, 2025-01-31 or an RFC 3339 time)", s)
+	}
+	return now.Add(-d), nil
+}
+
+// enableAudit logs every state-changing call of apiClient to the audit log.
+// A log that cannot be written is reported once but doesn't fail the
+// command, since the change itself went through.
+func enableAudit() {
+	path, err := config.AuditLogPath()
+	if err != nil {
+		output.Warn("Audit log disabled: %v", err)
+		return
+	}
+	if path == "" {
+		return
+	}
+	log := audit.Open(path)
+	user := audit.CurrentUser()
+	host, _ := os.Hostname()
+	command := audit.CommandLine(os.Args)
+	var warn sync.Once
+
+	apiClient.OnMutation(func(ctx context.Context, m api.Mutation) {
+		e := audit.Entry{
+			Time:     time.Now().UTC(),
+			Profile:  cfg.Account(),
+			User:     user,
+			Host:     host,
+			Command:  command,
+			Endpoint: m.Endpoint,
+			Domain:   strings.ToLower(m.Domain),
+			Params:   audit.Redact(m.Params),
+			Before:   m.Before,
+		}
+		if m.Err != nil {
+			e.Error = m.Err.Error()
+		}
+		if err := log.Append(e); err != nil {
+			warn.Do(func() { output.Warn("Audit log not written: %v", err) })
+		}
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(auditLogCmd)
+	auditLogCmd.Flags().String("domain", "", "Only show changes to this domain")
+	auditLogCmd.Flags().String("since", "", "Only show changes since a time (7d, 12h, 2025-01-31 or RFC 3339)")
+	auditLogCmd.Flags().String("file", "", "Read this audit log instead of the default one")
+}
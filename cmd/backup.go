@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"github.com/OverseedAI/overpork/internal/backup"
+	"github.com/OverseedAI/overpork/internal/output"
+	"github.com/OverseedAI/overpork/internal/zone"
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup [domain...]",
+	Short: "Snapshot the state of every domain in the account",
+	Long: `Save DNS records, URL forwards, nameservers, glue records, DNSSEC DS
+records and domain settings of every domain (or only the given ones) into a
+single versioned snapshot for "restore".
+
+The snapshot is written as JSON, or as a gzipped tarball with one file per
+domain when --format is tar.gz or the file name ends in .tar.gz or .tgz.
+Parts that cannot be read (e.g. glue for a domain without API access) are
+noted in the snapshot and skipped on restore.
+
+Examples:
+  overpork backup --file porkbun.json
+  overpork backup example.com example.net --file sites.tar.gz
+  overpork backup > porkbun.json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, _ := cmd.Flags().GetString("file")
+		format, _ := cmd.Flags().GetString("format")
+		if format == "" {
+			format = backupFormat(path)
+		}
+		if format != backup.FormatJSON && format != backup.FormatTar {
+			return fmt.Errorf("invalid format %q: use %s or %s", format, backup.FormatJSON, backup.FormatTar)
+		}
+
+		domains, err := apiClient.DomainListAllContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			if domains, err = selectDomains(domains, args); err != nil {
+				return err
+			}
+		}
+
+		toFile := path != "" && path != "-"
+		progress := func(domain string) {
+			if toFile && !output.Structured() {
+				fmt.Fprintf(output.Stderr, "Backing up %s\n", domain)
+			}
+		}
+		snap, err := backup.Collect(cmd.Context(), apiClient, domains, progress)
+		if err != nil {
+			return err
+		}
+
+		var w io.Writer = output.Stdout
+		if toFile {
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", path, err)
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := backup.Write(w, snap, format); err != nil {
+			return fmt.Errorf("failed to write snapshot: %w", err)
+		}
+
+		failed := 0
+		for _, d := range snap.Domains {
+			for _, part := range backup.Parts {
+				if msg, ok := d.Errors[part]; ok {
+					failed++
+					if !output.Structured() {
+						output.Warn("%s: could not back up %s: %s", d.Domain, part, msg)
+					}
+				}
+			}
+		}
+
+		if toFile {
+			if output.Structured() {
+				output.PrintJSON(map[string]any{"path": path, "domains": len(snap.Domains), "failedParts": failed, "status": "saved"})
+			} else {
+				output.Success("Saved %d domains to %s", len(snap.Domains), path)
+			}
+		}
+		return nil
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <snapshot> [domain...]",
+	Short: "Restore domains from a snapshot taken with backup",
+	Long: `Replay a snapshot ("-" reads stdin) onto every domain in it, or only the
+given ones. Live state is compared with the snapshot first, and only the
+differences are applied, after confirmation.
+
+Parts are restored in this order: settings, glue, nameservers, dnssec,
+forwards, dns. Use --only to restore some of them. Live records, forwards,
+glue and DS records missing from the snapshot are deleted unless
+--no-delete is given.
+
+Examples:
+  overpork restore porkbun.json --dry-run
+  overpork restore porkbun.json example.com --only dns
+  overpork restore porkbun.json --no-delete --yes`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		noDelete, _ := cmd.Flags().GetBool("no-delete")
+		only, _ := cmd.Flags().GetStringSlice("only")
+		for _, part := range only {
+			if !slices.Contains(backup.Parts, part) {
+				return fmt.Errorf("invalid part %q: use %s", part, strings.Join(backup.Parts, ", "))
+			}
+		}
+
+		snap, err := readSnapshot(args[0])
+		if err != nil {
+			return err
+		}
+		targets := snap.Domains
+		if len(args) > 1 {
+			targets = nil
+			for _, name := range args[1:] {
+				d := snap.Find(name)
+				if d == nil {
+					return fmt.Errorf("%s is not in the snapshot", name)
+				}
+				targets = append(targets, *d)
+			}
+		}
+
+		opts := backup.RestoreOptions{Parts: only, NoDelete: noDelete}
+		var plans []*backup.Plan
+		total := 0
+		for i := range targets {
+			plan, err := backup.PlanRestore(cmd.Context(), apiClient, &targets[i], opts)
+			if err != nil {
+				return fmt.Errorf("failed to plan %s: %w", targets[i].Domain, err)
+			}
+			if plan.DNS != nil {
+				if err := validatePlan(cmd, plan.DNS, nil); err != nil {
+					return fmt.Errorf("%s: %w", plan.Domain, err)
+				}
+			}
+			plans = append(plans, plan)
+			c, u, d := plan.Counts()
+			total += c + u + d
+		}
+
+		if dryRun || total == 0 {
+			if output.Structured() {
+				output.PrintJSON(plans)
+			} else {
+				printRestorePlans(plans)
+			}
+			return nil
+		}
+
+		if !output.Structured() {
+			printRestorePlans(plans)
+		}
+		ok, err := confirm(cmd, fmt.Sprintf("Apply %d changes to %d domains?", total, len(plans)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("aborted")
+		}
+
+		applied := 0
+		for _, plan := range plans {
+			n, err := plan.Apply(cmd.Context(), apiClient)
+			applied += n
+			if err != nil {
+				return fmt.Errorf("applied %d of %d changes: %s: %w", applied, total, plan.Domain, err)
+			}
+		}
+
+		if output.Structured() {
+			output.PrintJSON(map[string]any{"applied": applied, "status": "restored", "plans": plans})
+		} else {
+			output.Success("Applied %d changes to %d domains", applied, len(plans))
+		}
+		return nil
+	},
+}
+
+// backupFormat infers the snapshot format from a file name.
+func backupFormat(path string) string {
+	if strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz") {
+		return backup.FormatTar
+	}
+	return backup.FormatJSON
+}
+
+// selectDomains keeps the named domains, failing on any not in the account.
+func selectDomains(domains []api.Domain, names []string) ([]api.Domain, error) {
+	var selected []api.Domain
+	for _, name := range names {
+		i := slices.IndexFunc(domains, func(d api.Domain) bool { return strings.EqualFold(d.Domain, name) })
+		if i < 0 {
+			return nil, fmt.Errorf("%s is not in this account", name)
+		}
+		selected = append(selected, domains[i])
+	}
+	return selected, nil
+}
+
+func readSnapshot(path string) (*backup.Snapshot, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open snapshot: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+	return backup.Read(r)
+}
+
+func printRestorePlans(plans []*backup.Plan) {
+	shown := false
+	for _, plan := range plans {
+		for _, msg := range plan.Skipped {
+			output.Warn("%s: %s", plan.Domain, msg)
+		}
+		if plan.Empty() {
+			continue
+		}
+		if shown {
+			output.Print("")
+		}
+		shown = true
+		output.Print(plan.Domain + ":")
+
+		if len(plan.Changes) > 0 {
+			rows := make([][]string, len(plan.Changes))
+			for i, c := range plan.Changes {
+				rows[i] = []string{actionLabel(c.Action), c.Part, c.Target, changeValue(c.Before, c.After)}
+			}
+			output.PrintTable([]string{"ACTION", "PART", "TARGET", "VALUE"}, rows)
+		}
+		if plan.DNS != nil && !plan.DNS.Empty() {
+			if len(plan.Changes) > 0 {
+				output.Print("")
+			}
+			printPlan(plan.DNS)
+		}
+	}
+	if !shown {
+		output.Print("No changes")
+	}
+}
+
+func actionLabel(a zone.Action) string {
+	switch a {
+	case zone.ActionCreate:
+		return "+ create"
+	case zone.ActionDelete:
+		return "- delete"
+	}
+	return "~ update"
+}
+
+// changeValue shows a value change where either side may be empty.
+func changeValue(before, after string) string {
+	switch {
+	case before == "":
+		return after
+	case after == "":
+		return before
+	}
+	return changed(before, after)
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	backupCmd.Flags().StringP("file", "f", "", "Write the snapshot to a file instead of stdout")
+	backupCmd.Flags().String("format", "", "Snapshot format: json or tar.gz (default from the file name, else json)")
+
+	rootCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().Bool("dry-run", false, "Show changes without applying them")
+	restoreCmd.Flags().Bool("no-delete", false, "Never delete live records, forwards, glue or DS records missing from the snapshot")
+	restoreCmd.Flags().StringSlice("only", nil, "Restore only these parts: "+strings.Join(backup.Parts, ", "))
+	restoreCmd.Flags().BoolP("yes", "y", false, "Apply without asking for confirmation")
+	addValidationFlag(restoreCmd)
+}
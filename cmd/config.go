@@ -2,13 +2,14 @@ package cmd
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/OverseedAI/overpork/internal/config"
 	"github.com/OverseedAI/overpork/internal/output"
+	"github.com/OverseedAI/overpork/internal/secrets"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 )
@@ -21,80 +22,342 @@ var configCmd = &cobra.Command{
 var configInitCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Create config file with API credentials",
-	Long:  "Create config file with API credentials. Interactive by default, or use flags for non-interactive mode.",
+	Long: `Create config file with API credentials. Interactive by default, or use flags for non-interactive mode.
+With --profile, the credentials are written into that named profile.
+
+By default the keys are written to config.yaml in plain text. Use --store to
+keep them out of the file:
+
+  keyring  the OS keyring (Secret Service via secret-tool on Linux, Keychain
+           on macOS), falling back to the encrypted file when unavailable
+  file     secrets.enc in the config directory, encrypted with a passphrase
+           from PORKBUN_PASSPHRASE or a prompt
+
+Or use --secret-command to fetch the secret key from a password manager each
+time it is needed.
+
+Examples:
+  overpork config init --store keyring
+  overpork config init --secret-command "pass show porkbun"`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		apiKey, _ := cmd.Flags().GetString("api-key")
-		secretKey, _ := cmd.Flags().GetString("secret-key")
+		profile, _ := cmd.Flags().GetString("profile")
+		return saveCredentials(cmd, strings.ToLower(profile), "created")
+	},
+}
 
-		reader := bufio.NewReader(os.Stdin)
+// promptCredentials returns the --api-key and --secret-key flags, asking
+// for whichever is missing. The secret key is skipped when needSecret is
+// false.
+func promptCredentials(cmd *cobra.Command, needSecret bool) (string, string, error) {
+	apiKey, _ := cmd.Flags().GetString("api-key")
+	secretKey, _ := cmd.Flags().GetString("secret-key")
 
-		if apiKey == "" {
-			fmt.Print("API Key: ")
-			input, err := reader.ReadString('\n')
+	reader := bufio.NewReader(os.Stdin)
+
+	if apiKey == "" {
+		fmt.Print("API Key: ")
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read API key: %w", err)
+		}
+		apiKey = strings.TrimSpace(input)
+	}
+
+	if secretKey == "" && needSecret {
+		fmt.Print("Secret Key: ")
+		if term.IsTerminal(int(os.Stdin.Fd())) {
+			secret, err := term.ReadPassword(int(os.Stdin.Fd()))
 			if err != nil {
-				return fmt.Errorf("failed to read API key: %w", err)
+				return "", "", fmt.Errorf("failed to read secret key: %w", err)
 			}
-			apiKey = strings.TrimSpace(input)
-		}
-
-		if secretKey == "" {
-			fmt.Print("Secret Key: ")
-			if term.IsTerminal(int(os.Stdin.Fd())) {
-				secret, err := term.ReadPassword(int(os.Stdin.Fd()))
-				if err != nil {
-					return fmt.Errorf("failed to read secret key: %w", err)
-				}
-				fmt.Println()
-				secretKey = string(secret)
-			} else {
-				input, err := reader.ReadString('\n')
-				if err != nil {
-					return fmt.Errorf("failed to read secret key: %w", err)
-				}
-				secretKey = strings.TrimSpace(input)
+			fmt.Println()
+			secretKey = string(secret)
+		} else {
+			input, err := reader.ReadString('\n')
+			if err != nil {
+				return "", "", fmt.Errorf("failed to read secret key: %w", err)
 			}
+			secretKey = strings.TrimSpace(input)
+		}
+	}
+
+	if apiKey == "" || (secretKey == "" && needSecret) {
+		return "", "", fmt.Errorf("both API key and secret key are required")
+	}
+	return apiKey, secretKey, nil
+}
+
+// saveCredentials prompts for credentials and stores them in profile,
+// either in config.yaml or in the secret store selected with --store.
+func saveCredentials(cmd *cobra.Command, profile, status string) error {
+	store, _ := cmd.Flags().GetString("store")
+	secretCommand, _ := cmd.Flags().GetString("secret-command")
+	switch store {
+	case "plain", config.StoreKeyring, config.StoreFile:
+	default:
+		return fmt.Errorf("invalid --store %q (use plain, keyring or file)", store)
+	}
+	if secretCommand != "" && store != "plain" {
+		return fmt.Errorf("--secret-command cannot be combined with --store")
+	}
+
+	apiKey, secretKey, err := promptCredentials(cmd, secretCommand == "")
+	if err != nil {
+		return err
+	}
+
+	f, err := config.OpenFile()
+	if err != nil {
+		return err
+	}
+	for _, key := range []string{"api_key", "secret_key", "secret_store", "secret_command", "api_key_command"} {
+		f.Unset(profile, key)
+	}
+
+	switch {
+	case secretCommand != "":
+		f.Set(profile, "api_key", apiKey)
+		f.Set(profile, "secret_command", secretCommand)
+		store = "command"
+	case store == "plain":
+		f.Set(profile, "api_key", apiKey)
+		f.Set(profile, "secret_key", secretKey)
+	default:
+		if store, err = storeCredentials(store, profile, apiKey, secretKey); err != nil {
+			return err
 		}
+		f.Set(profile, "secret_store", store)
+	}
+	if err := f.Save(); err != nil {
+		return err
+	}
 
-		if apiKey == "" || secretKey == "" {
-			return fmt.Errorf("both API key and secret key are required")
+	if output.Structured() {
+		result := map[string]string{"path": f.Path(), "status": status, "store": store}
+		if profile != "" {
+			result["profile"] = profile
 		}
+		output.PrintJSON(result)
+	} else if profile != "" {
+		output.Success("Profile %s saved to %s", profile, f.Path())
+	} else {
+		output.Success("Config saved to %s", f.Path())
+	}
+	return nil
+}
+
+var configProfilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Short: "Manage named credential profiles",
+	Long: `Manage named credential profiles for multiple Porkbun accounts.
+
+Select a profile per command with --profile or PORKBUN_PROFILE, or make
+one the default with "config profiles use". The top-level credentials in
+config.yaml are the "default" profile.`,
+}
 
-		configDir, err := config.ConfigDir()
+var configProfilesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List credential profiles",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := config.OpenFile()
 		if err != nil {
-			return fmt.Errorf("failed to get config directory: %w", err)
+			return err
+		}
+
+		active := f.ActiveProfile()
+		if active == "" {
+			active = config.DefaultProfile
+		}
+
+		type profileInfo struct {
+			Name   string `json:"name"`
+			APIKey string `json:"apiKey"`
+			Active bool   `json:"active"`
+		}
+		var profiles []profileInfo
+		if f.HasProfile(config.DefaultProfile) {
+			profiles = append(profiles, profileInfo{config.DefaultProfile, profileKey(f, ""), active == config.DefaultProfile})
+		}
+		for _, name := range f.Profiles() {
+			profiles = append(profiles, profileInfo{name, profileKey(f, name), name == active})
+		}
+
+		if output.Structured() {
+			output.PrintJSON(profiles)
+			return nil
+		}
+
+		if len(profiles) == 0 {
+			output.Print("No profiles found")
+			return nil
+		}
+
+		headers := []string{"ACTIVE", "NAME", "API KEY"}
+		rows := make([][]string, len(profiles))
+		for i, p := range profiles {
+			mark := ""
+			if p.Active {
+				mark = "*"
+			}
+			rows[i] = []string{mark, p.Name, p.APIKey}
+		}
+		output.PrintTable(headers, rows)
+		return nil
+	},
+}
+
+var configProfilesAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add or replace a credential profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := strings.ToLower(args[0])
+		if name == config.DefaultProfile {
+			return fmt.Errorf("use \"config init\" to set the default credentials")
 		}
+		return saveCredentials(cmd, name, "added")
+	},
+}
 
-		if err := os.MkdirAll(configDir, 0700); err != nil {
-			return fmt.Errorf("failed to create config directory: %w", err)
+var configProfilesRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a credential profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := config.OpenFile()
+		if err != nil {
+			return err
+		}
+		// Check the profile can be removed before touching its secrets.
+		store := f.Get(args[0], "secret_store")
+		if err := f.RemoveProfile(args[0]); err != nil {
+			return err
+		}
+		if store != "" {
+			s, err := config.OpenStore(store)
+			if err != nil {
+				return err
+			}
+			if err := s.Delete(config.AccountName(args[0])); err != nil && !errors.Is(err, secrets.ErrNotFound) {
+				return fmt.Errorf("failed to remove credentials from %s store: %w", store, err)
+			}
+		}
+		if err := f.Save(); err != nil {
+			return err
 		}
 
-		configPath := filepath.Join(configDir, "config.yaml")
-		content := fmt.Sprintf("api_key: %s\nsecret_key: %s\n", apiKey, secretKey)
+		if output.Structured() {
+			output.PrintJSON(map[string]string{"profile": args[0], "status": "removed"})
+		} else {
+			output.Success("Removed profile %s", args[0])
+		}
+		return nil
+	},
+}
 
-		if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
-			return fmt.Errorf("failed to write config file: %w", err)
+var configProfilesUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Make a profile the default for future commands",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := config.OpenFile()
+		if err != nil {
+			return err
+		}
+		if err := f.UseProfile(strings.ToLower(args[0])); err != nil {
+			return err
+		}
+		if err := f.Save(); err != nil {
+			return err
 		}
 
-		if output.JSONOutput {
-			output.PrintJSON(map[string]string{"path": configPath, "status": "created"})
+		if output.Structured() {
+			output.PrintJSON(map[string]string{"profile": args[0], "status": "active"})
 		} else {
-			output.Success("Config saved to %s", configPath)
+			output.Success("Using profile %s", args[0])
 		}
 		return nil
 	},
 }
 
+// storeCredentials saves the keys in the named secret store and returns the
+// store actually used: the keyring falls back to the encrypted file when no
+// keyring is available.
+func storeCredentials(store, profile, apiKey, secretKey string) (string, error) {
+	account := config.AccountName(profile)
+	creds := secrets.Credentials{APIKey: apiKey, SecretKey: secretKey}
+
+	s, err := config.OpenStore(store)
+	if err != nil {
+		return "", err
+	}
+	err = s.Set(account, creds)
+	if errors.Is(err, secrets.ErrUnavailable) && store == config.StoreKeyring {
+		output.Warn("Keyring unavailable (%v), using encrypted file instead", err)
+		return storeCredentials(config.StoreFile, profile, apiKey, secretKey)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to save credentials in %s store: %w", store, err)
+	}
+	return store, nil
+}
+
+// promptPassphrase reads the secrets file passphrase from
+// PORKBUN_PASSPHRASE or the terminal, asking at most once per run.
+func promptPassphrase() func() (string, error) {
+	var pass string
+	return func() (string, error) {
+		if pass != "" {
+			return pass, nil
+		}
+		if pass = os.Getenv("PORKBUN_PASSPHRASE"); pass != "" {
+			return pass, nil
+		}
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return "", fmt.Errorf("secrets file passphrase required (set PORKBUN_PASSPHRASE in non-interactive mode)")
+		}
+		fmt.Fprint(output.Stderr, "Passphrase: ")
+		input, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(output.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		pass = string(input)
+		return pass, nil
+	}
+}
+
+// profileKey describes a profile's API key for listing without reading any
+// secret store.
+func profileKey(f *config.File, profile string) string {
+	if store := f.Get(profile, "secret_store"); store != "" {
+		return "(" + store + ")"
+	}
+	if f.Get(profile, "api_key_command") != "" {
+		return "(command)"
+	}
+	return maskKey(f.Get(profile, "api_key"))
+}
+
+// maskKey hides all but the last four characters of a key.
+func maskKey(key string) string {
+	if len(key) <= 8 {
+		return strings.Repeat("*", len(key))
+	}
+	return key[:4] + strings.Repeat("*", 4) + key[len(key)-4:]
+}
+
 var configPathCmd = &cobra.Command{
 	Use:   "path",
 	Short: "Print config file path",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		configDir, err := config.ConfigDir()
+		configPath, err := config.FilePath()
 		if err != nil {
 			return err
 		}
-		configPath := filepath.Join(configDir, "config.yaml")
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(map[string]string{"path": configPath})
 		} else {
 			output.Print(configPath)
@@ -104,11 +367,25 @@ var configPathCmd = &cobra.Command{
 }
 
 func init() {
+	config.Passphrase = promptPassphrase()
+
 	rootCmd.AddCommand(configCmd)
 
 	configCmd.AddCommand(configInitCmd)
 	configInitCmd.Flags().String("api-key", "", "Porkbun API key")
 	configInitCmd.Flags().String("secret-key", "", "Porkbun secret key")
+	configInitCmd.Flags().String("store", "plain", "Where to keep the keys: plain, keyring or file")
+	configInitCmd.Flags().String("secret-command", "", "Command that prints the secret key (e.g. \"pass show porkbun\")")
 
 	configCmd.AddCommand(configPathCmd)
+
+	configCmd.AddCommand(configProfilesCmd)
+	configProfilesCmd.AddCommand(configProfilesListCmd)
+	configProfilesCmd.AddCommand(configProfilesAddCmd)
+	configProfilesAddCmd.Flags().String("api-key", "", "Porkbun API key")
+	configProfilesAddCmd.Flags().String("secret-key", "", "Porkbun secret key")
+	configProfilesAddCmd.Flags().String("store", "plain", "Where to keep the keys: plain, keyring or file")
+	configProfilesAddCmd.Flags().String("secret-command", "", "Command that prints the secret key (e.g. \"pass show porkbun\")")
+	configProfilesCmd.AddCommand(configProfilesRemoveCmd)
+	configProfilesCmd.AddCommand(configProfilesUseCmd)
 }
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/OverseedAI/overpork/internal/output"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// confirm asks the user to approve a change. It returns true without asking
+// when --yes was given, and refuses to proceed when stdin is not a terminal.
+func confirm(cmd *cobra.Command, prompt string) (bool, error) {
+	if yes, _ := cmd.Flags().GetBool("yes"); yes {
+		return true, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, fmt.Errorf("refusing to make changes without confirmation (use --yes in non-interactive mode)")
+	}
+
+	fmt.Fprintf(output.Stderr, "%s [y/N]: ", prompt)
+	input, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
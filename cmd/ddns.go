@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"github.com/OverseedAI/overpork/internal/ddns"
+	"github.com/OverseedAI/overpork/internal/output"
+	"github.com/OverseedAI/overpork/internal/zone"
+	"github.com/spf13/cobra"
+)
+
+// ddnsMaxAge is how long the state file is trusted before the live record
+// is checked again, so edits made elsewhere are eventually corrected.
+const ddnsMaxAge = 24 * time.Hour
+
+var ddnsCmd = &cobra.Command{
+	Use:   "ddns <domain>",
+	Short: "Point an A/AAAA record at this machine's public address",
+	Long: `Discover this machine's public address and update the A (and optionally
+AAAA) record for a name when it changed.
+
+The address comes from HTTP echo endpoints (--ip-url, --ip6-url) or from a
+local network interface (--interface). The last published address is kept in
+a state file so runs with an unchanged address make no API calls; the live
+record is re-checked at least once a day or with --force.
+
+Run it from cron, or keep it running with --watch.
+
+Examples:
+  overpork ddns example.com --name home
+  overpork ddns example.com --name home --ipv6
+  overpork ddns example.com --name vpn --interface eth0 --watch --interval 5m`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("name")
+		ttl, _ := cmd.Flags().GetString("ttl")
+		force, _ := cmd.Flags().GetBool("force")
+		watch, _ := cmd.Flags().GetBool("watch")
+		interval, _ := cmd.Flags().GetDuration("interval")
+		statePath, _ := cmd.Flags().GetString("state-file")
+
+		families, err := ddnsFamilies(cmd)
+		if err != nil {
+			return err
+		}
+		if watch && interval < time.Second {
+			return fmt.Errorf("--interval must be at least 1s")
+		}
+
+		if statePath == "" {
+			if statePath, err = ddns.DefaultStatePath(); err != nil {
+				return err
+			}
+		}
+		state, err := ddns.LoadState(statePath)
+		if err != nil {
+			return err
+		}
+
+		u := &ddns.Updater{
+			Client: apiClient,
+			Domain: args[0],
+			Name:   name,
+			TTL:    ttl,
+			State:  state,
+			MaxAge: ddnsMaxAge,
+			Force:  force,
+			Check: func(ctx context.Context, rec zone.Record) error {
+				return validateWrite(cmd, args[0], rec, func(r api.DNSRecord) bool {
+					return zone.RelativeName(r.Name, args[0]) == rec.Name && strings.EqualFold(r.Type, rec.Type)
+				})
+			},
+		}
+
+		if !watch {
+			results, err := ddnsSync(cmd, u, families)
+			printDDNSResults(results, true)
+			return err
+		}
+
+		ctx := cmd.Context()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			results, err := ddnsSync(cmd, u, families)
+			printDDNSResults(results, false)
+			if err != nil && ctx.Err() == nil {
+				output.Error("%v", err)
+			}
+			// Only the first cycle honors --force.
+			u.Force = false
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	},
+}
+
+// ddnsFamilies returns the address families selected with --ipv4/--ipv6
+// (false for IPv4, true for IPv6).
+func ddnsFamilies(cmd *cobra.Command) ([]bool, error) {
+	v4, _ := cmd.Flags().GetBool("ipv4")
+	v6, _ := cmd.Flags().GetBool("ipv6")
+	var families []bool
+	if v4 {
+		families = append(families, false)
+	}
+	if v6 {
+		families = append(families, true)
+	}
+	if len(families) == 0 {
+		return nil, fmt.Errorf("nothing to update (enable --ipv4 or --ipv6)")
+	}
+	return families, nil
+}
+
+// ddnsSync discovers and publishes the address of each family, saving the
+// state file afterwards. It carries on with the other family when one fails.
+func ddnsSync(cmd *cobra.Command, u *ddns.Updater, families []bool) ([]ddns.Result, error) {
+	iface, _ := cmd.Flags().GetString("interface")
+	ipURLs, _ := cmd.Flags().GetStringSlice("ip-url")
+	ip6URLs, _ := cmd.Flags().GetStringSlice("ip6-url")
+
+	var results []ddns.Result
+	var firstErr error
+	for _, ipv6 := range families {
+		addr, err := discoverAddr(cmd.Context(), iface, ipURLs, ip6URLs, ipv6)
+		if err == nil {
+			var res ddns.Result
+			res, err = u.Sync(cmd.Context(), addr)
+			if err == nil {
+				results = append(results, res)
+			}
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if err := u.State.Save(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return results, firstErr
+}
+
+func discoverAddr(ctx context.Context, iface string, ipURLs, ip6URLs []string, ipv6 bool) (netip.Addr, error) {
+	if iface != "" {
+		return ddns.FromInterface(iface, ipv6)
+	}
+	if ipv6 {
+		return ddns.FromHTTP(ctx, ip6URLs, true)
+	}
+	return ddns.FromHTTP(ctx, ipURLs, false)
+}
+
+// printDDNSResults reports sync results. Unchanged records are only shown
+// when verbose, so --watch logs just the changes.
+func printDDNSResults(results []ddns.Result, verbose bool) {
+	if output.Structured() {
+		if len(results) > 0 || verbose {
+			output.PrintJSON(results)
+		}
+		return
+	}
+	for _, r := range results {
+		switch r.Action {
+		case ddns.ActionCreated:
+			output.Success("Created %s %s -> %s", r.Type, r.Name, r.Address)
+		case ddns.ActionUpdated:
+			output.Success("Updated %s %s: %s -> %s", r.Type, r.Name, r.Previous, r.Address)
+		default:
+			if verbose {
+				output.Success("%s %s is up to date (%s)", r.Type, r.Name, r.Address)
+			}
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(ddnsCmd)
+
+	ddnsCmd.Flags().String("name", "", "Subdomain to update (empty for the apex)")
+	ddnsCmd.Flags().String("ttl", "", "TTL in seconds for the record")
+	ddnsCmd.Flags().Bool("ipv4", true, "Update the A record")
+	ddnsCmd.Flags().Bool("ipv6", false, "Update the AAAA record")
+	ddnsCmd.Flags().String("interface", "", "Read the address from this network interface instead of HTTP")
+	ddnsCmd.Flags().StringSlice("ip-url", ddns.DefaultIPv4URLs, "HTTP endpoints that echo the public IPv4 address")
+	ddnsCmd.Flags().StringSlice("ip6-url", ddns.DefaultIPv6URLs, "HTTP endpoints that echo the public IPv6 address")
+	ddnsCmd.Flags().Bool("force", false, "Check the live record even if the state file says it is current")
+	ddnsCmd.Flags().Bool("watch", false, "Keep running and re-check every --interval")
+	ddnsCmd.Flags().Duration("interval", 5*time.Minute, "Time between checks with --watch")
+	ddnsCmd.Flags().String("state-file", "", "State file path (default: user cache dir)")
+	addValidationFlag(ddnsCmd)
+}
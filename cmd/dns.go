@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"strings"
+
 	"github.com/OverseedAI/overpork/internal/api"
 	"github.com/OverseedAI/overpork/internal/output"
+	"github.com/OverseedAI/overpork/internal/query"
+	"github.com/OverseedAI/overpork/internal/zone"
 	"github.com/spf13/cobra"
 )
 
@@ -14,27 +18,41 @@ var dnsCmd = &cobra.Command{
 var dnsListCmd = &cobra.Command{
 	Use:   "list <domain>",
 	Short: "List DNS records for a domain",
-	Args:  cobra.ExactArgs(1),
+	Long: `List DNS records for a domain.
+
+--type and --subdomain are passed to the API; --filter and --sort work on
+any field of the JSON output after the records are retrieved.
+
+Examples:
+  overpork dns list example.com --type MX
+  overpork dns list example.com --filter 'content~=^1\.2\.3\.' --sort -ttl
+  overpork dns list example.com --filter 'name=mail*' --filter 'ttl<3600'`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		domain := args[0]
 		recordType, _ := cmd.Flags().GetString("type")
 		subdomain, _ := cmd.Flags().GetString("subdomain")
+		q, err := listQuery(cmd)
+		if err != nil {
+			return err
+		}
 
 		var records []api.DNSRecord
-		var err error
-
 		if recordType != "" && subdomain != "" {
-			records, err = apiClient.DNSListByTypeAndSubdomain(domain, recordType, subdomain)
+			records, err = apiClient.DNSListByTypeAndSubdomainContext(cmd.Context(), domain, recordType, subdomain)
 		} else if recordType != "" {
-			records, err = apiClient.DNSListByType(domain, recordType)
+			records, err = apiClient.DNSListByTypeContext(cmd.Context(), domain, recordType)
 		} else {
-			records, err = apiClient.DNSList(domain)
+			records, err = apiClient.DNSListContext(cmd.Context(), domain)
 		}
 		if err != nil {
 			return err
 		}
+		if records, err = query.Apply(q, records); err != nil {
+			return err
+		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(records)
 			return nil
 		}
@@ -80,12 +98,17 @@ Examples:
 			Prio: prio,
 		}
 
-		id, err := apiClient.DNSCreate(domain, recordType, content, opts)
+		rec := zone.Record{Name: recordName(name), Type: strings.ToUpper(recordType), Content: content, TTL: ttl, Prio: prio}
+		if err := validateWrite(cmd, domain, rec, nil); err != nil {
+			return err
+		}
+
+		id, err := apiClient.DNSCreateContext(cmd.Context(), domain, recordType, content, opts)
 		if err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(map[string]any{"id": id, "status": "created"})
 		} else {
 			output.Success("Created record %d", id)
@@ -114,11 +137,16 @@ var dnsUpdateCmd = &cobra.Command{
 			Prio: prio,
 		}
 
-		if err := apiClient.DNSUpdate(domain, recordID, recordType, content, opts); err != nil {
+		rec := zone.Record{Name: recordName(name), Type: strings.ToUpper(recordType), Content: content, TTL: ttl, Prio: prio}
+		if err := validateWrite(cmd, domain, rec, func(r api.DNSRecord) bool { return r.ID == recordID }); err != nil {
+			return err
+		}
+
+		if err := apiClient.DNSUpdateContext(cmd.Context(), domain, recordID, recordType, content, opts); err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(map[string]string{"status": "updated"})
 		} else {
 			output.Success("Updated record %s", recordID)
@@ -158,11 +186,23 @@ Examples:
 			Prio: prio,
 		}
 
-		if err := apiClient.DNSUpdateByTypeAndSubdomain(domain, recordType, subdomain, content, opts); err != nil {
+		newName := subdomain
+		if cmd.Flags().Changed("name") {
+			newName = name
+		}
+		rec := zone.Record{Name: recordName(newName), Type: strings.ToUpper(recordType), Content: content, TTL: ttl, Prio: prio}
+		replaced := func(r api.DNSRecord) bool {
+			return strings.EqualFold(r.Type, recordType) && zone.RelativeName(r.Name, domain) == recordName(subdomain)
+		}
+		if err := validateWrite(cmd, domain, rec, replaced); err != nil {
+			return err
+		}
+
+		if err := apiClient.DNSUpdateByTypeAndSubdomainContext(cmd.Context(), domain, recordType, subdomain, content, opts); err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(map[string]string{"status": "updated"})
 		} else {
 			output.Success("Updated %s record for %s", recordType, subdomain)
@@ -179,11 +219,11 @@ var dnsDeleteCmd = &cobra.Command{
 		domain := args[0]
 		recordID := args[1]
 
-		if err := apiClient.DNSDelete(domain, recordID); err != nil {
+		if err := apiClient.DNSDeleteContext(cmd.Context(), domain, recordID); err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(map[string]string{"status": "deleted"})
 		} else {
 			output.Success("Deleted record %s", recordID)
@@ -211,11 +251,11 @@ Examples:
 			subdomain = ""
 		}
 
-		if err := apiClient.DNSDeleteByTypeAndSubdomain(domain, recordType, subdomain); err != nil {
+		if err := apiClient.DNSDeleteByTypeAndSubdomainContext(cmd.Context(), domain, recordType, subdomain); err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(map[string]string{"status": "deleted"})
 		} else {
 			displayName := subdomain
@@ -234,21 +274,25 @@ func init() {
 	dnsCmd.AddCommand(dnsListCmd)
 	dnsListCmd.Flags().StringP("type", "t", "", "Filter by record type (A, AAAA, MX, etc.)")
 	dnsListCmd.Flags().StringP("subdomain", "s", "", "Filter by subdomain (requires --type)")
+	addQueryFlags(dnsListCmd)
 
 	dnsCmd.AddCommand(dnsCreateCmd)
 	dnsCreateCmd.Flags().StringP("name", "n", "", "Subdomain (empty for root)")
 	dnsCreateCmd.Flags().String("ttl", "", "TTL in seconds")
 	dnsCreateCmd.Flags().String("prio", "", "Priority (for MX/SRV records)")
+	addValidationFlag(dnsCreateCmd)
 
 	dnsCmd.AddCommand(dnsUpdateCmd)
 	dnsUpdateCmd.Flags().StringP("name", "n", "", "Subdomain (empty for root)")
 	dnsUpdateCmd.Flags().String("ttl", "", "TTL in seconds")
 	dnsUpdateCmd.Flags().String("prio", "", "Priority (for MX/SRV records)")
+	addValidationFlag(dnsUpdateCmd)
 
 	dnsCmd.AddCommand(dnsSetCmd)
 	dnsSetCmd.Flags().StringP("name", "n", "", "New subdomain name")
 	dnsSetCmd.Flags().String("ttl", "", "TTL in seconds")
 	dnsSetCmd.Flags().String("prio", "", "Priority (for MX/SRV records)")
+	addValidationFlag(dnsSetCmd)
 
 	dnsCmd.AddCommand(dnsDeleteCmd)
 	dnsCmd.AddCommand(dnsDeleteByNameCmd)
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/OverseedAI/overpork/internal/output"
+	"github.com/OverseedAI/overpork/internal/zone"
+	"github.com/spf13/cobra"
+)
+
+var dnsCopyCmd = &cobra.Command{
+	Use:   "copy <src-domain> <dst-domain>",
+	Short: "Copy DNS records from one domain to another",
+	Long: `Copy the DNS records of one domain to another, rewriting content that
+refers to the source domain (CNAME and MX targets, SPF includes, ...) to
+the destination. Apex NS records are left alone since Porkbun manages them.
+
+Modes:
+  merge    Keep destination records the source doesn't have; records with
+           the same name and type take the source's values (default)
+  replace  Make the destination an exact copy, deleting everything else
+
+Examples:
+  overpork dns copy example.com example.net --dry-run
+  overpork dns copy example.com example.net --mode replace --yes
+  overpork dns copy example.com example.net --no-rewrite`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src, dst := strings.ToLower(args[0]), strings.ToLower(args[1])
+		mode, _ := cmd.Flags().GetString("mode")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		noRewrite, _ := cmd.Flags().GetBool("no-rewrite")
+		if mode != "merge" && mode != "replace" {
+			return fmt.Errorf("invalid mode %q: use merge or replace", mode)
+		}
+		if src == dst {
+			return fmt.Errorf("source and destination are the same domain")
+		}
+
+		srcRecords, err := apiClient.DNSListContext(cmd.Context(), src)
+		if err != nil {
+			return err
+		}
+		dstRecords, err := apiClient.DNSListContext(cmd.Context(), dst)
+		if err != nil {
+			return err
+		}
+
+		records := withoutApexNS(zone.FromAPI(src, srcRecords))
+		if !noRewrite {
+			records = zone.Rebase(records, src, dst)
+		}
+
+		plan := zone.Diff(dst, records, liveWithoutApexNS(dst, dstRecords), zone.DiffOptions{NoDelete: mode == "merge"})
+		if err := validatePlan(cmd, plan, dstRecords); err != nil {
+			return err
+		}
+
+		if dryRun {
+			if output.Structured() {
+				output.PrintJSON(plan)
+			} else {
+				printPlan(plan)
+			}
+			return nil
+		}
+		return applyPlan(cmd, plan, "copied")
+	},
+}
+
+func init() {
+	dnsCmd.AddCommand(dnsCopyCmd)
+	dnsCopyCmd.Flags().String("mode", "merge", "Copy mode: merge or replace")
+	dnsCopyCmd.Flags().Bool("dry-run", false, "Show changes without applying them")
+	dnsCopyCmd.Flags().Bool("no-rewrite", false, "Copy content verbatim instead of rewriting references to the source domain")
+	dnsCopyCmd.Flags().BoolP("yes", "y", false, "Apply without asking for confirmation")
+	addValidationFlag(dnsCopyCmd)
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/OverseedAI/overpork/internal/output"
+	"github.com/OverseedAI/overpork/internal/zone"
+	"github.com/OverseedAI/overpork/internal/zonelint"
+	"github.com/spf13/cobra"
+)
+
+// defaultLintConfig is read from the current directory when --config is
+// not given.
+const defaultLintConfig = ".opork-lint.yaml"
+
+var dnsLintCmd = &cobra.Command{
+	Use:   "lint [domain]",
+	Short: "Check a zone for problems across records",
+	Long: `Check a domain's records, or a zone file, for problems that per-record
+validation cannot see: dangling CNAMEs, CNAME conflicts, duplicates,
+MX/NS targets that are CNAMEs, missing CAA, TTLs differing within a set,
+wildcards shadowed by existing names, and private IP addresses.
+
+Rules can be disabled, ignored for some names, or given another severity
+in a YAML config file (--config, default ` + defaultLintConfig + ` if
+present). Exits with code 2 if any finding is at least as severe as
+--fail-on.
+
+Examples:
+  overpork dns lint example.com
+  overpork dns lint --file example.com.yaml --offline
+  overpork dns lint example.com --file example.com.zone
+  overpork dns lint example.com --fail-on warning --json
+  overpork dns lint --list-rules`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: map[string]string{offlineFlags: "file,list-rules"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if list, _ := cmd.Flags().GetBool("list-rules"); list {
+			printLintRules()
+			return nil
+		}
+		failOn, _ := cmd.Flags().GetString("fail-on")
+		threshold := zonelint.Severity(failOn)
+		if threshold != zonelint.SeverityError && threshold != zonelint.SeverityWarning && threshold != zonelint.SeverityInfo {
+			return fmt.Errorf("invalid --fail-on %q: use error, warning or info", failOn)
+		}
+
+		domain := ""
+		if len(args) > 0 {
+			domain = strings.ToLower(args[0])
+		}
+		domain, records, err := lintRecords(cmd, domain)
+		if err != nil {
+			return err
+		}
+		lintCfg, err := lintConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		opts := zonelint.Options{Config: lintCfg}
+		if offline, _ := cmd.Flags().GetBool("offline"); !offline {
+			opts.Resolver = net.DefaultResolver
+		}
+		findings := zonelint.Lint(cmd.Context(), domain, records, opts)
+		if err := cmd.Context().Err(); err != nil {
+			return err
+		}
+
+		if output.Structured() {
+			output.PrintJSON(findings)
+		} else if len(findings) == 0 {
+			output.Print("No problems found")
+		} else {
+			headers := []string{"SEVERITY", "RULE", "NAME", "TYPE", "MESSAGE"}
+			rows := make([][]string, len(findings))
+			for i, f := range findings {
+				rows[i] = []string{string(f.Severity), f.Rule + " " + f.RuleName, displayName(f.Name), f.Type, f.Message}
+			}
+			output.PrintTable(headers, rows)
+		}
+
+		failed := 0
+		for _, f := range findings {
+			if atLeast(f.Severity, threshold) {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return &checkFailed{msg: fmt.Sprintf("%d lint findings at %s level or above in %s", failed, threshold, domain)}
+		}
+		return nil
+	},
+}
+
+// lintRecords reads the zone to lint: the --file zone file (YAML, or BIND
+// for any other extension) or the domain's live records.
+func lintRecords(cmd *cobra.Command, domain string) (string, []zone.Record, error) {
+	file, _ := cmd.Flags().GetString("file")
+	if file == "" {
+		if domain == "" {
+			return "", nil, fmt.Errorf("give a domain or --file")
+		}
+		live, err := apiClient.DNSListContext(cmd.Context(), domain)
+		if err != nil {
+			return "", nil, err
+		}
+		return domain, zone.FromAPI(domain, live), nil
+	}
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		f, err := zone.Load(file, domain)
+		if err != nil {
+			return "", nil, err
+		}
+		if domain = f.Domain; domain == "" {
+			return "", nil, fmt.Errorf("zone file has no domain (set \"domain:\" or pass the domain)")
+		}
+		return domain, f.Records, nil
+	}
+	if domain == "" {
+		return "", nil, fmt.Errorf("pass the domain to lint a BIND zone file")
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to open zone file: %w", err)
+	}
+	defer f.Close()
+	records, err := zone.ParseBIND(f, domain)
+	return domain, records, err
+}
+
+// lintConfig loads --config, or the default config file if there is one.
+func lintConfig(cmd *cobra.Command) (*zonelint.Config, error) {
+	path, _ := cmd.Flags().GetString("config")
+	if path == "" {
+		if _, err := os.Stat(defaultLintConfig); errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		path = defaultLintConfig
+	}
+	return zonelint.LoadConfig(path)
+}
+
+func atLeast(s, threshold zonelint.Severity) bool {
+	order := map[zonelint.Severity]int{zonelint.SeverityError: 3, zonelint.SeverityWarning: 2, zonelint.SeverityInfo: 1}
+	return order[s] >= order[threshold]
+}
+
+func printLintRules() {
+	if output.Structured() {
+		output.PrintJSON(zonelint.Rules)
+		return
+	}
+	headers := []string{"CODE", "NAME", "SEVERITY", "DESCRIPTION"}
+	rows := make([][]string, len(zonelint.Rules))
+	for i, r := range zonelint.Rules {
+		rows[i] = []string{r.Code, r.Name, string(r.Severity), r.Description}
+	}
+	output.PrintTable(headers, rows)
+}
+
+func init() {
+	dnsCmd.AddCommand(dnsLintCmd)
+	dnsLintCmd.Flags().StringP("file", "f", "", "Lint a zone file (YAML, or BIND) instead of the live records")
+	dnsLintCmd.Flags().String("config", "", "Lint config file (default "+defaultLintConfig+" if present)")
+	dnsLintCmd.Flags().Bool("offline", false, "Skip checks that need DNS lookups")
+	dnsLintCmd.Flags().String("fail-on", string(zonelint.SeverityError), "Exit with code 2 on findings of this severity or worse: error, warning or info")
+	dnsLintCmd.Flags().Bool("list-rules", false, "List the lint rules and exit")
+}
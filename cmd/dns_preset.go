@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/OverseedAI/overpork/internal/config"
+	"github.com/OverseedAI/overpork/internal/output"
+	"github.com/OverseedAI/overpork/internal/preset"
+	"github.com/spf13/cobra"
+)
+
+var dnsPresetCmd = &cobra.Command{
+	Use:   "preset",
+	Short: "Add the DNS records email providers ask for",
+	Long: `Presets are templates of the records a provider needs (MX, SPF, DKIM,
+DMARC, autodiscovery...). Built-in presets cover Google Workspace,
+Microsoft 365, Fastmail, Proton and Amazon SES; YAML files in the presets
+directory of the config dir add more or replace built-in ones.`,
+}
+
+var dnsPresetListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available presets",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		presets, err := loadPresets()
+		if err != nil {
+			return err
+		}
+
+		if output.Structured() {
+			output.PrintJSON(presets)
+			return nil
+		}
+		headers := []string{"NAME", "DESCRIPTION", "SOURCE"}
+		rows := make([][]string, len(presets))
+		for i, p := range presets {
+			rows[i] = []string{p.Name, p.Description, p.Source}
+		}
+		output.PrintTable(headers, rows)
+		return nil
+	},
+}
+
+var dnsPresetShowCmd = &cobra.Command{
+	Use:   "show <preset>",
+	Short: "Show a preset's parameters and records",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := findPreset(args[0])
+		if err != nil {
+			return err
+		}
+
+		if output.Structured() {
+			output.PrintJSON(p)
+			return nil
+		}
+		output.Print(fmt.Sprintf("%s: %s", p.Name, p.Description))
+		if len(p.Params) > 0 {
+			output.Print("")
+			headers := []string{"PARAM", "DEFAULT", "REQUIRED", "DESCRIPTION"}
+			rows := make([][]string, len(p.Params))
+			for i, param := range p.Params {
+				required := ""
+				if param.Required {
+					required = "yes"
+				}
+				rows[i] = []string{param.Name, param.Default, required, param.Description}
+			}
+			output.PrintTable(headers, rows)
+		}
+		output.Print("")
+		headers := []string{"TYPE", "NAME", "CONTENT", "PRIO", "WHEN"}
+		rows := make([][]string, len(p.Records))
+		for i, r := range p.Records {
+			rows[i] = []string{r.Type, displayName(r.Name), r.Content, r.Prio, r.When}
+		}
+		output.PrintTable(headers, rows)
+		return nil
+	},
+}
+
+var dnsPresetApplyCmd = &cobra.Command{
+	Use:   "apply <preset> <domain>",
+	Short: "Create a preset's records on a domain",
+	Long: `Create the records of a preset on a domain. Records that already exist
+are skipped. Existing records the preset would clash with (another
+provider's MX, a second SPF or DMARC policy, anything at a CNAME's name)
+stop the command unless --replace is given to delete them.
+
+Examples:
+  overpork dns preset apply google-workspace example.com --dry-run
+  overpork dns preset apply google-workspace example.com --param dkim_key=MIIBIjANBg...
+  overpork dns preset apply proton example.com -p verification=abc123 -p dkim_id=xyz
+  overpork dns preset apply fastmail example.com --replace --yes`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domain := args[1]
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		replace, _ := cmd.Flags().GetBool("replace")
+		pairs, _ := cmd.Flags().GetStringArray("param")
+		values := make(map[string]string, len(pairs))
+		for _, pair := range pairs {
+			k, v, ok := strings.Cut(pair, "=")
+			if !ok || k == "" {
+				return fmt.Errorf("invalid parameter %q: use name=value", pair)
+			}
+			values[k] = v
+		}
+
+		p, err := findPreset(args[0])
+		if err != nil {
+			return err
+		}
+		records, err := p.Render(domain, values)
+		if err != nil {
+			return err
+		}
+		live, err := apiClient.DNSListContext(cmd.Context(), domain)
+		if err != nil {
+			return err
+		}
+		plan, conflicts := preset.Plan(domain, records, live, replace)
+		// Unresolved conflicts are reported below in the preset's terms.
+		if len(conflicts) == 0 || replace {
+			if err := validatePlan(cmd, plan, live); err != nil {
+				return err
+			}
+		}
+
+		if dryRun {
+			if output.Structured() {
+				output.PrintJSON(map[string]any{"plan": plan, "conflicts": conflicts})
+			} else {
+				printPlan(plan)
+				printConflicts(conflicts, replace)
+			}
+			return nil
+		}
+		if len(conflicts) > 0 && !replace {
+			if !output.Structured() {
+				printConflicts(conflicts, replace)
+			}
+			return fmt.Errorf("%d existing records conflict with preset %s (use --replace to delete them)", len(conflicts), p.Name)
+		}
+		return applyPlan(cmd, plan, "applied")
+	},
+}
+
+func loadPresets() ([]*preset.Preset, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return preset.Builtin()
+	}
+	return preset.Load(filepath.Join(dir, "presets"))
+}
+
+func findPreset(name string) (*preset.Preset, error) {
+	presets, err := loadPresets()
+	if err != nil {
+		return nil, err
+	}
+	p := preset.Find(presets, name)
+	if p == nil {
+		names := make([]string, len(presets))
+		for i, p := range presets {
+			names[i] = p.Name
+		}
+		return nil, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	return p, nil
+}
+
+func printConflicts(conflicts []preset.Conflict, replace bool) {
+	for _, c := range conflicts {
+		msg := fmt.Sprintf("%s %s %q (%s): %s", c.Record.Type, displayName(c.Record.Name), c.Record.Content, c.ID, c.Reason)
+		if replace {
+			output.Warn("replacing %s", msg)
+		} else {
+			output.Warn("conflict: %s", msg)
+		}
+	}
+}
+
+func init() {
+	dnsCmd.AddCommand(dnsPresetCmd)
+	dnsPresetCmd.AddCommand(dnsPresetListCmd)
+	dnsPresetCmd.AddCommand(dnsPresetShowCmd)
+
+	dnsPresetCmd.AddCommand(dnsPresetApplyCmd)
+	dnsPresetApplyCmd.Flags().StringArrayP("param", "p", nil, "Preset parameter as name=value (repeatable)")
+	dnsPresetApplyCmd.Flags().Bool("replace", false, "Delete existing records that conflict with the preset")
+	dnsPresetApplyCmd.Flags().Bool("dry-run", false, "Show changes without applying them")
+	dnsPresetApplyCmd.Flags().BoolP("yes", "y", false, "Apply without asking for confirmation")
+	addValidationFlag(dnsPresetApplyCmd)
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/OverseedAI/overpork/internal/output"
+	"github.com/OverseedAI/overpork/internal/search"
+	"github.com/spf13/cobra"
+)
+
+var dnsReplaceCmd = &cobra.Command{
+	Use:   "replace",
+	Short: "Replace record content across domains",
+	Long: `Find records whose content is exactly --from and change it to --to,
+in the domains given with --domains or in every domain with --all.
+
+The affected records are shown first and updated after confirmation. Each
+update is reported separately, and the previous content is saved to a
+rollback file that --rollback replays to undo the change; records edited
+or deleted since are skipped with a warning. With --partial, --from is
+replaced wherever it occurs in the content, e.g. inside SPF records.
+
+Examples:
+  overpork dns replace --from 203.0.113.10 --to 198.51.100.7 --all --dry-run
+  overpork dns replace --from 203.0.113.10 --to 198.51.100.7 --type A --domains a.com,b.com
+  overpork dns replace --from 203.0.113.10 --to 198.51.100.7 --all --partial --yes
+  overpork dns replace --rollback overpork-rollback-20250101-120000.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if path, _ := cmd.Flags().GetString("rollback"); path != "" {
+			for _, name := range []string{"from", "to", "all", "domains", "type", "partial"} {
+				if cmd.Flags().Changed(name) {
+					return fmt.Errorf("--rollback cannot be combined with --%s", name)
+				}
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return fmt.Errorf("failed to open rollback file: %w", err)
+			}
+			defer f.Close()
+			rb, err := search.ReadRollback(f)
+			if err != nil {
+				return err
+			}
+			return rollbackReplacements(cmd, rb)
+		}
+
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		if from == "" || !cmd.Flags().Changed("to") {
+			return fmt.Errorf("--from and --to are required")
+		}
+		all, _ := cmd.Flags().GetBool("all")
+		domains, _ := cmd.Flags().GetStringSlice("domains")
+		if all == (len(domains) > 0) {
+			return fmt.Errorf("use either --domains or --all")
+		}
+		mode := search.ModeExact
+		if partial, _ := cmd.Flags().GetBool("partial"); partial {
+			mode = search.ModeSubstring
+		}
+		m, err := search.NewMatcher(from, mode, search.FieldContent)
+		if err != nil {
+			return err
+		}
+
+		zones, err := fetchZones(cmd)
+		if err != nil {
+			return err
+		}
+		recordType, _ := cmd.Flags().GetString("type")
+		hits := search.Find(zones, m, recordType)
+		reps := search.Replacements(hits, func(content string) string { return m.Replace(content, to) })
+		return applyReplacements(cmd, reps, zones)
+	},
+}
+
+// rollbackReplacements restores the content saved in a rollback file.
+// Records edited or deleted since the replace are left alone.
+func rollbackReplacements(cmd *cobra.Command, rb *search.Rollback) error {
+	var domains []string
+	for _, rep := range rb.Replacements {
+		if !slices.ContainsFunc(domains, func(d string) bool { return strings.EqualFold(d, rep.Domain) }) {
+			domains = append(domains, rep.Domain)
+		}
+	}
+	zones, err := fetchDomainZones(cmd, domains)
+	if err != nil {
+		return err
+	}
+	reps, skipped := search.Unchanged(search.Invert(rb.Replacements), zones)
+	for _, s := range skipped {
+		output.Warn("Skipping %s", s)
+	}
+	return applyReplacements(cmd, reps, zones)
+}
+
+func init() {
+	dnsCmd.AddCommand(dnsReplaceCmd)
+	dnsReplaceCmd.Flags().String("from", "", "Record content to replace")
+	dnsReplaceCmd.Flags().String("to", "", "New record content")
+	dnsReplaceCmd.Flags().StringP("type", "t", "", "Only change records of this type")
+	dnsReplaceCmd.Flags().StringSlice("domains", nil, "Domains to change")
+	dnsReplaceCmd.Flags().Bool("all", false, "Change every domain in the account")
+	dnsReplaceCmd.Flags().Bool("partial", false, "Replace --from wherever it occurs in the content")
+	dnsReplaceCmd.Flags().Int("concurrency", 4, "Domains to fetch in parallel")
+	dnsReplaceCmd.Flags().Bool("dry-run", false, "Show changes without applying them")
+	addValidationFlag(dnsReplaceCmd)
+	dnsReplaceCmd.Flags().BoolP("yes", "y", false, "Apply without asking for confirmation")
+	dnsReplaceCmd.Flags().String("rollback-file", "", "Where to save previous content (default overpork-rollback-<time>.json)")
+	dnsReplaceCmd.Flags().String("rollback", "", "Undo the changes saved in a rollback file")
+}
@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"github.com/OverseedAI/overpork/internal/dnsvalidate"
+	"github.com/OverseedAI/overpork/internal/output"
+	"github.com/OverseedAI/overpork/internal/search"
+	"github.com/OverseedAI/overpork/internal/zone"
+	"github.com/spf13/cobra"
+)
+
+var dnsSearchCmd = &cobra.Command{
+	Use:   "search <pattern>",
+	Short: "Find records by name or content across all domains",
+	Long: `Search the DNS records of every domain in the account (or those given
+with --domains) and print the ones whose name or content matches.
+
+The pattern is a case-insensitive substring by default, or a glob with
+--glob or a regular expression with --regex. With --replace-with, the
+matching part of each record's content is rewritten and the records are
+updated after confirmation.
+
+Examples:
+  overpork dns search 203.0.113.10
+  overpork dns search 'mail*' --glob --in name
+  overpork dns search '^203\.0\.113\.' --regex --type A
+  overpork dns search old-host.example.net --replace-with new-host.example.net`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		recordType, _ := cmd.Flags().GetString("type")
+		field, _ := cmd.Flags().GetString("in")
+		glob, _ := cmd.Flags().GetBool("glob")
+		regex, _ := cmd.Flags().GetBool("regex")
+		mode := search.ModeSubstring
+		switch {
+		case glob && regex:
+			return fmt.Errorf("--glob and --regex cannot be combined")
+		case glob:
+			mode = search.ModeGlob
+		case regex:
+			mode = search.ModeRegex
+		}
+		m, err := search.NewMatcher(args[0], mode, field)
+		if err != nil {
+			return err
+		}
+
+		zones, err := fetchZones(cmd)
+		if err != nil {
+			return err
+		}
+		hits := search.Find(zones, m, recordType)
+
+		if cmd.Flags().Changed("replace-with") {
+			with, _ := cmd.Flags().GetString("replace-with")
+			reps := search.Replacements(hits, func(content string) string { return m.Replace(content, with) })
+			return applyReplacements(cmd, reps, zones)
+		}
+
+		if output.Structured() {
+			output.PrintJSON(hits)
+			return nil
+		}
+		if len(hits) == 0 {
+			output.Print("No matching records")
+			return nil
+		}
+
+		headers := []string{"DOMAIN", "ID", "TYPE", "NAME", "CONTENT"}
+		rows := make([][]string, len(hits))
+		for i, h := range hits {
+			rows[i] = []string{h.Domain, h.ID, h.Type, h.Name, h.Content}
+		}
+		output.PrintTable(headers, rows)
+		return nil
+	},
+}
+
+// fetchZones reads the records of the domains named with --domains, or of
+// every domain in the account. Domains that cannot be read are reported
+// and skipped.
+func fetchZones(cmd *cobra.Command) ([]search.Zone, error) {
+	domains, _ := cmd.Flags().GetStringSlice("domains")
+	if len(domains) == 0 {
+		all, err := apiClient.DomainListAllContext(cmd.Context())
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range all {
+			domains = append(domains, d.Domain)
+		}
+	}
+	return fetchDomainZones(cmd, domains)
+}
+
+// fetchDomainZones reads the records of domains, reporting and skipping
+// those that cannot be read.
+func fetchDomainZones(cmd *cobra.Command, domains []string) ([]search.Zone, error) {
+	ctx := cmd.Context()
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	if concurrency < 1 {
+		return nil, fmt.Errorf("concurrency must be at least 1")
+	}
+
+	var zones []search.Zone
+	for _, z := range search.Fetch(ctx, apiClient, domains, concurrency) {
+		if z.Err != nil {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			output.Warn("%s: %v", z.Domain, z.Err)
+			continue
+		}
+		zones = append(zones, z)
+	}
+	if len(zones) == 0 && len(domains) > 0 {
+		return nil, fmt.Errorf("could not read records of any domain")
+	}
+	return zones, nil
+}
+
+// applyReplacements previews content changes, asks for confirmation and
+// applies them, reporting each record. zones are the live records the
+// replacements were found in.
+func applyReplacements(cmd *cobra.Command, reps []search.Replacement, zones []search.Zone) error {
+	if len(reps) == 0 {
+		if output.Structured() {
+			output.PrintJSON(map[string]any{"applied": 0, "status": "unchanged"})
+		} else {
+			output.Print("No records to change")
+		}
+		return nil
+	}
+
+	if err := validateReplacements(cmd, reps, zones); err != nil {
+		return err
+	}
+
+	if !output.Structured() {
+		printReplacements(reps)
+	}
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		if output.Structured() {
+			output.PrintJSON(reps)
+		}
+		return nil
+	}
+	ok, err := confirm(cmd, fmt.Sprintf("Update %d records in %d domains?", len(reps), countDomains(reps)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("aborted")
+	}
+
+	rollback, err := writeRollback(cmd, reps)
+	if err != nil {
+		return err
+	}
+	outcomes := search.Apply(cmd.Context(), apiClient, reps)
+	return reportOutcomes(cmd, outcomes, rollback)
+}
+
+// validateReplacements checks the new content of the replaced records
+// against the rest of their domain's records.
+func validateReplacements(cmd *cobra.Command, reps []search.Replacement, zones []search.Zone) error {
+	if skip, _ := cmd.Flags().GetBool("skip-validation"); skip {
+		return nil
+	}
+	byDomain := map[string][]search.Replacement{}
+	var domains []string
+	for _, r := range reps {
+		if _, ok := byDomain[r.Domain]; !ok {
+			domains = append(domains, r.Domain)
+		}
+		byDomain[r.Domain] = append(byDomain[r.Domain], r)
+	}
+
+	var errs []error
+	for _, domain := range domains {
+		replaced := map[string]bool{}
+		var changed []zone.Record
+		for _, r := range byDomain[domain] {
+			replaced[r.Record.ID] = true
+			rec := zone.FromAPI(domain, []api.DNSRecord{r.Record})[0]
+			rec.Content = r.Content
+			changed = append(changed, rec)
+		}
+		var existing []zone.Record
+		if i := slices.IndexFunc(zones, func(z search.Zone) bool { return strings.EqualFold(z.Domain, domain) }); i >= 0 {
+			live := zones[i].Records
+			for j, rec := range zone.FromAPI(domain, live) {
+				if !replaced[live[j].ID] {
+					existing = append(existing, rec)
+				}
+			}
+		}
+		if err := dnsvalidate.Check(existing, changed); err != nil {
+			// Say which domain each problem is in.
+			for _, e := range unjoin(err) {
+				errs = append(errs, fmt.Errorf("%s: %w", domain, e))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid records (use --skip-validation to send them anyway):\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// unjoin splits an errors.Join error into its parts.
+func unjoin(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
+
+// writeRollback saves the previous content of the records about to change
+// to --rollback-file, or to a timestamped file in the current directory.
+func writeRollback(cmd *cobra.Command, reps []search.Replacement) (string, error) {
+	path, _ := cmd.Flags().GetString("rollback-file")
+	if path == "" {
+		path = fmt.Sprintf("overpork-rollback-%s.json", time.Now().Format("20060102-150405"))
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create rollback file: %w", err)
+	}
+	defer f.Close()
+	if err := search.WriteRollback(f, reps); err != nil {
+		return "", fmt.Errorf("failed to write rollback file: %w", err)
+	}
+	return path, f.Close()
+}
+
+func printReplacements(reps []search.Replacement) {
+	headers := []string{"DOMAIN", "ID", "TYPE", "NAME", "CONTENT"}
+	rows := make([][]string, len(reps))
+	for i, r := range reps {
+		rows[i] = []string{r.Domain, r.Record.ID, r.Record.Type, r.Record.Name, r.Record.Content + " -> " + r.Content}
+	}
+	output.PrintTable(headers, rows)
+	output.Print("")
+}
+
+// reportOutcomes prints the result of every update and fails if any did.
+func reportOutcomes(cmd *cobra.Command, outcomes []search.Outcome, rollback string) error {
+	failed := 0
+	type result struct {
+		search.Replacement
+		Status string `json:"status"`
+		Error  string `json:"error,omitempty"`
+	}
+	results := make([]result, len(outcomes))
+	for i, o := range outcomes {
+		results[i] = result{Replacement: o.Replacement, Status: "updated"}
+		if o.Err != nil {
+			failed++
+			results[i].Status, results[i].Error = "failed", o.Err.Error()
+		}
+	}
+
+	if output.Structured() {
+		output.PrintJSON(map[string]any{"rollbackFile": rollback, "results": results})
+	} else {
+		for _, r := range results {
+			if r.Error != "" {
+				output.Error("%s %s (%s): %s", r.Domain, r.Record.Name, r.Record.ID, r.Error)
+			} else {
+				output.Success("Updated %s %s %s (%s)", r.Domain, r.Record.Type, r.Record.Name, r.Record.ID)
+			}
+		}
+		output.Print(fmt.Sprintf("Undo with: %s dns replace --rollback %s", cmd.Root().Name(), rollback))
+	}
+
+	if err := cmd.Context().Err(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return &checkFailed{msg: fmt.Sprintf("%d of %d updates failed", failed, len(outcomes))}
+	}
+	return nil
+}
+
+func countDomains(reps []search.Replacement) int {
+	seen := map[string]bool{}
+	for _, r := range reps {
+		seen[strings.ToLower(r.Domain)] = true
+	}
+	return len(seen)
+}
+
+func init() {
+	dnsCmd.AddCommand(dnsSearchCmd)
+	dnsSearchCmd.Flags().StringP("type", "t", "", "Only match records of this type")
+	dnsSearchCmd.Flags().String("in", search.FieldAny, "Field to match: name, content or any")
+	dnsSearchCmd.Flags().Bool("glob", false, "Treat the pattern as a glob (* and ?)")
+	dnsSearchCmd.Flags().Bool("regex", false, "Treat the pattern as a regular expression")
+	dnsSearchCmd.Flags().StringSlice("domains", nil, "Only search these domains (default: every domain in the account)")
+	dnsSearchCmd.Flags().Int("concurrency", 4, "Domains to fetch in parallel")
+	dnsSearchCmd.Flags().String("replace-with", "", "Rewrite the matching part of each record's content and update it")
+	dnsSearchCmd.Flags().Bool("dry-run", false, "With --replace-with, show changes without applying them")
+	dnsSearchCmd.Flags().BoolP("yes", "y", false, "With --replace-with, apply without asking for confirmation")
+	dnsSearchCmd.Flags().String("rollback-file", "", "With --replace-with, where to save previous content (default overpork-rollback-<time>.json)")
+	addValidationFlag(dnsSearchCmd)
+}
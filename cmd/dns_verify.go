@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"github.com/OverseedAI/overpork/internal/output"
+	"github.com/OverseedAI/overpork/internal/resolve"
+	"github.com/spf13/cobra"
+)
+
+// publicResolvers are checked with --public.
+var publicResolvers = []string{"1.1.1.1", "8.8.8.8", "9.9.9.9"}
+
+var dnsVerifyCmd = &cobra.Command{
+	Use:   "verify <domain>",
+	Short: "Check that DNS records are served by the nameservers",
+	Long: `Resolve every record of a domain directly against its nameservers and
+report whether each one matches what Porkbun has stored.
+
+The nameservers come from the domain's registration; override them with
+--nameserver (e.g. a local test server at 127.0.0.1:5353). Add public
+resolvers with --public or --resolver to see what clients get. ALIAS
+records cannot be queried and are reported as skipped.
+
+Exits with status 2 when any record does not match.
+
+Examples:
+  overpork dns verify example.com
+  overpork dns verify example.com --type TXT --wait
+  overpork dns verify example.com --public
+  overpork dns verify example.com --nameserver 127.0.0.1:5353`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domain := strings.ToLower(args[0])
+		recordType, _ := cmd.Flags().GetString("type")
+		nameservers, _ := cmd.Flags().GetStringSlice("nameserver")
+		resolvers, _ := cmd.Flags().GetStringSlice("resolver")
+		public, _ := cmd.Flags().GetBool("public")
+		wait, _ := cmd.Flags().GetBool("wait")
+		timeout, _ := cmd.Flags().GetDuration("wait-timeout")
+		interval, _ := cmd.Flags().GetDuration("interval")
+
+		var records []api.DNSRecord
+		var err error
+		if recordType != "" {
+			records, err = apiClient.DNSListByTypeContext(cmd.Context(), domain, strings.ToUpper(recordType))
+		} else {
+			records, err = apiClient.DNSListContext(cmd.Context(), domain)
+		}
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			if output.Structured() {
+				output.PrintJSON([]resolve.Check{})
+				return nil
+			}
+			output.Print("No records found")
+			return nil
+		}
+
+		if len(nameservers) == 0 {
+			if nameservers, err = apiClient.DomainGetNameserversContext(cmd.Context(), domain); err != nil {
+				return err
+			}
+		}
+		if public {
+			resolvers = append(resolvers, publicResolvers...)
+		}
+
+		r := &resolve.Resolver{}
+		servers, err := verifyServers(cmd.Context(), r, append(nameservers, resolvers...))
+		if err != nil {
+			return err
+		}
+
+		want := expectedRecords(records)
+		var checks []resolve.Check
+		check := func(ctx context.Context) (bool, error) {
+			checks = r.Verify(ctx, servers, want)
+			failed := countFailed(checks)
+			if wait && failed > 0 && !output.Structured() {
+				fmt.Fprintf(output.Stderr, "Waiting: %d of %d checks not matching...\n", failed, len(checks))
+			}
+			return failed == 0, nil
+		}
+		if wait {
+			// Giving up is reported through the failing checks below.
+			_ = pollUntil(cmd.Context(), timeout, interval, check)
+		} else {
+			_, _ = check(cmd.Context())
+		}
+		if err := cmd.Context().Err(); err != nil {
+			return err
+		}
+
+		printChecks(checks)
+		if failed := countFailed(checks); failed > 0 {
+			return &checkFailed{fmt.Sprintf("%d of %d checks did not match", failed, len(checks))}
+		}
+		return nil
+	},
+}
+
+// verifyServers resolves nameserver and resolver addresses.
+func verifyServers(ctx context.Context, r *resolve.Resolver, addrs []string) ([]resolve.Server, error) {
+	var servers []resolve.Server
+	for _, a := range addrs {
+		s, err := r.ServerAddr(ctx, a)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve nameserver %s: %w", a, err)
+		}
+		servers = append(servers, s)
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("no nameservers to check")
+	}
+	return servers, nil
+}
+
+// expectedRecords groups Porkbun records into record sets by name and type.
+func expectedRecords(records []api.DNSRecord) []resolve.Expected {
+	var want []resolve.Expected
+	index := map[string]int{}
+	for _, r := range records {
+		name := strings.ToLower(r.Name)
+		key := name + "|" + r.Type
+		i, ok := index[key]
+		if !ok {
+			i = len(want)
+			index[key] = i
+			want = append(want, resolve.Expected{Name: name, Type: r.Type})
+		}
+		want[i].Values = append(want[i].Values, resolve.Value(r.Type, r.Content, r.Prio))
+	}
+	return want
+}
+
+// countFailed counts checks that neither matched nor were skipped.
+func countFailed(checks []resolve.Check) int {
+	n := 0
+	for _, c := range checks {
+		if c.Status != resolve.StatusMatch && c.Status != resolve.StatusSkipped {
+			n++
+		}
+	}
+	return n
+}
+
+func printChecks(checks []resolve.Check) {
+	if output.Structured() {
+		output.PrintJSON(checks)
+		return
+	}
+
+	headers := []string{"NAME", "TYPE", "SERVER", "STATUS", "EXPECTED", "GOT"}
+	rows := make([][]string, len(checks))
+	for i, c := range checks {
+		got := strings.Join(c.Got, ", ")
+		if c.Error != "" {
+			got = c.Error
+		}
+		rows[i] = []string{c.Name, c.Type, c.Server, string(c.Status), strings.Join(c.Expected, ", "), got}
+	}
+	output.PrintTable(headers, rows)
+}
+
+func init() {
+	dnsCmd.AddCommand(dnsVerifyCmd)
+	dnsVerifyCmd.Flags().StringP("type", "t", "", "Only verify records of this type")
+	dnsVerifyCmd.Flags().StringSlice("nameserver", nil, "Nameserver to query, host or host:port (default: the domain's nameservers)")
+	dnsVerifyCmd.Flags().StringSlice("resolver", nil, "Also query this recursive resolver, host or host:port")
+	dnsVerifyCmd.Flags().Bool("public", false, "Also query public resolvers ("+strings.Join(publicResolvers, ", ")+")")
+	dnsVerifyCmd.Flags().Bool("wait", false, "Poll until every record matches")
+	dnsVerifyCmd.Flags().Duration("wait-timeout", 5*time.Minute, "How long to wait with --wait")
+	dnsVerifyCmd.Flags().Duration("interval", 10*time.Second, "Time between checks with --wait")
+}
@@ -0,0 +1,347 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"github.com/OverseedAI/overpork/internal/output"
+	"github.com/OverseedAI/overpork/internal/zone"
+	"github.com/spf13/cobra"
+)
+
+var dnsPlanCmd = &cobra.Command{
+	Use:   "plan <zone-file>",
+	Short: "Show changes needed to make live records match a zone file",
+	Long: `Compare a YAML zone file with the live DNS records of its domain and
+print the records that would be created, updated or deleted. Apex NS
+records are ignored on both sides since Porkbun manages them; use
+"domain ns-set" to change nameservers.
+
+Zone file format:
+  domain: example.com
+  records:
+    - name: "@"
+      type: A
+      content: 192.168.1.1
+      ttl: 600
+    - name: www
+      type: CNAME
+      content: example.com
+    - type: MX
+      content: mail.example.com
+      prio: 10
+
+Examples:
+  overpork dns plan example.com.yaml
+  overpork dns plan example.com.yaml --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		plan, err := planZone(cmd, args[0])
+		if err != nil {
+			return err
+		}
+
+		if output.Structured() {
+			output.PrintJSON(plan)
+			return nil
+		}
+
+		printPlan(plan)
+		return nil
+	},
+}
+
+var dnsApplyCmd = &cobra.Command{
+	Use:   "apply <zone-file>",
+	Short: "Apply a zone file to live DNS records",
+	Long: `Compute the plan for a YAML zone file (see "dns plan") and apply it.
+Deletes run first, then updates, then creates.
+
+Examples:
+  overpork dns apply example.com.yaml
+  overpork dns apply example.com.yaml --yes --no-delete`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		plan, err := planZone(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		return applyPlan(cmd, plan, "applied")
+	},
+}
+
+var dnsExportCmd = &cobra.Command{
+	Use:   "export <domain>",
+	Short: "Export DNS records as a BIND zone file or YAML zone file",
+	Long: `Export the live DNS records of a domain.
+
+Formats:
+  bind  RFC 1035 master file
+  yaml  Zone file for "dns plan" and "dns apply"
+
+Examples:
+  overpork dns export example.com > example.com.zone
+  overpork dns export example.com --format yaml --file example.com.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domain := args[0]
+		format, _ := cmd.Flags().GetString("format")
+		path, _ := cmd.Flags().GetString("file")
+
+		live, err := apiClient.DNSListContext(cmd.Context(), domain)
+		if err != nil {
+			return err
+		}
+		records := zone.FromAPI(domain, live)
+
+		var w io.Writer = output.Stdout
+		if path != "" {
+			f, err := os.Create(path)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", path, err)
+			}
+			defer f.Close()
+			w = f
+		}
+
+		switch format {
+		case "bind":
+			if err := zone.WriteBIND(w, domain, records); err != nil {
+				return err
+			}
+		case "yaml":
+			data, err := (&zone.File{Domain: domain, Records: records}).Marshal()
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("invalid format %q: use bind or yaml", format)
+		}
+
+		if path != "" {
+			if output.Structured() {
+				output.PrintJSON(map[string]any{"path": path, "records": len(records), "status": "exported"})
+			} else {
+				output.Success("Exported %d records to %s", len(records), path)
+			}
+		}
+		return nil
+	},
+}
+
+var dnsImportCmd = &cobra.Command{
+	Use:   "import <domain> <zone-file>",
+	Short: "Import records from a BIND zone file",
+	Long: `Import records from an RFC 1035 master file ("-" reads stdin).
+
+Records that already exist are left alone and missing ones are created;
+existing records are never changed, even with the same name and type.
+With --replace, live records are made to match the file: records are
+updated in place, and records absent from the file are deleted.
+SOA and apex NS records are skipped since Porkbun manages them.
+
+Examples:
+  overpork dns import example.com example.com.zone --dry-run
+  overpork dns import example.com example.com.zone --yes
+  overpork dns import example.com example.com.zone --replace`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domain := strings.ToLower(args[0])
+		path := args[1]
+		replace, _ := cmd.Flags().GetBool("replace")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		var r io.Reader = os.Stdin
+		if path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				return fmt.Errorf("failed to open zone file: %w", err)
+			}
+			defer f.Close()
+			r = f
+		}
+
+		parsed, err := zone.ParseBIND(r, domain)
+		if err != nil {
+			return err
+		}
+		records := withoutApexNS(parsed)
+
+		live, err := apiClient.DNSListContext(cmd.Context(), domain)
+		if err != nil {
+			return err
+		}
+
+		plan := zone.Diff(domain, records, liveWithoutApexNS(domain, live), zone.DiffOptions{CreateOnly: !replace})
+		if err := validatePlan(cmd, plan, live); err != nil {
+			return err
+		}
+
+		if dryRun {
+			if output.Structured() {
+				output.PrintJSON(plan)
+			} else {
+				printPlan(plan)
+			}
+			return nil
+		}
+		return applyPlan(cmd, plan, "imported")
+	},
+}
+
+func isApexNS(name, recordType string) bool {
+	return name == "" && strings.EqualFold(recordType, "NS")
+}
+
+// withoutApexNS drops apex NS records, which Porkbun manages itself.
+func withoutApexNS(records []zone.Record) []zone.Record {
+	var kept []zone.Record
+	for _, r := range records {
+		if !isApexNS(r.Name, r.Type) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// liveWithoutApexNS drops apex NS records from the live records of domain.
+func liveWithoutApexNS(domain string, records []api.DNSRecord) []api.DNSRecord {
+	var kept []api.DNSRecord
+	for _, r := range records {
+		if !isApexNS(zone.RelativeName(r.Name, domain), r.Type) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// applyPlan shows a plan, asks for confirmation and applies it. status is
+// reported in JSON output on success.
+func applyPlan(cmd *cobra.Command, plan *zone.Plan, status string) error {
+	if plan.Empty() {
+		if output.Structured() {
+			output.PrintJSON(map[string]any{"domain": plan.Domain, "applied": 0, "status": "unchanged"})
+		} else {
+			output.Print("No changes")
+		}
+		return nil
+	}
+
+	if !output.Structured() {
+		printPlan(plan)
+	}
+	ok, err := confirm(cmd, fmt.Sprintf("Apply %d changes to %s?", len(plan.Changes), plan.Domain))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("aborted")
+	}
+
+	applied, err := plan.Apply(cmd.Context(), apiClient)
+	if err != nil {
+		return fmt.Errorf("applied %d of %d changes: %w", applied, len(plan.Changes), err)
+	}
+
+	if output.Structured() {
+		output.PrintJSON(map[string]any{"domain": plan.Domain, "applied": applied, "status": status, "changes": plan.Changes})
+	} else {
+		output.Success("Applied %d changes to %s", applied, plan.Domain)
+	}
+	return nil
+}
+
+// planZone loads a zone file and diffs it against the live records.
+func planZone(cmd *cobra.Command, path string) (*zone.Plan, error) {
+	domain, _ := cmd.Flags().GetString("domain")
+	f, err := zone.Load(path, domain)
+	if err != nil {
+		return nil, err
+	}
+	if f.Domain == "" {
+		return nil, fmt.Errorf("zone file has no domain (set \"domain:\" or use --domain)")
+	}
+
+	live, err := apiClient.DNSListContext(cmd.Context(), f.Domain)
+	if err != nil {
+		return nil, err
+	}
+
+	noDelete, _ := cmd.Flags().GetBool("no-delete")
+	plan := zone.Diff(f.Domain, withoutApexNS(f.Records), liveWithoutApexNS(f.Domain, live), zone.DiffOptions{NoDelete: noDelete})
+	if err := validatePlan(cmd, plan, live); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func printPlan(plan *zone.Plan) {
+	if plan.Empty() {
+		output.Print("No changes")
+		return
+	}
+
+	headers := []string{"ACTION", "ID", "TYPE", "NAME", "CONTENT", "TTL", "PRIO"}
+	rows := make([][]string, len(plan.Changes))
+	for i, c := range plan.Changes {
+		switch c.Action {
+		case zone.ActionCreate:
+			r := c.After
+			rows[i] = []string{"+ create", "", r.Type, displayName(r.Name), r.Content, r.TTL, r.Prio}
+		case zone.ActionDelete:
+			r := c.Before
+			rows[i] = []string{"- delete", c.ID, r.Type, displayName(r.Name), r.Content, r.TTL, r.Prio}
+		case zone.ActionUpdate:
+			rows[i] = []string{"~ update", c.ID, c.After.Type, displayName(c.After.Name),
+				changed(c.Before.Content, c.After.Content),
+				changed(c.Before.TTL, c.After.TTL),
+				changed(c.Before.Prio, c.After.Prio)}
+		}
+	}
+	output.PrintTable(headers, rows)
+	output.Print("")
+	output.Print(plan.Summary())
+}
+
+func changed(before, after string) string {
+	if before == after {
+		return after
+	}
+	return before + " -> " + after
+}
+
+func displayName(name string) string {
+	if name == "" {
+		return "@"
+	}
+	return name
+}
+
+func init() {
+	dnsCmd.AddCommand(dnsPlanCmd)
+	dnsPlanCmd.Flags().String("domain", "", "Domain to plan for (overrides the zone file)")
+	dnsPlanCmd.Flags().Bool("no-delete", false, "Never delete live records missing from the zone file")
+	addValidationFlag(dnsPlanCmd)
+
+	dnsCmd.AddCommand(dnsApplyCmd)
+	dnsApplyCmd.Flags().String("domain", "", "Domain to apply to (overrides the zone file)")
+	dnsApplyCmd.Flags().Bool("no-delete", false, "Never delete live records missing from the zone file")
+	dnsApplyCmd.Flags().BoolP("yes", "y", false, "Apply without asking for confirmation")
+	addValidationFlag(dnsApplyCmd)
+
+	dnsCmd.AddCommand(dnsExportCmd)
+	dnsExportCmd.Flags().StringP("format", "f", "bind", "Output format: bind or yaml")
+	dnsExportCmd.Flags().String("file", "", "Write to file instead of stdout")
+
+	dnsCmd.AddCommand(dnsImportCmd)
+	dnsImportCmd.Flags().Bool("replace", false, "Update and delete live records to match the zone file")
+	dnsImportCmd.Flags().Bool("dry-run", false, "Show changes without applying them")
+	dnsImportCmd.Flags().BoolP("yes", "y", false, "Apply without asking for confirmation")
+	addValidationFlag(dnsImportCmd)
+}
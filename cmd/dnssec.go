@@ -16,12 +16,12 @@ var dnssecListCmd = &cobra.Command{
 	Short: "List DNSSEC records for a domain",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		records, err := apiClient.DNSSECList(args[0])
+		records, err := apiClient.DNSSECListContext(cmd.Context(), args[0])
 		if err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(records)
 			return nil
 		}
@@ -71,11 +71,11 @@ Required flags: --keytag, --algorithm, --digest-type, --digest`,
 			Flags:      flags,
 		}
 
-		if err := apiClient.DNSSECCreate(domain, record); err != nil {
+		if err := apiClient.DNSSECCreateContext(cmd.Context(), domain, record); err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(map[string]string{"status": "created"})
 		} else {
 			output.Success("Created DNSSEC record for %s", domain)
@@ -89,11 +89,11 @@ var dnssecDeleteCmd = &cobra.Command{
 	Short: "Delete a DNSSEC record",
 	Args:  cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := apiClient.DNSSECDelete(args[0], args[1]); err != nil {
+		if err := apiClient.DNSSECDeleteContext(cmd.Context(), args[0], args[1]); err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(map[string]string{"status": "deleted"})
 		} else {
 			output.Success("Deleted DNSSEC record %s", args[1])
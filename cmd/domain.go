@@ -5,6 +5,7 @@ import (
 
 	"github.com/OverseedAI/overpork/internal/api"
 	"github.com/OverseedAI/overpork/internal/output"
+	"github.com/OverseedAI/overpork/internal/query"
 	"github.com/spf13/cobra"
 )
 
@@ -16,14 +17,45 @@ var domainCmd = &cobra.Command{
 var domainListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all domains in account",
+	Long: `List the domains in the account, fetching further pages from the API
+until the list is exhausted.
+
+With --filter or --sort every page is fetched first, and --limit applies
+to the result.
+
+Examples:
+  overpork domain list
+  overpork domain list --limit 20
+  overpork domain list --filter 'expireDate<2026-01-01' --sort expireDate`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		start, _ := cmd.Flags().GetInt("start")
-		domains, err := apiClient.DomainList(start)
+		limit, _ := cmd.Flags().GetInt("limit")
+		if limit < 0 {
+			return fmt.Errorf("limit must not be negative")
+		}
+		q, err := listQuery(cmd)
 		if err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		domains := []api.Domain{}
+		for d, err := range apiClient.Domains(cmd.Context(), start) {
+			if err != nil {
+				return err
+			}
+			domains = append(domains, d)
+			if q.Empty() && len(domains) == limit {
+				break
+			}
+		}
+		if domains, err = query.Apply(q, domains); err != nil {
+			return err
+		}
+		if limit > 0 && len(domains) > limit {
+			domains = domains[:limit]
+		}
+
+		if output.Structured() {
 			output.PrintJSON(domains)
 			return nil
 		}
@@ -48,12 +80,12 @@ var domainGetCmd = &cobra.Command{
 	Short: "Get domain details",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		domain, err := apiClient.DomainGet(args[0])
+		domain, err := apiClient.DomainGetContext(cmd.Context(), args[0])
 		if err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(domain)
 			return nil
 		}
@@ -77,12 +109,12 @@ var domainNsGetCmd = &cobra.Command{
 	Short: "Get nameservers for a domain",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ns, err := apiClient.DomainGetNameservers(args[0])
+		ns, err := apiClient.DomainGetNameserversContext(cmd.Context(), args[0])
 		if err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(ns)
 			return nil
 		}
@@ -102,11 +134,11 @@ var domainNsSetCmd = &cobra.Command{
 		domain := args[0]
 		nameservers := args[1:]
 
-		if err := apiClient.DomainUpdateNameservers(domain, nameservers); err != nil {
+		if err := apiClient.DomainUpdateNameserversContext(cmd.Context(), domain, nameservers); err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(map[string]string{"status": "updated"})
 		} else {
 			output.Success("Nameservers updated")
@@ -120,12 +152,19 @@ var domainForwardListCmd = &cobra.Command{
 	Short: "List URL forwards for a domain",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		forwards, err := apiClient.DomainGetForwards(args[0])
+		q, err := listQuery(cmd)
+		if err != nil {
+			return err
+		}
+		forwards, err := apiClient.DomainGetForwardsContext(cmd.Context(), args[0])
 		if err != nil {
 			return err
 		}
+		if forwards, err = query.Apply(q, forwards); err != nil {
+			return err
+		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(forwards)
 			return nil
 		}
@@ -169,11 +208,11 @@ var domainForwardAddCmd = &cobra.Command{
 			Subdomain:   subdomain,
 		}
 
-		if err := apiClient.DomainAddForward(domain, location, opts); err != nil {
+		if err := apiClient.DomainAddForwardContext(cmd.Context(), domain, location, opts); err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(map[string]string{"status": "created"})
 		} else {
 			output.Success("Forward created")
@@ -187,11 +226,11 @@ var domainForwardDeleteCmd = &cobra.Command{
 	Short: "Delete a URL forward",
 	Args:  cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := apiClient.DomainDeleteForward(args[0], args[1]); err != nil {
+		if err := apiClient.DomainDeleteForwardContext(cmd.Context(), args[0], args[1]); err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(map[string]string{"status": "deleted"})
 		} else {
 			output.Success("Forward deleted")
@@ -227,11 +266,11 @@ Examples:
 			AutoRenew:    autoRenew,
 		}
 
-		if err := apiClient.DomainRegister(domain, opts); err != nil {
+		if err := apiClient.DomainRegisterContext(cmd.Context(), domain, opts); err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(map[string]string{"domain": domain, "status": "registered"})
 		} else {
 			output.Success("Registered %s", domain)
@@ -258,11 +297,11 @@ var domainAutoRenewCmd = &cobra.Command{
 			return fmt.Errorf("invalid action: use 'enable' or 'disable'")
 		}
 
-		if err := apiClient.DomainSetAutoRenew(domain, enabled); err != nil {
+		if err := apiClient.DomainSetAutoRenewContext(cmd.Context(), domain, enabled); err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(map[string]any{"domain": domain, "autoRenew": enabled})
 		} else {
 			if enabled {
@@ -279,13 +318,16 @@ func init() {
 	rootCmd.AddCommand(domainCmd)
 
 	domainCmd.AddCommand(domainListCmd)
-	domainListCmd.Flags().Int("start", 0, "Pagination start index")
+	domainListCmd.Flags().Int("limit", 0, "Maximum number of domains to list (0 = all)")
+	domainListCmd.Flags().Int("start", 0, "Skip this many domains")
+	addQueryFlags(domainListCmd)
 
 	domainCmd.AddCommand(domainGetCmd)
 	domainCmd.AddCommand(domainNsGetCmd)
 	domainCmd.AddCommand(domainNsSetCmd)
 
 	domainCmd.AddCommand(domainForwardListCmd)
+	addQueryFlags(domainForwardListCmd)
 	domainCmd.AddCommand(domainForwardAddCmd)
 	domainForwardAddCmd.Flags().String("type", "temporary", "Forward type: temporary or permanent")
 	domainForwardAddCmd.Flags().Bool("include-path", false, "Include path in redirect")
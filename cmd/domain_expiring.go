@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/OverseedAI/overpork/internal/expiry"
+	"github.com/OverseedAI/overpork/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var domainExpiringCmd = &cobra.Command{
+	Use:   "expiring",
+	Short: "Report domains that expire soon and what renewing them costs",
+	Long: `List the domains that expire within a window (including any already
+expired), soonest first, with their auto-renew setting and renewal price
+from the TLD price list, and the total upcoming spend.
+
+Exits with status 2 when a domain without auto-renew is inside the window,
+so it can run from cron or a CI schedule as an alarm.
+
+Examples:
+  overpork domain expiring
+  overpork domain expiring --within 90d
+  overpork domain expiring --within 2w --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		withinFlag, _ := cmd.Flags().GetString("within")
+		within, err := expiry.ParseWithin(withinFlag)
+		if err != nil {
+			return err
+		}
+
+		domains, err := apiClient.DomainListAllContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+		pricing, err := apiClient.PricingListContext(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to get pricing: %w", err)
+		}
+
+		report := expiry.Build(domains, pricing, time.Now(), within)
+
+		if output.Structured() {
+			output.PrintJSON(map[string]any{
+				"within":  withinFlag,
+				"domains": report.Entries,
+				"total":   report.Total,
+				"atRisk":  report.AtRisk,
+				"skipped": report.Skipped,
+			})
+		} else {
+			for _, name := range report.Skipped {
+				output.Warn("%s: could not parse expiry date", name)
+			}
+			printExpiryReport(report, withinFlag)
+		}
+
+		if report.AtRisk > 0 {
+			return &checkFailed{msg: fmt.Sprintf("%d domains expire within %s without auto-renew", report.AtRisk, withinFlag)}
+		}
+		return nil
+	},
+}
+
+func printExpiryReport(report *expiry.Report, within string) {
+	if len(report.Entries) == 0 {
+		output.Print("No domains expire within " + within)
+		return
+	}
+
+	headers := []string{"DOMAIN", "EXPIRES", "DAYS", "AUTO-RENEW", "RENEWAL"}
+	rows := make([][]string, len(report.Entries))
+	for i, e := range report.Entries {
+		autoRenew := "yes"
+		if !e.AutoRenew {
+			autoRenew = "NO"
+		}
+		price := "?"
+		if e.PriceKnown {
+			price = fmt.Sprintf("$%.2f", e.Renewal)
+		}
+		rows[i] = []string{e.Domain, e.ExpireDate.Format(time.DateOnly), strconv.Itoa(e.DaysLeft), autoRenew, price}
+	}
+	output.PrintTable(headers, rows)
+	output.Print("")
+	output.Print(fmt.Sprintf("%d domains, $%.2f to renew", len(report.Entries), report.Total))
+}
+
+func init() {
+	domainCmd.AddCommand(domainExpiringCmd)
+	domainExpiringCmd.Flags().String("within", "30d", "Report domains expiring within this window, e.g. 30d, 2w or 72h")
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/OverseedAI/overpork/internal/api"
+)
+
+// Process exit codes. API failures map to a code per error kind so scripts
+// can react without parsing messages.
+const (
+	exitError       = 1
+	exitCheck       = 2 // a check command found problems
+	exitAuth        = 3
+	exitNotFound    = 4
+	exitInvalid     = 5
+	exitRateLimit   = 6
+	exitNetwork     = 7
+	exitServer      = 8
+	exitTimeout     = 124
+	exitInterrupted = 130
+)
+
+// checkFailed ends a command with exitCheck after it has already reported
+// its findings. Under --json no error object is printed.
+type checkFailed struct {
+	msg string
+}
+
+func (e *checkFailed) Error() string {
+	return e.msg
+}
+
+// errorInfo is the structured form of an error printed under --json.
+type errorInfo struct {
+	Kind       string `json:"kind"`
+	Message    string `json:"message"`
+	HTTPStatus int    `json:"httpStatus,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Endpoint   string `json:"endpoint,omitempty"`
+	ExitCode   int    `json:"exitCode"`
+}
+
+// describeError classifies err into a kind and an exit code. interrupted
+// reports whether the command was stopped by a signal.
+func describeError(err error, interrupted bool) errorInfo {
+	info := errorInfo{Kind: "error", Message: err.Error(), ExitCode: exitError}
+
+	var apiErr *api.Error
+	if errors.As(err, &apiErr) {
+		info.Kind = string(apiErr.Kind)
+		info.HTTPStatus = apiErr.HTTPStatus
+		info.Status = apiErr.Status
+		info.Endpoint = apiErr.Endpoint
+		switch apiErr.Kind {
+		case api.KindAuth:
+			info.ExitCode = exitAuth
+		case api.KindNotFound:
+			info.ExitCode = exitNotFound
+		case api.KindInvalid:
+			info.ExitCode = exitInvalid
+		case api.KindRateLimit:
+			info.ExitCode = exitRateLimit
+		case api.KindNetwork:
+			info.ExitCode = exitNetwork
+		case api.KindServer:
+			info.ExitCode = exitServer
+		}
+	}
+
+	switch {
+	case interrupted:
+		info.Kind = "interrupted"
+		info.Message = "interrupted"
+		info.ExitCode = exitInterrupted
+	case errors.Is(err, context.DeadlineExceeded):
+		info.Kind = "timeout"
+		info.Message = "timed out: " + err.Error()
+		info.ExitCode = exitTimeout
+	}
+	return info
+}
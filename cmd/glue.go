@@ -17,12 +17,12 @@ var glueListCmd = &cobra.Command{
 	Short: "List glue records for a domain",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		records, err := apiClient.GlueList(args[0])
+		records, err := apiClient.GlueListContext(cmd.Context(), args[0])
 		if err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(records)
 			return nil
 		}
@@ -56,11 +56,11 @@ Examples:
 		subdomain := args[1]
 		ips := args[2:]
 
-		if err := apiClient.GlueCreate(domain, subdomain, ips); err != nil {
+		if err := apiClient.GlueCreateContext(cmd.Context(), domain, subdomain, ips); err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(map[string]string{"status": "created"})
 		} else {
 			output.Success("Created glue record for %s.%s", subdomain, domain)
@@ -78,11 +78,11 @@ var glueUpdateCmd = &cobra.Command{
 		subdomain := args[1]
 		ips := args[2:]
 
-		if err := apiClient.GlueUpdate(domain, subdomain, ips); err != nil {
+		if err := apiClient.GlueUpdateContext(cmd.Context(), domain, subdomain, ips); err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(map[string]string{"status": "updated"})
 		} else {
 			output.Success("Updated glue record for %s.%s", subdomain, domain)
@@ -96,11 +96,11 @@ var glueDeleteCmd = &cobra.Command{
 	Short: "Delete a glue record",
 	Args:  cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := apiClient.GlueDelete(args[0], args[1]); err != nil {
+		if err := apiClient.GlueDeleteContext(cmd.Context(), args[0], args[1]); err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(map[string]string{"status": "deleted"})
 		} else {
 			output.Success("Deleted glue record for %s.%s", args[1], args[0])
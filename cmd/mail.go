@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"github.com/OverseedAI/overpork/internal/mailauth"
+	"github.com/OverseedAI/overpork/internal/output"
+	"github.com/OverseedAI/overpork/internal/zone"
+	"github.com/spf13/cobra"
+)
+
+var mailCmd = &cobra.Command{
+	Use:   "mail",
+	Short: "Check and edit SPF, DKIM and DMARC records",
+}
+
+var mailAuditCmd = &cobra.Command{
+	Use:   "audit <domain>",
+	Short: "Check a domain's SPF, DKIM and DMARC records",
+	Long: `Check the SPF, DMARC and DKIM TXT records of a domain: multiple SPF
+records, +all, more than 10 DNS lookups (following includes through DNS),
+DMARC policy tags, and DKIM key records. Exits with code 2 if any errors
+are found.
+
+Examples:
+  overpork mail audit example.com
+  overpork mail audit example.com --no-resolve
+  overpork mail audit example.com --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domain := args[0]
+		records, err := apiClient.DNSListByTypeContext(cmd.Context(), domain, "TXT")
+		if err != nil {
+			return err
+		}
+		report := mailauth.Audit(cmd.Context(), domain, records, txtLookup(cmd))
+
+		if output.Structured() {
+			output.PrintJSON(report)
+		} else {
+			printMailReport(report)
+		}
+
+		if n := report.Count(mailauth.SeverityError); n > 0 {
+			return &checkFailed{msg: fmt.Sprintf("%d mail authentication errors in %s", n, domain)}
+		}
+		return nil
+	},
+}
+
+var mailSPFCmd = &cobra.Command{
+	Use:   "spf",
+	Short: "Edit the SPF record",
+}
+
+var mailSPFAddIncludeCmd = &cobra.Command{
+	Use:   "add-include <domain> <include-domain>",
+	Short: "Add an include to the SPF record",
+	Long: `Add include:<include-domain> to the domain's SPF record, in front of its
+"all" mechanism. A record with ~all is created if the domain has none.
+The change is refused if the policy would need more than 10 DNS lookups,
+unless --force is given.
+
+Examples:
+  overpork mail spf add-include example.com _spf.google.com
+  overpork mail spf add-include example.com amazonses.com --dry-run`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		include := args[1]
+		force, _ := cmd.Flags().GetBool("force")
+		return editTXT(cmd, args[0], "", "SPF", mailauth.IsSPF, func(content string) (string, error) {
+			if content == "" {
+				content = "v=spf1 ~all"
+			}
+			spf, err := mailauth.ParseSPF(content)
+			if err != nil {
+				return "", fmt.Errorf("existing SPF record: %w", err)
+			}
+			if !spf.AddInclude(include) {
+				// Already there: keep the record as written.
+				return content, nil
+			}
+			if count, _ := spf.Lookups(cmd.Context(), txtLookup(cmd)); count > mailauth.MaxLookups && !force {
+				return "", fmt.Errorf("SPF policy would need %d DNS lookups, more than the limit of %d (use --force to save it anyway)", count, mailauth.MaxLookups)
+			}
+			return spf.String(), nil
+		})
+	},
+}
+
+var mailSPFRemoveIncludeCmd = &cobra.Command{
+	Use:   "remove-include <domain> <include-domain>",
+	Short: "Remove an include from the SPF record",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		include := args[1]
+		return editTXT(cmd, args[0], "", "SPF", mailauth.IsSPF, func(content string) (string, error) {
+			if content == "" {
+				return "", fmt.Errorf("%s has no SPF record", args[0])
+			}
+			spf, err := mailauth.ParseSPF(content)
+			if err != nil {
+				return "", fmt.Errorf("existing SPF record: %w", err)
+			}
+			if !spf.RemoveInclude(include) {
+				return "", fmt.Errorf("SPF record does not include %s", include)
+			}
+			return spf.String(), nil
+		})
+	},
+}
+
+var mailDMARCCmd = &cobra.Command{
+	Use:   "dmarc",
+	Short: "Edit the DMARC record",
+}
+
+var mailDMARCSetCmd = &cobra.Command{
+	Use:   "set <domain>",
+	Short: "Set DMARC policy tags",
+	Long: `Set tags of the domain's DMARC record at _dmarc, keeping the others. The
+record is created if the domain has none.
+
+Examples:
+  overpork mail dmarc set example.com --policy quarantine
+  overpork mail dmarc set example.com --policy reject --pct 25
+  overpork mail dmarc set example.com --rua mailto:dmarc@example.com`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := cmd.Flags()
+		changes := map[string]string{}
+		for flag, tag := range map[string]string{"policy": "p", "subdomain-policy": "sp", "pct": "pct", "rua": "rua", "ruf": "ruf"} {
+			if flags.Changed(flag) {
+				changes[tag], _ = flags.GetString(flag)
+			}
+		}
+		if len(changes) == 0 {
+			return fmt.Errorf("nothing to set (use --policy, --subdomain-policy, --pct, --rua or --ruf)")
+		}
+
+		return editTXT(cmd, args[0], "_dmarc", "DMARC", mailauth.IsDMARC, func(content string) (string, error) {
+			tags := mailauth.Tags{{Name: "v", Value: "DMARC1"}}
+			if content != "" {
+				var err error
+				if tags, err = mailauth.ParseTags(content); err != nil {
+					return "", fmt.Errorf("existing DMARC record: %w", err)
+				}
+			}
+			// Keep the tag order of RFC 7489 examples for new tags.
+			for _, tag := range []string{"p", "sp", "pct", "rua", "ruf"} {
+				if value, ok := changes[tag]; ok {
+					if value == "" {
+						tags.Delete(tag)
+					} else {
+						tags.Set(tag, value)
+					}
+				}
+			}
+			if !tags.Has("p") {
+				return "", fmt.Errorf("DMARC record needs a policy (use --policy)")
+			}
+			if err := mailauth.ValidateDMARC(tags); err != nil {
+				return "", err
+			}
+			return tags.String(), nil
+		})
+	},
+}
+
+// txtLookup resolves TXT records through the system resolver, or returns
+// nil if --no-resolve was given.
+func txtLookup(cmd *cobra.Command) mailauth.TXTLookup {
+	if noResolve, _ := cmd.Flags().GetBool("no-resolve"); noResolve {
+		return nil
+	}
+	return net.DefaultResolver.LookupTXT
+}
+
+// editTXT rewrites the single TXT record at name for which match is true,
+// or creates one when there is none, after showing the change and asking
+// for confirmation. edit gets "" when there is no record.
+func editTXT(cmd *cobra.Command, domain, name, kind string, match func(string) bool, edit func(string) (string, error)) error {
+	records, err := apiClient.DNSListByTypeContext(cmd.Context(), domain, "TXT")
+	if err != nil {
+		return err
+	}
+	var found []api.DNSRecord
+	for _, r := range records {
+		if zone.RelativeName(r.Name, domain) == name && match(r.Content) {
+			found = append(found, r)
+		}
+	}
+	if len(found) > 1 {
+		return fmt.Errorf("%s has %d %s records at %s; merge them with dns update first", domain, len(found), kind, displayName(name))
+	}
+
+	var before string
+	if len(found) == 1 {
+		before = found[0].Content
+	}
+	after, err := edit(before)
+	if err != nil {
+		return err
+	}
+	if before == after {
+		if output.Structured() {
+			output.PrintJSON(map[string]any{"domain": domain, "name": name, "content": before, "status": "unchanged"})
+		} else {
+			output.Print("No changes")
+		}
+		return nil
+	}
+
+	rec := zone.Record{Name: name, Type: "TXT", Content: after}
+	var replaced func(api.DNSRecord) bool
+	if len(found) == 1 {
+		rec.TTL = found[0].TTL
+		replaced = func(r api.DNSRecord) bool { return r.ID == found[0].ID }
+	}
+	if err := validateWrite(cmd, domain, rec, replaced); err != nil {
+		return err
+	}
+
+	if !output.Structured() {
+		if before != "" {
+			output.Print("- " + before)
+		}
+		output.Print("+ " + after)
+	}
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		if output.Structured() {
+			output.PrintJSON(map[string]any{"domain": domain, "name": name, "before": before, "after": after, "status": "planned"})
+		}
+		return nil
+	}
+	ok, err := confirm(cmd, fmt.Sprintf("Save %s record of %s?", kind, domain))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("aborted")
+	}
+
+	status := "updated"
+	if len(found) == 1 {
+		r := found[0]
+		err = apiClient.DNSUpdateContext(cmd.Context(), domain, r.ID, "TXT", after, api.DNSCreateOpts{Name: name, TTL: r.TTL})
+	} else {
+		status = "created"
+		_, err = apiClient.DNSCreateContext(cmd.Context(), domain, "TXT", after, api.DNSCreateOpts{Name: name})
+	}
+	if err != nil {
+		return err
+	}
+
+	if output.Structured() {
+		output.PrintJSON(map[string]any{"domain": domain, "name": name, "before": before, "after": after, "status": status})
+	} else {
+		output.Success("%s %s record of %s", strings.ToUpper(status[:1])+status[1:], kind, domain)
+	}
+	return nil
+}
+
+func printMailReport(r *mailauth.Report) {
+	spf := r.SPF
+	if spf == "" {
+		spf = "(none)"
+	}
+	dmarc := r.DMARC
+	if dmarc == "" {
+		dmarc = "(none)"
+	}
+	dkim := strings.Join(r.DKIM, ", ")
+	if dkim == "" {
+		dkim = "(none in TXT records)"
+	}
+	output.Print(fmt.Sprintf("SPF:    %s (%d/%d lookups)", spf, r.Lookups, mailauth.MaxLookups))
+	output.Print("DMARC:  " + dmarc)
+	output.Print("DKIM:   " + dkim)
+	output.Print("")
+
+	if len(r.Findings) == 0 {
+		output.Print("No problems found")
+		return
+	}
+	headers := []string{"SEVERITY", "CHECK", "NAME", "MESSAGE"}
+	var rows [][]string
+	for _, s := range []mailauth.Severity{mailauth.SeverityError, mailauth.SeverityWarning, mailauth.SeverityInfo} {
+		for _, f := range r.Findings {
+			if f.Severity == s {
+				rows = append(rows, []string{string(f.Severity), strings.ToUpper(f.Check), displayName(f.Name), f.Message})
+			}
+		}
+	}
+	output.PrintTable(headers, rows)
+}
+
+func init() {
+	rootCmd.AddCommand(mailCmd)
+
+	mailCmd.AddCommand(mailAuditCmd)
+	mailAuditCmd.Flags().Bool("no-resolve", false, "Don't follow SPF includes through DNS to count lookups")
+
+	mailCmd.AddCommand(mailSPFCmd)
+	mailSPFCmd.AddCommand(mailSPFAddIncludeCmd)
+	mailSPFAddIncludeCmd.Flags().Bool("force", false, "Save the record even if it needs more than 10 DNS lookups")
+	mailSPFAddIncludeCmd.Flags().Bool("no-resolve", false, "Don't follow SPF includes through DNS to count lookups")
+	mailSPFCmd.AddCommand(mailSPFRemoveIncludeCmd)
+	for _, c := range []*cobra.Command{mailSPFAddIncludeCmd, mailSPFRemoveIncludeCmd} {
+		c.Flags().Bool("dry-run", false, "Show the new record without saving it")
+		c.Flags().BoolP("yes", "y", false, "Save without asking for confirmation")
+		addValidationFlag(c)
+	}
+
+	mailCmd.AddCommand(mailDMARCCmd)
+	mailDMARCCmd.AddCommand(mailDMARCSetCmd)
+	mailDMARCSetCmd.Flags().String("policy", "", "Policy for the domain: none, quarantine or reject")
+	mailDMARCSetCmd.Flags().String("subdomain-policy", "", "Policy for subdomains (sp=); empty to remove")
+	mailDMARCSetCmd.Flags().String("pct", "", "Percentage of failing mail the policy applies to; empty to remove")
+	mailDMARCSetCmd.Flags().String("rua", "", "Aggregate report URIs, e.g. mailto:dmarc@example.com; empty to remove")
+	mailDMARCSetCmd.Flags().String("ruf", "", "Failure report URIs; empty to remove")
+	mailDMARCSetCmd.Flags().Bool("dry-run", false, "Show the new record without saving it")
+	mailDMARCSetCmd.Flags().BoolP("yes", "y", false, "Save without asking for confirmation")
+	addValidationFlag(mailDMARCSetCmd)
+}
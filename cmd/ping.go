@@ -9,7 +9,7 @@ var pingCmd = &cobra.Command{
 	Use:   "ping",
 	Short: "Test API connectivity and authentication",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := apiClient.Ping(); err != nil {
+		if err := apiClient.PingContext(cmd.Context()); err != nil {
 			return err
 		}
 		output.Success("OK")
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"sort"
 
+	"github.com/OverseedAI/overpork/internal/api"
 	"github.com/OverseedAI/overpork/internal/output"
+	"github.com/OverseedAI/overpork/internal/query"
 	"github.com/spf13/cobra"
 )
 
@@ -16,47 +18,80 @@ var pricingCmd = &cobra.Command{
 var pricingListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all TLD pricing",
+	Long: `List registration, renewal and transfer prices for every TLD.
+
+--filter and --sort work on the tld field and the price fields.
+
+Structured output is the API's object keyed by TLD, or a list of
+{tld, registration, ...} objects when --filter, --sort or --fields is
+given.
+
+Examples:
+  overpork pricing list --filter 'renewal<10' --sort renewal
+  overpork pricing list --filter 'tld=co*'`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		pricing, err := apiClient.PricingList()
+		q, err := listQuery(cmd)
 		if err != nil {
 			return err
 		}
-
-		if output.JSONOutput {
-			output.PrintJSON(pricing)
-			return nil
+		pricing, err := apiClient.PricingListContext(cmd.Context())
+		if err != nil {
+			return err
 		}
 
 		// Sort TLDs alphabetically
-		tlds := make([]string, 0, len(pricing))
-		for tld := range pricing {
-			tlds = append(tlds, tld)
+		tlds := make([]tldPricing, 0, len(pricing))
+		for tld, p := range pricing {
+			tlds = append(tlds, tldPricing{TLD: tld, Pricing: p})
+		}
+		sort.Slice(tlds, func(i, j int) bool { return tlds[i].TLD < tlds[j].TLD })
+		if tlds, err = query.Apply(q, tlds); err != nil {
+			return err
+		}
+
+		if output.Structured() {
+			if len(q.Filters) > 0 || len(q.Sort) > 0 || len(output.Fields) > 0 {
+				// A list, so the order and selected fields carry through.
+				output.PrintJSON(tlds)
+				return nil
+			}
+			// Keep the API's shape, a map keyed by TLD.
+			byTLD := make(map[string]api.Pricing, len(tlds))
+			for _, t := range tlds {
+				byTLD[t.TLD] = t.Pricing
+			}
+			output.PrintJSON(byTLD)
+			return nil
 		}
-		sort.Strings(tlds)
 
 		headers := []string{"TLD", "REGISTER", "RENEW", "TRANSFER"}
 		rows := make([][]string, len(tlds))
-		for i, tld := range tlds {
-			p := pricing[tld]
-			rows[i] = []string{tld, "$" + p.Registration, "$" + p.Renewal, "$" + p.Transfer}
+		for i, t := range tlds {
+			rows[i] = []string{t.TLD, "$" + t.Registration, "$" + t.Renewal, "$" + t.Transfer}
 		}
 		output.PrintTable(headers, rows)
 		return nil
 	},
 }
 
+// tldPricing is a price list entry with its TLD, for filtering.
+type tldPricing struct {
+	TLD string `json:"tld"`
+	api.Pricing
+}
+
 var pricingCheckCmd = &cobra.Command{
 	Use:   "check <domain>",
 	Short: "Check domain availability and price",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		domain := args[0]
-		available, price, err := apiClient.DomainCheck(domain)
+		available, price, err := apiClient.DomainCheckContext(cmd.Context(), domain)
 		if err != nil {
 			return err
 		}
 
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(map[string]any{
 				"domain":    domain,
 				"available": available,
@@ -77,5 +112,6 @@ var pricingCheckCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(pricingCmd)
 	pricingCmd.AddCommand(pricingListCmd)
+	addQueryFlags(pricingListCmd)
 	pricingCmd.AddCommand(pricingCheckCmd)
 }
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"github.com/OverseedAI/overpork/internal/query"
+	"github.com/spf13/cobra"
+)
+
+// addQueryFlags adds --filter and --sort to a list command.
+func addQueryFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArray("filter", nil, "Only show items matching field<op>value, e.g. ttl<3600 or name=mail* (op: = != ~= !~ < <= > >=; repeatable)")
+	cmd.Flags().StringSlice("sort", nil, "Sort by fields, e.g. ttl,-name (- for descending)")
+}
+
+// listQuery parses --filter and --sort.
+func listQuery(cmd *cobra.Command) (*query.Query, error) {
+	filters, _ := cmd.Flags().GetStringArray("filter")
+	sorts, _ := cmd.Flags().GetStringSlice("sort")
+	return query.Parse(filters, sorts)
+}
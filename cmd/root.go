@@ -1,7 +1,13 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/OverseedAI/overpork/internal/api"
 	"github.com/OverseedAI/overpork/internal/config"
@@ -12,33 +18,73 @@ import (
 var (
 	cfg       *config.Config
 	apiClient *api.Client
+
+	// cancelTimeout releases the --timeout context once the command is done.
+	cancelTimeout context.CancelFunc = func() {}
 )
 
+// offlineFlags is a command annotation listing flags (comma-separated)
+// that make the command work without the API, so no credentials are needed.
+const offlineFlags = "offline-flags"
+
 var rootCmd = &cobra.Command{
 	Use:   "opork",
 	Short: "CLI wrapper for Porkbun API",
 	Long:  "opork is a CLI tool for managing domains, DNS records, and SSL certificates via the Porkbun API.",
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := setOutputFormat(cmd); err != nil {
+			return err
+		}
+		if timeout, _ := cmd.Flags().GetDuration("timeout"); timeout > 0 {
+			ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+			cancelTimeout = cancel
+			cmd.SetContext(ctx)
+		}
+
 		// Skip auth for commands that don't need it
 		if cmd.Name() == "help" || cmd.Name() == "version" || cmd.Name() == "completion" {
 			return nil
 		}
-		// Skip auth for config commands
+		// Skip auth for config and audit log commands
 		for c := cmd; c != nil; c = c.Parent() {
-			if c.Name() == "config" {
+			if c.Name() == "config" || c == auditCmd {
+				return nil
+			}
+		}
+		// Skip auth for commands working on local files
+		for _, flag := range strings.Split(cmd.Annotations[offlineFlags], ",") {
+			if flag != "" && cmd.Flags().Changed(flag) {
 				return nil
 			}
 		}
 
 		var err error
-		cfg, err = config.Load()
+		profile, _ := cmd.Flags().GetString("profile")
+		cfg, err = config.LoadProfile(profile)
 		if err != nil {
 			return err
 		}
+		flags := cmd.Flags()
+		if flags.Changed("retries") {
+			cfg.Retries, _ = flags.GetInt("retries")
+		}
+		if flags.Changed("rate-limit") {
+			cfg.RateLimit, _ = flags.GetFloat64("rate-limit")
+		}
+		if flags.Changed("rate-burst") {
+			cfg.RateBurst, _ = flags.GetInt("rate-burst")
+		}
+		if flags.Changed("base-url") {
+			cfg.BaseURL, _ = flags.GetString("base-url")
+		}
+		if flags.Changed("pricing-url") {
+			cfg.PricingURL, _ = flags.GetString("pricing-url")
+		}
 		if err := cfg.Validate(); err != nil {
 			return err
 		}
 		apiClient = api.NewClient(cfg)
+		enableAudit()
 		return nil
 	},
 	SilenceUsage:  true,
@@ -46,12 +92,70 @@ var rootCmd = &cobra.Command{
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		output.Error("%v", err)
-		os.Exit(1)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	if err == nil {
+		err = output.Err()
+	}
+	cancelTimeout()
+	interrupted := ctx.Err() != nil
+	stop()
+
+	var failed *checkFailed
+	if errors.As(err, &failed) && !interrupted {
+		if !output.Structured() {
+			output.Error("%s", failed.msg)
+		}
+		os.Exit(exitCheck)
+	}
+	if err != nil {
+		info := describeError(err, interrupted)
+		printError(info)
+		os.Exit(info.ExitCode)
+	}
+}
+
+// printError reports a failed command. Machine-readable formats get the
+// error object whatever --fields or template was given.
+func printError(info errorInfo) {
+	switch output.Format {
+	case output.FormatTable:
+		output.Error("%s", info.Message)
+		return
+	case output.FormatTemplate:
+		output.Format = output.FormatJSON
+	}
+	output.Fields = nil
+	output.PrintJSON(map[string]any{"error": info})
+}
+
+// setOutputFormat applies --output (or --json, a shorthand for it) and
+// --fields.
+func setOutputFormat(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+	format, _ := flags.GetString("output")
+	if asJSON, _ := flags.GetBool("json"); asJSON {
+		if flags.Changed("output") && format != output.FormatJSON {
+			return fmt.Errorf("--json conflicts with --output %s", format)
+		}
+		format = output.FormatJSON
+	}
+	if err := output.SetFormat(format); err != nil {
+		return err
 	}
+	fields, _ := flags.GetStringSlice("fields")
+	return output.SetFields(fields)
 }
 
 func init() {
-	rootCmd.PersistentFlags().BoolVar(&output.JSONOutput, "json", false, "Output in JSON format")
+	rootCmd.PersistentFlags().StringP("output", "o", output.FormatTable, "Output format: "+strings.Join(output.Formats, ", ")+", go-template=TEMPLATE or go-template-file=PATH")
+	rootCmd.PersistentFlags().StringSlice("fields", nil, "Fields to print, e.g. id,name,content (any field of the JSON output)")
+	rootCmd.PersistentFlags().Bool("json", false, "Output in JSON format (same as --output json)")
+	rootCmd.PersistentFlags().String("profile", "", "Credential profile to use (overrides PORKBUN_PROFILE)")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "Overall time limit for the command, e.g. 30s or 2m (0 = none)")
+	rootCmd.PersistentFlags().Int("retries", config.DefaultRetries, "Retries for idempotent API calls on network errors, 429 and 5xx")
+	rootCmd.PersistentFlags().Float64("rate-limit", 0, "Maximum API requests per second (0 = unlimited)")
+	rootCmd.PersistentFlags().Int("rate-burst", config.DefaultRateBurst, "Requests allowed back to back before rate limiting")
+	rootCmd.PersistentFlags().String("base-url", "", "API base URL (default "+api.BaseURL+")")
+	rootCmd.PersistentFlags().String("pricing-url", "", "Pricing API base URL (default "+api.PricingURL+")")
 }
@@ -17,14 +17,14 @@ var sslGetCmd = &cobra.Command{
 Outputs certificate, intermediate cert, and private key.`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		bundle, err := apiClient.SSLRetrieve(args[0])
+		bundle, err := apiClient.SSLRetrieveContext(cmd.Context(), args[0])
 		if err != nil {
 			return err
 		}
 
 		part, _ := cmd.Flags().GetString("part")
 
-		if output.JSONOutput {
+		if output.Structured() {
 			if part != "" {
 				switch part {
 				case "cert":
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"github.com/OverseedAI/overpork/internal/dnsvalidate"
+	"github.com/OverseedAI/overpork/internal/zone"
+	"github.com/spf13/cobra"
+)
+
+// addValidationFlag adds --skip-validation to a command that writes
+// records.
+func addValidationFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("skip-validation", false, "Send records to the API without checking them locally first")
+}
+
+// validateRecords checks the records a command is about to write against
+// the records that stay as they are.
+func validateRecords(cmd *cobra.Command, existing, changed []zone.Record) error {
+	if skip, _ := cmd.Flags().GetBool("skip-validation"); skip {
+		return nil
+	}
+	if err := dnsvalidate.Check(existing, changed); err != nil {
+		return fmt.Errorf("invalid records (use --skip-validation to send them anyway):\n%w", err)
+	}
+	return nil
+}
+
+// validatePlan checks the records a plan creates or updates. live are the
+// records the plan was computed from; they may be nil when unknown.
+func validatePlan(cmd *cobra.Command, plan *zone.Plan, live []api.DNSRecord) error {
+	replaced := make(map[string]bool)
+	var changed []zone.Record
+	for _, c := range plan.Changes {
+		if c.ID != "" {
+			replaced[c.ID] = true
+		}
+		if c.After != nil {
+			changed = append(changed, *c.After)
+		}
+	}
+	var existing []zone.Record
+	for i, r := range zone.FromAPI(plan.Domain, live) {
+		if !replaced[live[i].ID] {
+			existing = append(existing, r)
+		}
+	}
+	return validateRecords(cmd, existing, changed)
+}
+
+// validateWrite checks a single record written with dns create, update or
+// set. replaced reports which live records the write overwrites.
+func validateWrite(cmd *cobra.Command, domain string, rec zone.Record, replaced func(api.DNSRecord) bool) error {
+	if skip, _ := cmd.Flags().GetBool("skip-validation"); skip {
+		return nil
+	}
+	live, err := apiClient.DNSListContext(cmd.Context(), domain)
+	if err != nil {
+		return err
+	}
+	var existing []zone.Record
+	for i, r := range zone.FromAPI(domain, live) {
+		if replaced == nil || !replaced(live[i]) {
+			existing = append(existing, r)
+		} else if rec.Prio == "" {
+			// An update without --prio keeps the record's priority.
+			rec.Prio = r.Prio
+		}
+	}
+	return validateRecords(cmd, existing, []zone.Record{rec})
+}
+
+// recordName turns a --name or subdomain argument into a relative record
+// name.
+func recordName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "@" {
+		return ""
+	}
+	return name
+}
@@ -11,7 +11,7 @@ var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print version information",
 	Run: func(cmd *cobra.Command, args []string) {
-		if output.JSONOutput {
+		if output.Structured() {
 			output.PrintJSON(map[string]string{"version": Version})
 		} else {
 			output.Print("opork " + Version)
@@ -2,29 +2,70 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/OverseedAI/overpork/internal/config"
 )
 
+// Every exported Client method has a ...Context variant that takes a
+// context.Context for cancellation and deadlines; the plain variants use
+// context.Background().
+
+// BaseURL is the default API endpoint; see config.Config.BaseURL.
 const BaseURL = "https://api.porkbun.com/api/json/v3"
 
+const (
+	defaultRetryWait = 500 * time.Millisecond
+	maxRetryWait     = 30 * time.Second
+	maxRetryAfter    = 2 * time.Minute
+)
+
 type Client struct {
 	httpClient *http.Client
+	baseURL    string
+	pricingURL string
 	apiKey     string
 	secretKey  string
+	retries    int
+	retryWait  time.Duration
+	limiter    *limiter
+	sleep      func(context.Context, time.Duration) error
+	onMutation func(context.Context, Mutation)
+
+	// records caches each domain's DNS records as the before-state of
+	// edits and deletes while mutations are reported.
+	recordsMu sync.Mutex
+	records   map[string][]DNSRecord
 }
 
 func NewClient(cfg *config.Config) *Client {
 	return &Client{
 		httpClient: &http.Client{Timeout: 30 * time.Second},
+		baseURL:    orDefault(cfg.BaseURL, BaseURL),
+		pricingURL: orDefault(cfg.PricingURL, PricingURL),
 		apiKey:     cfg.APIKey,
 		secretKey:  cfg.SecretKey,
+		retries:    cfg.Retries,
+		retryWait:  defaultRetryWait,
+		limiter:    newLimiter(cfg.RateLimit, cfg.RateBurst),
+		sleep:      sleep,
+	}
+}
+
+// orDefault returns a configured URL without its trailing slash, or def.
+func orDefault(url, def string) string {
+	if url == "" {
+		return def
 	}
+	return strings.TrimSuffix(url, "/")
 }
 
 type Response struct {
@@ -32,56 +73,153 @@ type Response struct {
 	Message string `json:"message,omitempty"`
 }
 
-func (c *Client) doURL(method, url string, reqBody, respBody any) error {
-	var body io.Reader
+// doURL sends a request to base+endpoint, retrying failed attempts with
+// exponential backoff. Rate-limited (HTTP 429) requests are always retried
+// since the server rejected them unprocessed; network errors and 5xx
+// responses are only retried for idempotent calls. Any error returned is an
+// *Error.
+func (c *Client) doURL(ctx context.Context, method, base, endpoint string, reqBody, respBody any, idempotent bool) error {
+	var data []byte
 	if reqBody != nil {
-		data, err := json.Marshal(reqBody)
+		var err error
+		data, err = json.Marshal(reqBody)
 		if err != nil {
-			return fmt.Errorf("failed to marshal request: %w", err)
+			return &Error{Kind: KindUnknown, Message: "failed to marshal request", Endpoint: endpoint, Err: err}
 		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.wait(ctx); err != nil {
+			return transportError(endpoint, "rate limiter wait aborted", err)
+		}
+
+		retryAfter, err := c.attempt(ctx, method, base+endpoint, endpoint, data, respBody)
+		if err == nil {
+			return nil
+		}
+
+		retry := err.Kind == KindRateLimit ||
+			(idempotent && (err.Kind == KindNetwork || err.Kind == KindServer))
+		if !retry || attempt >= c.retries || ctx.Err() != nil {
+			return err
+		}
+		if err := c.sleep(ctx, c.backoff(attempt, retryAfter)); err != nil {
+			return transportError(endpoint, "retry aborted", err)
+		}
+	}
+}
+
+// attempt performs a single request and returns any Retry-After delay the
+// server asked for.
+func (c *Client) attempt(ctx context.Context, method, url, endpoint string, data []byte, respBody any) (time.Duration, *Error) {
+	var body io.Reader
+	if data != nil {
 		body = bytes.NewReader(data)
 	}
 
-	req, err := http.NewRequest(method, url, body)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return 0, &Error{Kind: KindUnknown, Message: "failed to create request", Endpoint: endpoint, Err: err}
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return 0, transportError(endpoint, "request failed", err)
 	}
 	defer resp.Body.Close()
 
 	respData, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		return 0, transportError(endpoint, "failed to read response", err)
+	}
+
+	// Check for API error
+	var baseResp Response
+	if err := json.Unmarshal(respData, &baseResp); err == nil && baseResp.Status == "ERROR" {
+		apiErr := apiError(endpoint, resp.StatusCode, baseResp)
+		return parseRetryAfter(resp.Header.Get("Retry-After")), apiErr
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return parseRetryAfter(resp.Header.Get("Retry-After")), httpError(endpoint, resp.StatusCode)
 	}
 
 	if respBody != nil {
 		if err := json.Unmarshal(respData, respBody); err != nil {
-			return fmt.Errorf("failed to parse response: %w", err)
+			apiErr := httpError(endpoint, resp.StatusCode)
+			if apiErr.Kind == KindUnknown {
+				apiErr.Kind = KindServer
+			}
+			apiErr.Message = "failed to parse response"
+			apiErr.Err = err
+			return 0, apiErr
 		}
 	}
 
-	// Check for API error
-	var baseResp Response
-	if err := json.Unmarshal(respData, &baseResp); err == nil {
-		if baseResp.Status == "ERROR" {
-			return fmt.Errorf("API error: %s", baseResp.Message)
+	return 0, nil
+}
+
+// backoff returns the delay before retry number attempt+1: the server's
+// Retry-After if given, otherwise exponential backoff with jitter.
+func (c *Client) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, maxRetryAfter)
+	}
+	d := c.retryWait << attempt
+	if d <= 0 || d > maxRetryWait {
+		d = maxRetryWait
+	}
+	// Equal jitter: half fixed, half random.
+	return d/2 + rand.N(d/2+1)
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an
+// HTTP date.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
 		}
 	}
+	return 0
+}
 
-	return nil
+// post sends a state-changing request, which is not retried on failures
+// that may have reached the server.
+func (c *Client) post(ctx context.Context, endpoint string, reqBody, respBody any) error {
+	if c.onMutation != nil {
+		return c.mutate(ctx, endpoint, reqBody, respBody)
+	}
+	return c.doURL(ctx, "POST", c.baseURL, endpoint, reqBody, respBody, false)
 }
 
-func (c *Client) post(endpoint string, reqBody, respBody any) error {
-	return c.doURL("POST", BaseURL+endpoint, reqBody, respBody)
+// postIdempotent sends a read-only request that is safe to retry.
+func (c *Client) postIdempotent(ctx context.Context, endpoint string, reqBody, respBody any) error {
+	return c.doURL(ctx, "POST", c.baseURL, endpoint, reqBody, respBody, true)
 }
 
-func (c *Client) postURL(url string, reqBody, respBody any) error {
-	return c.doURL("POST", url, reqBody, respBody)
+// postURL sends a read-only request to an endpoint under another base URL.
+func (c *Client) postURL(ctx context.Context, base, endpoint string, reqBody, respBody any) error {
+	return c.doURL(ctx, "POST", base, endpoint, reqBody, respBody, true)
 }
 
 func (c *Client) authBody() map[string]string {
@@ -104,6 +242,10 @@ func (c *Client) authBodyWith(extra map[string]any) map[string]any {
 
 // Ping checks API connectivity
 func (c *Client) Ping() error {
+	return c.PingContext(context.Background())
+}
+
+func (c *Client) PingContext(ctx context.Context) error {
 	var resp Response
-	return c.post("/ping", c.authBody(), &resp)
+	return c.postIdempotent(ctx, "/ping", c.authBody(), &resp)
 }
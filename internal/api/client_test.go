@@ -0,0 +1,197 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OverseedAI/overpork/internal/config"
+)
+
+func newTestClient(retries int) (*Client, *[]time.Duration) {
+	c := NewClient(&config.Config{APIKey: "pk1_test", SecretKey: "sk1_test", Retries: retries})
+	var sleeps []time.Duration
+	c.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	return c, &sleeps
+}
+
+// flakyServer fails the first n requests with status, then succeeds.
+func flakyServer(t *testing.T, n int32, status int, header http.Header) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= n {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"SUCCESS"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestDoURLRetriesIdempotent(t *testing.T) {
+	srv, calls := flakyServer(t, 2, http.StatusServiceUnavailable, nil)
+	c, sleeps := newTestClient(3)
+
+	var resp Response
+	if err := c.doURL(context.Background(), "POST", srv.URL, "/ping", nil, &resp, true); err != nil {
+		t.Fatalf("doURL() error = %v", err)
+	}
+	if *calls != 3 {
+		t.Errorf("calls = %d, want 3", *calls)
+	}
+	if len(*sleeps) != 2 {
+		t.Errorf("sleeps = %v, want 2 backoffs", *sleeps)
+	}
+}
+
+func TestDoURLGivesUp(t *testing.T) {
+	srv, calls := flakyServer(t, 10, http.StatusInternalServerError, nil)
+	c, _ := newTestClient(2)
+
+	if err := c.doURL(context.Background(), "POST", srv.URL, "/ping", nil, nil, true); err == nil {
+		t.Fatal("doURL() error = nil, want error")
+	}
+	if *calls != 3 {
+		t.Errorf("calls = %d, want 3", *calls)
+	}
+}
+
+func TestDoURLNoRetryForMutations(t *testing.T) {
+	srv, calls := flakyServer(t, 1, http.StatusBadGateway, nil)
+	c, _ := newTestClient(3)
+
+	if err := c.doURL(context.Background(), "POST", srv.URL, "/ping", nil, nil, false); err == nil {
+		t.Fatal("doURL() error = nil, want error")
+	}
+	if *calls != 1 {
+		t.Errorf("calls = %d, want 1", *calls)
+	}
+}
+
+func TestDoURLRetryAfter(t *testing.T) {
+	srv, calls := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"7"}})
+	c, sleeps := newTestClient(3)
+
+	// 429 is retried even for non-idempotent calls.
+	if err := c.doURL(context.Background(), "POST", srv.URL, "/ping", nil, nil, false); err != nil {
+		t.Fatalf("doURL() error = %v", err)
+	}
+	if *calls != 2 {
+		t.Errorf("calls = %d, want 2", *calls)
+	}
+	if len(*sleeps) != 1 || (*sleeps)[0] != 7*time.Second {
+		t.Errorf("sleeps = %v, want [7s]", *sleeps)
+	}
+}
+
+func TestDoURLAPIErrorNotRetried(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"status":"ERROR","message":"Invalid API key. (002)"}`))
+	}))
+	defer srv.Close()
+	c, _ := newTestClient(3)
+
+	if err := c.doURL(context.Background(), "POST", srv.URL, "/ping", nil, nil, true); err == nil {
+		t.Fatal("doURL() error = nil, want error")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestDoURLCanceled(t *testing.T) {
+	srv, calls := flakyServer(t, 10, http.StatusServiceUnavailable, nil)
+	c := NewClient(&config.Config{Retries: 5})
+	c.retryWait = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := c.doURL(ctx, "POST", srv.URL, "/ping", nil, nil, true)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("doURL() error = %v, want context.DeadlineExceeded", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("doURL() did not return promptly after cancellation")
+	}
+	if *calls != 1 {
+		t.Errorf("calls = %d, want 1", *calls)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c, _ := newTestClient(3)
+	for attempt := 0; attempt < 10; attempt++ {
+		d := c.backoff(attempt, 0)
+		ceiling := min(c.retryWait<<attempt, maxRetryWait)
+		if d < ceiling/2 || d > ceiling {
+			t.Errorf("backoff(%d) = %v, want between %v and %v", attempt, d, ceiling/2, ceiling)
+		}
+	}
+	if d := c.backoff(0, time.Hour); d != maxRetryAfter {
+		t.Errorf("backoff with long Retry-After = %v, want %v", d, maxRetryAfter)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d := parseRetryAfter("3"); d != 3*time.Second {
+		t.Errorf("parseRetryAfter(\"3\") = %v, want 3s", d)
+	}
+	future := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	if d := parseRetryAfter(future); d <= 0 || d > 10*time.Second {
+		t.Errorf("parseRetryAfter(date) = %v, want (0, 10s]", d)
+	}
+	if d := parseRetryAfter("soon"); d != 0 {
+		t.Errorf("parseRetryAfter(\"soon\") = %v, want 0", d)
+	}
+}
+
+func TestLimiterCanceled(t *testing.T) {
+	l := newLimiter(0.001, 1)
+	_ = l.wait(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	if l := newLimiter(0, 5); l != nil {
+		t.Error("newLimiter(0) != nil, want disabled limiter")
+	}
+
+	l := newLimiter(2, 2)
+	var slept time.Duration
+	l.sleep = func(ctx context.Context, d time.Duration) error {
+		slept += d
+		return nil
+	}
+
+	// The burst passes immediately; the next two wait ~0.5s each.
+	for i := 0; i < 4; i++ {
+		if err := l.wait(context.Background()); err != nil {
+			t.Fatalf("wait() error = %v", err)
+		}
+	}
+	if slept < 1400*time.Millisecond || slept > 1600*time.Millisecond {
+		t.Errorf("limiter slept %v, want about 1.5s", slept)
+	}
+}
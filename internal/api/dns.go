@@ -1,6 +1,9 @@
 package api
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 type DNSRecord struct {
 	ID      string `json:"id"`
@@ -23,17 +26,26 @@ type dnsCreateResponse struct {
 }
 
 func (c *Client) DNSList(domain string) ([]DNSRecord, error) {
+	return c.DNSListContext(context.Background(), domain)
+}
+
+func (c *Client) DNSListContext(ctx context.Context, domain string) ([]DNSRecord, error) {
 	var resp dnsListResponse
-	err := c.post(fmt.Sprintf("/dns/retrieve/%s", domain), c.authBody(), &resp)
+	err := c.postIdempotent(ctx, fmt.Sprintf("/dns/retrieve/%s", domain), c.authBody(), &resp)
 	if err != nil {
 		return nil, err
 	}
+	c.cacheRecords(domain, resp.Records)
 	return resp.Records, nil
 }
 
 func (c *Client) DNSListByType(domain, recordType string) ([]DNSRecord, error) {
+	return c.DNSListByTypeContext(context.Background(), domain, recordType)
+}
+
+func (c *Client) DNSListByTypeContext(ctx context.Context, domain, recordType string) ([]DNSRecord, error) {
 	var resp dnsListResponse
-	err := c.post(fmt.Sprintf("/dns/retrieveByNameType/%s/%s", domain, recordType), c.authBody(), &resp)
+	err := c.postIdempotent(ctx, fmt.Sprintf("/dns/retrieveByNameType/%s/%s", domain, recordType), c.authBody(), &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -41,9 +53,13 @@ func (c *Client) DNSListByType(domain, recordType string) ([]DNSRecord, error) {
 }
 
 func (c *Client) DNSListByTypeAndSubdomain(domain, recordType, subdomain string) ([]DNSRecord, error) {
+	return c.DNSListByTypeAndSubdomainContext(context.Background(), domain, recordType, subdomain)
+}
+
+func (c *Client) DNSListByTypeAndSubdomainContext(ctx context.Context, domain, recordType, subdomain string) ([]DNSRecord, error) {
 	var resp dnsListResponse
 	endpoint := fmt.Sprintf("/dns/retrieveByNameType/%s/%s/%s", domain, recordType, subdomain)
-	err := c.post(endpoint, c.authBody(), &resp)
+	err := c.postIdempotent(ctx, endpoint, c.authBody(), &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -51,6 +67,10 @@ func (c *Client) DNSListByTypeAndSubdomain(domain, recordType, subdomain string)
 }
 
 func (c *Client) DNSCreate(domain, recordType, content string, opts DNSCreateOpts) (int64, error) {
+	return c.DNSCreateContext(context.Background(), domain, recordType, content, opts)
+}
+
+func (c *Client) DNSCreateContext(ctx context.Context, domain, recordType, content string, opts DNSCreateOpts) (int64, error) {
 	body := c.authBodyWith(map[string]any{
 		"type":    recordType,
 		"content": content,
@@ -66,7 +86,7 @@ func (c *Client) DNSCreate(domain, recordType, content string, opts DNSCreateOpt
 	}
 
 	var resp dnsCreateResponse
-	err := c.post(fmt.Sprintf("/dns/create/%s", domain), body, &resp)
+	err := c.post(ctx, fmt.Sprintf("/dns/create/%s", domain), body, &resp)
 	if err != nil {
 		return 0, err
 	}
@@ -80,6 +100,10 @@ type DNSCreateOpts struct {
 }
 
 func (c *Client) DNSUpdate(domain, recordID, recordType, content string, opts DNSCreateOpts) error {
+	return c.DNSUpdateContext(context.Background(), domain, recordID, recordType, content, opts)
+}
+
+func (c *Client) DNSUpdateContext(ctx context.Context, domain, recordID, recordType, content string, opts DNSCreateOpts) error {
 	body := c.authBodyWith(map[string]any{
 		"type":    recordType,
 		"content": content,
@@ -95,10 +119,14 @@ func (c *Client) DNSUpdate(domain, recordID, recordType, content string, opts DN
 	}
 
 	var resp Response
-	return c.post(fmt.Sprintf("/dns/edit/%s/%s", domain, recordID), body, &resp)
+	return c.post(ctx, fmt.Sprintf("/dns/edit/%s/%s", domain, recordID), body, &resp)
 }
 
 func (c *Client) DNSUpdateByTypeAndSubdomain(domain, recordType, subdomain, content string, opts DNSCreateOpts) error {
+	return c.DNSUpdateByTypeAndSubdomainContext(context.Background(), domain, recordType, subdomain, content, opts)
+}
+
+func (c *Client) DNSUpdateByTypeAndSubdomainContext(ctx context.Context, domain, recordType, subdomain, content string, opts DNSCreateOpts) error {
 	body := c.authBodyWith(map[string]any{
 		"type":    recordType,
 		"content": content,
@@ -115,16 +143,24 @@ func (c *Client) DNSUpdateByTypeAndSubdomain(domain, recordType, subdomain, cont
 
 	var resp Response
 	endpoint := fmt.Sprintf("/dns/editByNameType/%s/%s/%s", domain, recordType, subdomain)
-	return c.post(endpoint, body, &resp)
+	return c.post(ctx, endpoint, body, &resp)
 }
 
 func (c *Client) DNSDelete(domain, recordID string) error {
+	return c.DNSDeleteContext(context.Background(), domain, recordID)
+}
+
+func (c *Client) DNSDeleteContext(ctx context.Context, domain, recordID string) error {
 	var resp Response
-	return c.post(fmt.Sprintf("/dns/delete/%s/%s", domain, recordID), c.authBody(), &resp)
+	return c.post(ctx, fmt.Sprintf("/dns/delete/%s/%s", domain, recordID), c.authBody(), &resp)
 }
 
 func (c *Client) DNSDeleteByTypeAndSubdomain(domain, recordType, subdomain string) error {
+	return c.DNSDeleteByTypeAndSubdomainContext(context.Background(), domain, recordType, subdomain)
+}
+
+func (c *Client) DNSDeleteByTypeAndSubdomainContext(ctx context.Context, domain, recordType, subdomain string) error {
 	var resp Response
 	endpoint := fmt.Sprintf("/dns/deleteByNameType/%s/%s/%s", domain, recordType, subdomain)
-	return c.post(endpoint, c.authBody(), &resp)
+	return c.post(ctx, endpoint, c.authBody(), &resp)
 }
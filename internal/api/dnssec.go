@@ -1,6 +1,9 @@
 package api
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 type DNSSECRecord struct {
 	KeyTag     string `json:"keyTag"`
@@ -17,8 +20,12 @@ type dnssecListResponse struct {
 }
 
 func (c *Client) DNSSECList(domain string) ([]DNSSECRecord, error) {
+	return c.DNSSECListContext(context.Background(), domain)
+}
+
+func (c *Client) DNSSECListContext(ctx context.Context, domain string) ([]DNSSECRecord, error) {
 	var resp dnssecListResponse
-	err := c.post(fmt.Sprintf("/dns/getDnssecRecords/%s", domain), c.authBody(), &resp)
+	err := c.postIdempotent(ctx, fmt.Sprintf("/dns/getDnssecRecords/%s", domain), c.authBody(), &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -26,6 +33,10 @@ func (c *Client) DNSSECList(domain string) ([]DNSSECRecord, error) {
 }
 
 func (c *Client) DNSSECCreate(domain string, record DNSSECRecord) error {
+	return c.DNSSECCreateContext(context.Background(), domain, record)
+}
+
+func (c *Client) DNSSECCreateContext(ctx context.Context, domain string, record DNSSECRecord) error {
 	body := c.authBodyWith(map[string]any{
 		"keyTag":     record.KeyTag,
 		"algorithm":  record.Algorithm,
@@ -40,10 +51,14 @@ func (c *Client) DNSSECCreate(domain string, record DNSSECRecord) error {
 	}
 
 	var resp Response
-	return c.post(fmt.Sprintf("/dns/createDnssecRecord/%s", domain), body, &resp)
+	return c.post(ctx, fmt.Sprintf("/dns/createDnssecRecord/%s", domain), body, &resp)
 }
 
 func (c *Client) DNSSECDelete(domain, keyTag string) error {
+	return c.DNSSECDeleteContext(context.Background(), domain, keyTag)
+}
+
+func (c *Client) DNSSECDeleteContext(ctx context.Context, domain, keyTag string) error {
 	var resp Response
-	return c.post(fmt.Sprintf("/dns/deleteDnssecRecord/%s/%s", domain, keyTag), c.authBody(), &resp)
+	return c.post(ctx, fmt.Sprintf("/dns/deleteDnssecRecord/%s/%s", domain, keyTag), c.authBody(), &resp)
 }
@@ -1,6 +1,10 @@
 package api
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"iter"
+)
 
 type Domain struct {
 	Domain       string `json:"domain"`
@@ -34,22 +38,72 @@ type domainGetResponse struct {
 }
 
 func (c *Client) DomainList(start int) ([]Domain, error) {
+	return c.DomainListContext(context.Background(), start)
+}
+
+func (c *Client) DomainListContext(ctx context.Context, start int) ([]Domain, error) {
 	body := c.authBodyWith(map[string]any{})
 	if start > 0 {
 		body["start"] = start
 	}
 
 	var resp domainListResponse
-	err := c.post("/domain/listAll", body, &resp)
+	err := c.postIdempotent(ctx, "/domain/listAll", body, &resp)
 	if err != nil {
 		return nil, err
 	}
 	return resp.Domains, nil
 }
 
+// Domains iterates over every domain in the account from index start,
+// requesting further pages as needed. Iteration stops at the first error,
+// which is yielded with a zero Domain.
+func (c *Client) Domains(ctx context.Context, start int) iter.Seq2[Domain, error] {
+	return func(yield func(Domain, error) bool) {
+		for {
+			page, err := c.DomainListContext(ctx, start)
+			if err != nil {
+				yield(Domain{}, err)
+				return
+			}
+			// Porkbun signals the end of the list with an empty page.
+			if len(page) == 0 {
+				return
+			}
+			for _, d := range page {
+				if !yield(d, nil) {
+					return
+				}
+			}
+			start += len(page)
+		}
+	}
+}
+
+func (c *Client) DomainListAll() ([]Domain, error) {
+	return c.DomainListAllContext(context.Background())
+}
+
+// DomainListAllContext returns every domain in the account, following
+// pages until the list is exhausted.
+func (c *Client) DomainListAllContext(ctx context.Context) ([]Domain, error) {
+	domains := []Domain{}
+	for d, err := range c.Domains(ctx, 0) {
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, d)
+	}
+	return domains, nil
+}
+
 func (c *Client) DomainGet(domain string) (*Domain, error) {
+	return c.DomainGetContext(context.Background(), domain)
+}
+
+func (c *Client) DomainGetContext(ctx context.Context, domain string) (*Domain, error) {
 	var resp domainGetResponse
-	err := c.post(fmt.Sprintf("/domain/getDomain/%s", domain), c.authBody(), &resp)
+	err := c.postIdempotent(ctx, fmt.Sprintf("/domain/getDomain/%s", domain), c.authBody(), &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -67,19 +121,27 @@ func (c *Client) DomainGet(domain string) (*Domain, error) {
 }
 
 func (c *Client) DomainUpdateNameservers(domain string, nameservers []string) error {
+	return c.DomainUpdateNameserversContext(context.Background(), domain, nameservers)
+}
+
+func (c *Client) DomainUpdateNameserversContext(ctx context.Context, domain string, nameservers []string) error {
 	body := c.authBodyWith(map[string]any{
 		"ns": nameservers,
 	})
 	var resp Response
-	return c.post(fmt.Sprintf("/domain/updateNs/%s", domain), body, &resp)
+	return c.post(ctx, fmt.Sprintf("/domain/updateNs/%s", domain), body, &resp)
 }
 
 func (c *Client) DomainGetNameservers(domain string) ([]string, error) {
+	return c.DomainGetNameserversContext(context.Background(), domain)
+}
+
+func (c *Client) DomainGetNameserversContext(ctx context.Context, domain string) ([]string, error) {
 	var resp struct {
 		Response
 		NS []string `json:"ns"`
 	}
-	err := c.post(fmt.Sprintf("/domain/getNs/%s", domain), c.authBody(), &resp)
+	err := c.postIdempotent(ctx, fmt.Sprintf("/domain/getNs/%s", domain), c.authBody(), &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -87,6 +149,10 @@ func (c *Client) DomainGetNameservers(domain string) ([]string, error) {
 }
 
 func (c *Client) DomainAddForward(domain, location string, opts ForwardOpts) error {
+	return c.DomainAddForwardContext(context.Background(), domain, location, opts)
+}
+
+func (c *Client) DomainAddForwardContext(ctx context.Context, domain, location string, opts ForwardOpts) error {
 	body := c.authBodyWith(map[string]any{
 		"location": location,
 	})
@@ -103,7 +169,7 @@ func (c *Client) DomainAddForward(domain, location string, opts ForwardOpts) err
 		body["subdomain"] = opts.Subdomain
 	}
 	var resp Response
-	return c.post(fmt.Sprintf("/domain/addUrlForward/%s", domain), body, &resp)
+	return c.post(ctx, fmt.Sprintf("/domain/addUrlForward/%s", domain), body, &resp)
 }
 
 type ForwardOpts struct {
@@ -114,11 +180,15 @@ type ForwardOpts struct {
 }
 
 func (c *Client) DomainGetForwards(domain string) ([]URLForward, error) {
+	return c.DomainGetForwardsContext(context.Background(), domain)
+}
+
+func (c *Client) DomainGetForwardsContext(ctx context.Context, domain string) ([]URLForward, error) {
 	var resp struct {
 		Response
 		Forwards []URLForward `json:"forwards"`
 	}
-	err := c.post(fmt.Sprintf("/domain/getUrlForwarding/%s", domain), c.authBody(), &resp)
+	err := c.postIdempotent(ctx, fmt.Sprintf("/domain/getUrlForwarding/%s", domain), c.authBody(), &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -135,6 +205,10 @@ type URLForward struct {
 }
 
 func (c *Client) DomainDeleteForward(domain, forwardID string) error {
+	return c.DomainDeleteForwardContext(context.Background(), domain, forwardID)
+}
+
+func (c *Client) DomainDeleteForwardContext(ctx context.Context, domain, forwardID string) error {
 	var resp Response
-	return c.post(fmt.Sprintf("/domain/deleteUrlForward/%s/%s", domain, forwardID), c.authBody(), &resp)
+	return c.post(ctx, fmt.Sprintf("/domain/deleteUrlForward/%s/%s", domain, forwardID), c.authBody(), &resp)
 }
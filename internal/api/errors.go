@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrorKind classifies why an API call failed.
+type ErrorKind string
+
+const (
+	KindUnknown   ErrorKind = "unknown"
+	KindAuth      ErrorKind = "auth"       // bad credentials or API access not enabled
+	KindNotFound  ErrorKind = "not_found"  // domain or record does not exist
+	KindInvalid   ErrorKind = "invalid"    // request rejected as malformed
+	KindRateLimit ErrorKind = "rate_limit" // too many requests
+	KindNetwork   ErrorKind = "network"    // no response received
+	KindServer    ErrorKind = "server"     // 5xx or unparseable response
+	KindCanceled  ErrorKind = "canceled"   // context canceled or deadline exceeded
+)
+
+// Error is returned by every Client method that fails. Use errors.As to
+// inspect it.
+type Error struct {
+	Kind       ErrorKind `json:"kind"`
+	HTTPStatus int       `json:"httpStatus,omitempty"`
+	Status     string    `json:"status,omitempty"`
+	Message    string    `json:"message"`
+	Endpoint   string    `json:"endpoint,omitempty"`
+	Err        error     `json:"-"`
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Status == "ERROR":
+		return "API error: " + e.Message
+	case e.Err != nil:
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// IsKind reports whether err is an *Error of the given kind.
+func IsKind(err error, kind ErrorKind) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Kind == kind
+}
+
+// transportError wraps a failure that happened before a response was read.
+func transportError(endpoint, msg string, err error) *Error {
+	kind := KindNetwork
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		kind = KindCanceled
+	}
+	return &Error{Kind: kind, Message: msg, Endpoint: endpoint, Err: err}
+}
+
+// httpError describes a response with a status code that carries no usable
+// API payload.
+func httpError(endpoint string, status int) *Error {
+	return &Error{
+		Kind:       classifyStatus(status),
+		HTTPStatus: status,
+		Message:    fmt.Sprintf("request failed: HTTP %d %s", status, http.StatusText(status)),
+		Endpoint:   endpoint,
+	}
+}
+
+// apiError describes a response with "status": "ERROR".
+func apiError(endpoint string, httpStatus int, resp Response) *Error {
+	kind := classifyMessage(resp.Message)
+	if kind == KindUnknown {
+		kind = classifyStatus(httpStatus)
+	}
+	return &Error{
+		Kind:       kind,
+		HTTPStatus: httpStatus,
+		Status:     resp.Status,
+		Message:    resp.Message,
+		Endpoint:   endpoint,
+	}
+}
+
+func classifyStatus(status int) ErrorKind {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return KindAuth
+	case status == http.StatusNotFound:
+		return KindNotFound
+	case status == http.StatusTooManyRequests:
+		return KindRateLimit
+	case status >= 500:
+		return KindServer
+	case status >= 400:
+		return KindInvalid
+	}
+	return KindUnknown
+}
+
+// classifyMessage maps Porkbun's error messages, which are the only signal
+// for most failures, to an error kind.
+func classifyMessage(msg string) ErrorKind {
+	m := strings.ToLower(msg)
+	switch {
+	case strings.Contains(m, "api key"),
+		strings.Contains(m, "opted in"),
+		strings.Contains(m, "api access"),
+		strings.Contains(m, "not authorized"),
+		strings.Contains(m, "permission"):
+		return KindAuth
+	case strings.Contains(m, "rate limit"),
+		strings.Contains(m, "too many"),
+		strings.Contains(m, "limit exceeded"):
+		return KindRateLimit
+	case strings.Contains(m, "not found"),
+		strings.Contains(m, "invalid domain"),
+		strings.Contains(m, "invalid record id"),
+		strings.Contains(m, "could not find"),
+		strings.Contains(m, "does not exist"),
+		strings.Contains(m, "not in your account"):
+		return KindNotFound
+	case strings.Contains(m, "invalid"),
+		strings.Contains(m, "required"),
+		strings.Contains(m, "must be"):
+		return KindInvalid
+	}
+	return KindUnknown
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClassifyMessage(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want ErrorKind
+	}{
+		{"Invalid API key. (002)", KindAuth},
+		{"Domain is not opted in to API access.", KindAuth},
+		{"Invalid domain.", KindNotFound},
+		{"Could not find record.", KindNotFound},
+		{"Invalid type.", KindInvalid},
+		{"Content is required.", KindInvalid},
+		{"You have exceeded the rate limit.", KindRateLimit},
+		{"Something odd happened.", KindUnknown},
+	}
+	for _, tt := range tests {
+		if got := classifyMessage(tt.msg); got != tt.want {
+			t.Errorf("classifyMessage(%q) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestDoURLTypedErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantKind ErrorKind
+		wantMsg  string
+	}{
+		{"auth", http.StatusBadRequest, `{"status":"ERROR","message":"Invalid API key. (002)"}`, KindAuth, "API error: Invalid API key. (002)"},
+		{"not found", http.StatusBadRequest, `{"status":"ERROR","message":"Invalid domain."}`, KindNotFound, "API error: Invalid domain."},
+		{"unclassified 400", http.StatusBadRequest, `{"status":"ERROR","message":"Nope."}`, KindInvalid, "API error: Nope."},
+		{"forbidden html", http.StatusForbidden, `<html>blocked</html>`, KindAuth, "failed to parse response: invalid character '<' looking for beginning of value"},
+		{"server", http.StatusBadGateway, ``, KindServer, "request failed: HTTP 502 Bad Gateway"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			c, _ := newTestClient(0)
+
+			var resp Response
+			err := c.doURL(context.Background(), "POST", srv.URL, "/dns/retrieve/example.com", nil, &resp, true)
+
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("doURL() error = %v (%T), want *Error", err, err)
+			}
+			if apiErr.Kind != tt.wantKind {
+				t.Errorf("Kind = %q, want %q", apiErr.Kind, tt.wantKind)
+			}
+			if apiErr.HTTPStatus != tt.status {
+				t.Errorf("HTTPStatus = %d, want %d", apiErr.HTTPStatus, tt.status)
+			}
+			if apiErr.Endpoint != "/dns/retrieve/example.com" {
+				t.Errorf("Endpoint = %q, want /dns/retrieve/example.com", apiErr.Endpoint)
+			}
+			if err.Error() != tt.wantMsg {
+				t.Errorf("Error() = %q, want %q", err.Error(), tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestDoURLNetworkError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+	c, _ := newTestClient(0)
+
+	err := c.doURL(context.Background(), "POST", url, "/ping", nil, nil, true)
+	if !IsKind(err, KindNetwork) {
+		t.Errorf("doURL() error = %v, want network error", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = c.doURL(ctx, "POST", url, "/ping", nil, nil, true)
+	if !IsKind(err, KindCanceled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("doURL() with canceled context error = %v, want canceled error wrapping context.Canceled", err)
+	}
+}
@@ -0,0 +1,282 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"github.com/OverseedAI/overpork/internal/porkbuntest"
+)
+
+func newFake(t *testing.T) (*porkbuntest.Server, *api.Client) {
+	t.Helper()
+	srv := porkbuntest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddDomain(porkbuntest.Domain{Name: "example.com"})
+	return srv, api.NewClient(srv.Config())
+}
+
+func TestFakeDNS(t *testing.T) {
+	srv, c := newFake(t)
+	ctx := context.Background()
+
+	id, err := c.DNSCreateContext(ctx, "example.com", "A", "192.0.2.1", api.DNSCreateOpts{Name: "www", TTL: "300"})
+	if err != nil {
+		t.Fatalf("DNSCreate() error = %v", err)
+	}
+	if _, err := c.DNSCreateContext(ctx, "example.com", "MX", "mail.example.com", api.DNSCreateOpts{Prio: "10"}); err != nil {
+		t.Fatalf("DNSCreate(MX) error = %v", err)
+	}
+
+	records, err := c.DNSListContext(ctx, "example.com")
+	if err != nil {
+		t.Fatalf("DNSList() error = %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("DNSList() = %+v, want 2 records", records)
+	}
+	www := records[0]
+	if www.Name != "www.example.com" || www.TTL != "600" || www.Prio != "0" {
+		t.Errorf("created record = %+v, want www.example.com with TTL raised to 600", www)
+	}
+
+	if err := c.DNSUpdateByTypeAndSubdomainContext(ctx, "example.com", "A", "www", "192.0.2.2", api.DNSCreateOpts{}); err != nil {
+		t.Fatalf("DNSUpdateByTypeAndSubdomain() error = %v", err)
+	}
+	records, err = c.DNSListByTypeAndSubdomainContext(ctx, "example.com", "A", "www")
+	if err != nil || len(records) != 1 || records[0].Content != "192.0.2.2" {
+		t.Fatalf("DNSListByTypeAndSubdomain() = %+v, %v; want updated record", records, err)
+	}
+
+	if _, err := c.DNSCreateContext(ctx, "example.com", "CNAME", "example.com", api.DNSCreateOpts{Name: "www"}); !api.IsKind(err, api.KindInvalid) {
+		t.Errorf("DNSCreate(conflicting CNAME) error = %v, want invalid", err)
+	}
+	if err := c.DNSDeleteContext(ctx, "example.com", "999"); !api.IsKind(err, api.KindNotFound) {
+		t.Errorf("DNSDelete(unknown id) error = %v, want not_found", err)
+	}
+	if err := c.DNSDeleteContext(ctx, "example.com", strconv.FormatInt(id, 10)); err != nil {
+		t.Errorf("DNSDelete() error = %v", err)
+	}
+	if got := srv.Records("example.com"); len(got) != 1 || got[0].Type != "MX" {
+		t.Errorf("Records() after delete = %+v, want only MX", got)
+	}
+}
+
+func TestFakeDomains(t *testing.T) {
+	srv, c := newFake(t)
+	ctx := context.Background()
+	srv.AddDomain(porkbuntest.Domain{Name: "example.net", AutoRenew: true})
+	srv.AddDomain(porkbuntest.Domain{Name: "example.org"})
+	srv.SetPageSize(2)
+
+	first, err := c.DomainListContext(ctx, 0)
+	if err != nil || len(first) != 2 {
+		t.Fatalf("DomainList(0) = %+v, %v; want 2 domains", first, err)
+	}
+	rest, err := c.DomainListContext(ctx, 2)
+	if err != nil || len(rest) != 1 || rest[0].Domain != "example.org" {
+		t.Fatalf("DomainList(2) = %+v, %v; want example.org", rest, err)
+	}
+
+	all, err := c.DomainListAllContext(ctx)
+	if err != nil || len(all) != 3 || all[2].Domain != "example.org" {
+		t.Fatalf("DomainListAll() = %+v, %v; want 3 domains", all, err)
+	}
+
+	d, err := c.DomainGetContext(ctx, "example.net")
+	if err != nil || d.AutoRenew != "1" || d.Status != "ACTIVE" {
+		t.Errorf("DomainGet() = %+v, %v; want active with auto-renew", d, err)
+	}
+	if err := c.DomainSetAutoRenewContext(ctx, "example.net", false); err != nil {
+		t.Errorf("DomainSetAutoRenew() error = %v", err)
+	}
+
+	if err := c.DomainUpdateNameserversContext(ctx, "example.com", []string{"ns1.example.net", "ns2.example.net"}); err != nil {
+		t.Fatalf("DomainUpdateNameservers() error = %v", err)
+	}
+	ns, err := c.DomainGetNameserversContext(ctx, "example.com")
+	if err != nil || len(ns) != 2 || ns[0] != "ns1.example.net" {
+		t.Errorf("DomainGetNameservers() = %v, %v", ns, err)
+	}
+
+	if err := c.DomainAddForwardContext(ctx, "example.com", "https://example.net", api.ForwardOpts{Subdomain: "go", Type: "permanent"}); err != nil {
+		t.Fatalf("DomainAddForward() error = %v", err)
+	}
+	forwards, err := c.DomainGetForwardsContext(ctx, "example.com")
+	if err != nil || len(forwards) != 1 || forwards[0].Type != "permanent" {
+		t.Fatalf("DomainGetForwards() = %+v, %v", forwards, err)
+	}
+	if err := c.DomainDeleteForwardContext(ctx, "example.com", forwards[0].ID); err != nil {
+		t.Errorf("DomainDeleteForward() error = %v", err)
+	}
+
+	avail, price, err := c.DomainCheckContext(ctx, "fresh.dev")
+	if err != nil || !avail || price != 10.81 {
+		t.Errorf("DomainCheck(fresh.dev) = %v, %v, %v; want available at 10.81", avail, price, err)
+	}
+	if err := c.DomainRegisterContext(ctx, "fresh.dev", api.DomainCreateOpts{Years: 2}); err != nil {
+		t.Fatalf("DomainRegister() error = %v", err)
+	}
+	if err := c.DomainRegisterContext(ctx, "fresh.dev", api.DomainCreateOpts{}); err == nil {
+		t.Error("DomainRegister() of a taken domain error = nil, want error")
+	}
+
+	pricing, err := c.PricingListContext(ctx)
+	if err != nil || pricing["com"].Registration == "" {
+		t.Errorf("PricingList() = %v, %v; want com pricing", pricing, err)
+	}
+}
+
+func TestDomainsPagination(t *testing.T) {
+	srv, c := newFake(t)
+	ctx := context.Background()
+	for i := range 4 {
+		srv.AddDomain(porkbuntest.Domain{Name: "example" + strconv.Itoa(i) + ".net"})
+	}
+	srv.SetPageSize(2)
+
+	tests := []struct {
+		name     string
+		start    int
+		limit    int
+		want     int
+		requests int
+	}{
+		{"all", 0, 0, 5, 4},
+		{"from start", 3, 0, 2, 2},
+		{"stop early", 0, 3, 3, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(srv.Requests())
+			var got []api.Domain
+			for d, err := range c.Domains(ctx, tt.start) {
+				if err != nil {
+					t.Fatalf("Domains() error = %v", err)
+				}
+				got = append(got, d)
+				if len(got) == tt.limit {
+					break
+				}
+			}
+			if len(got) != tt.want {
+				t.Errorf("Domains() yielded %d domains, want %d", len(got), tt.want)
+			}
+			if n := len(srv.Requests()) - before; n != tt.requests {
+				t.Errorf("Domains() made %d requests, want %d", n, tt.requests)
+			}
+		})
+	}
+
+	srv.Fail(porkbuntest.Failure{Endpoint: "/domain/listAll", Status: 403, Message: "Invalid API key. (002)"})
+	if _, err := c.DomainListAllContext(ctx); err == nil {
+		t.Error("DomainListAll() error = nil, want error")
+	}
+}
+
+func TestFakeGlueDNSSECAndSSL(t *testing.T) {
+	srv, c := newFake(t)
+	ctx := context.Background()
+
+	if err := c.GlueCreateContext(ctx, "example.com", "ns1", []string{"192.0.2.53"}); err != nil {
+		t.Fatalf("GlueCreate() error = %v", err)
+	}
+	if err := c.GlueUpdateContext(ctx, "example.com", "ns1", []string{"192.0.2.54", "2001:db8::53"}); err != nil {
+		t.Fatalf("GlueUpdate() error = %v", err)
+	}
+	glue, err := c.GlueListContext(ctx, "example.com")
+	if err != nil || len(glue) != 1 || len(glue[0].IPs) != 2 {
+		t.Errorf("GlueList() = %+v, %v", glue, err)
+	}
+	if err := c.GlueDeleteContext(ctx, "example.com", "ns2"); err == nil {
+		t.Error("GlueDelete(missing) error = nil, want error")
+	}
+
+	ds := api.DNSSECRecord{KeyTag: "12345", Algorithm: "13", DigestType: "2", Digest: "abcdef"}
+	if err := c.DNSSECCreateContext(ctx, "example.com", ds); err != nil {
+		t.Fatalf("DNSSECCreate() error = %v", err)
+	}
+	records, err := c.DNSSECListContext(ctx, "example.com")
+	if err != nil || len(records) != 1 || records[0] != ds {
+		t.Errorf("DNSSECList() = %+v, %v", records, err)
+	}
+	if err := c.DNSSECDeleteContext(ctx, "example.com", "12345"); err != nil {
+		t.Errorf("DNSSECDelete() error = %v", err)
+	}
+
+	if _, err := c.SSLRetrieveContext(ctx, "example.com"); err == nil {
+		t.Error("SSLRetrieve() without a certificate error = nil, want error")
+	}
+	srv.AddDomain(porkbuntest.Domain{Name: "secure.com", SSL: &porkbuntest.SSL{CertificateChain: "CHAIN", PrivateKey: "KEY"}})
+	bundle, err := c.SSLRetrieveContext(ctx, "secure.com")
+	if err != nil || bundle.CertificateChain != "CHAIN" {
+		t.Errorf("SSLRetrieve() = %+v, %v", bundle, err)
+	}
+}
+
+func TestFakeErrors(t *testing.T) {
+	srv, c := newFake(t)
+	ctx := context.Background()
+	srv.AddDomain(porkbuntest.Domain{Name: "locked.com", NoAPIAccess: true})
+
+	tests := []struct {
+		name string
+		call func() error
+		want api.ErrorKind
+	}{
+		{"unknown domain", func() error { _, err := c.DNSListContext(ctx, "nope.com"); return err }, api.KindNotFound},
+		{"no api access", func() error { _, err := c.DNSListContext(ctx, "locked.com"); return err }, api.KindAuth},
+		{"invalid type", func() error {
+			_, err := c.DNSCreateContext(ctx, "example.com", "BOGUS", "x", api.DNSCreateOpts{})
+			return err
+		}, api.KindInvalid},
+		{"missing content", func() error {
+			_, err := c.DNSCreateContext(ctx, "example.com", "A", "", api.DNSCreateOpts{})
+			return err
+		}, api.KindInvalid},
+	}
+	for _, tt := range tests {
+		if err := tt.call(); !api.IsKind(err, tt.want) {
+			t.Errorf("%s: error = %v, want %s", tt.name, err, tt.want)
+		}
+	}
+
+	srv.SetCredentials("pk1_other", "sk1_other")
+	if err := c.PingContext(ctx); !api.IsKind(err, api.KindAuth) {
+		t.Errorf("Ping() with wrong keys error = %v, want auth", err)
+	}
+}
+
+func TestFakeFailureInjection(t *testing.T) {
+	srv, c := newFake(t)
+	ctx := context.Background()
+
+	srv.Fail(porkbuntest.Failure{Endpoint: "/dns/create", Message: "You have exceeded the rate limit.", Status: 503, Times: 1})
+	_, err := c.DNSCreateContext(ctx, "example.com", "A", "192.0.2.1", api.DNSCreateOpts{})
+	var apiErr *api.Error
+	if !errors.As(err, &apiErr) || apiErr.Kind != api.KindRateLimit || apiErr.HTTPStatus != 503 {
+		t.Fatalf("DNSCreate() error = %v, want injected rate limit error", err)
+	}
+	if _, err := c.DNSCreateContext(ctx, "example.com", "A", "192.0.2.1", api.DNSCreateOpts{}); err != nil {
+		t.Errorf("DNSCreate() after the failure expired error = %v", err)
+	}
+
+	srv.Fail(porkbuntest.Failure{Endpoint: "/ping", Drop: true})
+	if err := c.PingContext(ctx); !api.IsKind(err, api.KindNetwork) {
+		t.Errorf("Ping() with dropped connection error = %v, want network", err)
+	}
+	srv.ClearFailures()
+
+	srv.Fail(porkbuntest.Failure{Endpoint: "/domain/listAll", Status: 502, Times: 1})
+	cfg := srv.Config()
+	cfg.Retries = 1
+	retrying := api.NewClient(cfg)
+	if _, err := retrying.DomainListContext(ctx, 0); err != nil {
+		t.Errorf("DomainList() with one retry error = %v, want success after retry", err)
+	}
+	if n := len(srv.Requests()); n == 0 || srv.Requests()[n-1].Endpoint != "/domain/listAll" {
+		t.Errorf("Requests() = %+v, want listAll logged", srv.Requests())
+	}
+}
@@ -1,6 +1,9 @@
 package api
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 type GlueRecord struct {
 	Subdomain string   `json:"subdomain"`
@@ -13,8 +16,12 @@ type glueListResponse struct {
 }
 
 func (c *Client) GlueList(domain string) ([]GlueRecord, error) {
+	return c.GlueListContext(context.Background(), domain)
+}
+
+func (c *Client) GlueListContext(ctx context.Context, domain string) ([]GlueRecord, error) {
 	var resp glueListResponse
-	err := c.post(fmt.Sprintf("/domain/getGlue/%s", domain), c.authBody(), &resp)
+	err := c.postIdempotent(ctx, fmt.Sprintf("/domain/getGlue/%s", domain), c.authBody(), &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -22,22 +29,34 @@ func (c *Client) GlueList(domain string) ([]GlueRecord, error) {
 }
 
 func (c *Client) GlueCreate(domain, subdomain string, ips []string) error {
+	return c.GlueCreateContext(context.Background(), domain, subdomain, ips)
+}
+
+func (c *Client) GlueCreateContext(ctx context.Context, domain, subdomain string, ips []string) error {
 	body := c.authBodyWith(map[string]any{
 		"ip": ips,
 	})
 	var resp Response
-	return c.post(fmt.Sprintf("/domain/createGlue/%s/%s", domain, subdomain), body, &resp)
+	return c.post(ctx, fmt.Sprintf("/domain/createGlue/%s/%s", domain, subdomain), body, &resp)
 }
 
 func (c *Client) GlueUpdate(domain, subdomain string, ips []string) error {
+	return c.GlueUpdateContext(context.Background(), domain, subdomain, ips)
+}
+
+func (c *Client) GlueUpdateContext(ctx context.Context, domain, subdomain string, ips []string) error {
 	body := c.authBodyWith(map[string]any{
 		"ip": ips,
 	})
 	var resp Response
-	return c.post(fmt.Sprintf("/domain/updateGlue/%s/%s", domain, subdomain), body, &resp)
+	return c.post(ctx, fmt.Sprintf("/domain/updateGlue/%s/%s", domain, subdomain), body, &resp)
 }
 
 func (c *Client) GlueDelete(domain, subdomain string) error {
+	return c.GlueDeleteContext(context.Background(), domain, subdomain)
+}
+
+func (c *Client) GlueDeleteContext(ctx context.Context, domain, subdomain string) error {
 	var resp Response
-	return c.post(fmt.Sprintf("/domain/deleteGlue/%s/%s", domain, subdomain), c.authBody(), &resp)
+	return c.post(ctx, fmt.Sprintf("/domain/deleteGlue/%s/%s", domain, subdomain), c.authBody(), &resp)
 }
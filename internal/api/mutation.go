@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// Mutation describes a state-changing request, for audit logging.
+type Mutation struct {
+	Endpoint string
+	Domain   string
+	// Params is the request body, credentials included.
+	Params map[string]any
+	// Before is the state the request changes, fetched just before it was
+	// sent: the record, forward, glue or DNSSEC record it edits or deletes,
+	// the nameservers, or the domain. Nil for creations and when the fetch
+	// failed.
+	Before any
+	Err    error
+}
+
+// OnMutation registers fn to be called after every state-changing request,
+// whether it succeeded or not. Each such request then costs an extra read
+// request to fetch the state it changes, except for DNS record edits and
+// deletes: those look the record up in the domain's records, which are
+// fetched once and reused until a change makes them stale.
+func (c *Client) OnMutation(fn func(context.Context, Mutation)) {
+	c.onMutation = fn
+}
+
+// mutate sends a state-changing request and reports it to the mutation
+// hook.
+func (c *Client) mutate(ctx context.Context, endpoint string, reqBody, respBody any) error {
+	before := c.before(ctx, endpoint)
+	err := c.doURL(ctx, "POST", c.baseURL, endpoint, reqBody, respBody, false)
+
+	var params map[string]any
+	if data, merr := json.Marshal(reqBody); merr == nil {
+		_ = json.Unmarshal(data, &params)
+	}
+	domain, route, args := splitEndpoint(endpoint)
+	c.forgetRecords(domain, route, args)
+	c.onMutation(ctx, Mutation{Endpoint: endpoint, Domain: domain, Params: params, Before: before, Err: err})
+	return err
+}
+
+// splitEndpoint splits "/dns/edit/example.com/123" into the domain, the
+// route ("dns/edit") and the remaining arguments.
+func splitEndpoint(endpoint string) (domain, route string, args []string) {
+	parts := strings.Split(strings.Trim(endpoint, "/"), "/")
+	if len(parts) < 3 {
+		return "", strings.Join(parts, "/"), nil
+	}
+	return parts[2], parts[0] + "/" + parts[1], parts[3:]
+}
+
+// before fetches the state a request to endpoint is about to change.
+func (c *Client) before(ctx context.Context, endpoint string) any {
+	domain, route, args := splitEndpoint(endpoint)
+	arg := func(i int) string {
+		if i < len(args) {
+			return args[i]
+		}
+		return ""
+	}
+
+	switch route {
+	case "dns/edit", "dns/delete":
+		if r, ok := c.cachedRecord(domain, arg(0)); ok {
+			return r
+		}
+		if _, err := c.DNSListContext(ctx, domain); err == nil {
+			if r, ok := c.cachedRecord(domain, arg(0)); ok {
+				return r
+			}
+		}
+	case "dns/editByNameType", "dns/deleteByNameType":
+		records, err := c.DNSListByTypeAndSubdomainContext(ctx, domain, arg(0), arg(1))
+		if err == nil && len(records) > 0 {
+			return records
+		}
+	case "dns/deleteDnssecRecord":
+		records, err := c.DNSSECListContext(ctx, domain)
+		if i := slices.IndexFunc(records, func(r DNSSECRecord) bool { return r.KeyTag == arg(0) }); err == nil && i >= 0 {
+			return records[i]
+		}
+	case "domain/updateNs":
+		if ns, err := c.DomainGetNameserversContext(ctx, domain); err == nil {
+			return map[string]any{"ns": ns}
+		}
+	case "domain/updateAutoRenew":
+		if d, err := c.DomainGetContext(ctx, domain); err == nil {
+			return map[string]any{"autoRenew": d.AutoRenew}
+		}
+	case "domain/deleteUrlForward":
+		forwards, err := c.DomainGetForwardsContext(ctx, domain)
+		if i := slices.IndexFunc(forwards, func(f URLForward) bool { return f.ID == arg(0) }); err == nil && i >= 0 {
+			return forwards[i]
+		}
+	case "domain/updateGlue", "domain/deleteGlue":
+		glue, err := c.GlueListContext(ctx, domain)
+		if i := slices.IndexFunc(glue, func(g GlueRecord) bool { return strings.EqualFold(g.Subdomain, arg(0)) }); err == nil && i >= 0 {
+			return glue[i]
+		}
+	}
+	return nil
+}
+
+// cacheRecords remembers the records of domain while mutations are
+// reported, so that edits and deletes don't each fetch them again.
+func (c *Client) cacheRecords(domain string, records []DNSRecord) {
+	if c.onMutation == nil {
+		return
+	}
+	c.recordsMu.Lock()
+	defer c.recordsMu.Unlock()
+	if c.records == nil {
+		c.records = make(map[string][]DNSRecord)
+	}
+	c.records[strings.ToLower(domain)] = slices.Clone(records)
+}
+
+// cachedRecord returns the cached record of domain with the given ID.
+func (c *Client) cachedRecord(domain, id string) (DNSRecord, bool) {
+	c.recordsMu.Lock()
+	defer c.recordsMu.Unlock()
+	records := c.records[strings.ToLower(domain)]
+	if i := slices.IndexFunc(records, func(r DNSRecord) bool { return r.ID == id }); i >= 0 {
+		return records[i], true
+	}
+	return DNSRecord{}, false
+}
+
+// forgetRecords drops the cached records a request may have changed,
+// whether it succeeded or not: the record it edits or deletes by ID, or the
+// whole domain for changes by name and type. Records created since are
+// missing from the cache and trigger a fresh fetch.
+func (c *Client) forgetRecords(domain, route string, args []string) {
+	c.recordsMu.Lock()
+	defer c.recordsMu.Unlock()
+	key := strings.ToLower(domain)
+	switch route {
+	case "dns/edit", "dns/delete":
+		if records, ok := c.records[key]; ok && len(args) > 0 {
+			c.records[key] = slices.DeleteFunc(records, func(r DNSRecord) bool { return r.ID == args[0] })
+		}
+	case "dns/editByNameType", "dns/deleteByNameType":
+		delete(c.records, key)
+	}
+}
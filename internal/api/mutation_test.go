@@ -0,0 +1,118 @@
+package api_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"github.com/OverseedAI/overpork/internal/porkbuntest"
+)
+
+func TestOnMutation(t *testing.T) {
+	srv, c := newFake(t)
+	ctx := context.Background()
+	id := srv.AddRecord("example.com", porkbuntest.Record{Name: "www", Type: "A", Content: "192.0.2.1"})
+
+	var got []api.Mutation
+	c.OnMutation(func(ctx context.Context, m api.Mutation) { got = append(got, m) })
+
+	if _, err := c.DNSListContext(ctx, "example.com"); err != nil {
+		t.Fatalf("DNSList() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("read-only call reported %d mutations", len(got))
+	}
+
+	if err := c.DNSUpdateContext(ctx, "example.com", id, "A", "192.0.2.2", api.DNSCreateOpts{Name: "www"}); err != nil {
+		t.Fatalf("DNSUpdate() error = %v", err)
+	}
+	if err := c.DNSDeleteContext(ctx, "example.com", "999"); err == nil {
+		t.Fatal("DNSDelete(unknown id) succeeded")
+	}
+	if err := c.DomainUpdateNameserversContext(ctx, "example.com", []string{"ns1.example.net"}); err != nil {
+		t.Fatalf("DomainUpdateNameservers() error = %v", err)
+	}
+	if _, err := c.DNSCreateContext(ctx, "example.com", "TXT", "hello", api.DNSCreateOpts{}); err != nil {
+		t.Fatalf("DNSCreate() error = %v", err)
+	}
+
+	if len(got) != 4 {
+		t.Fatalf("got %d mutations, want 4", len(got))
+	}
+
+	update := got[0]
+	if update.Endpoint != "/dns/edit/example.com/"+id || update.Domain != "example.com" || update.Err != nil {
+		t.Errorf("update = %+v", update)
+	}
+	if update.Params["content"] != "192.0.2.2" || update.Params["secretapikey"] == nil {
+		t.Errorf("update params = %v, want the full request body", update.Params)
+	}
+	if before, ok := update.Before.(api.DNSRecord); !ok || before.Content != "192.0.2.1" {
+		t.Errorf("update before = %#v, want the record before the edit", update.Before)
+	}
+
+	if del := got[1]; del.Err == nil || del.Before != nil {
+		t.Errorf("failed delete = %+v, want an error and no before-state", del)
+	}
+	if ns, ok := got[2].Before.(map[string]any); !ok || len(ns["ns"].([]string)) == 0 {
+		t.Errorf("nameservers before = %#v", got[2].Before)
+	}
+	if got[3].Before != nil {
+		t.Errorf("create before = %#v, want nil", got[3].Before)
+	}
+}
+
+func TestMutationBeforeCache(t *testing.T) {
+	srv, c := newFake(t)
+	ctx := context.Background()
+	www := srv.AddRecord("example.com", porkbuntest.Record{Name: "www", Type: "A", Content: "192.0.2.1"})
+	mail := srv.AddRecord("example.com", porkbuntest.Record{Name: "mail", Type: "A", Content: "192.0.2.2"})
+	ftp := srv.AddRecord("example.com", porkbuntest.Record{Name: "ftp", Type: "A", Content: "192.0.2.3"})
+
+	var got []api.Mutation
+	c.OnMutation(func(ctx context.Context, m api.Mutation) { got = append(got, m) })
+	retrieves := func() int {
+		n := 0
+		for _, r := range srv.Requests() {
+			if r.Endpoint == "/dns/retrieve/example.com" {
+				n++
+			}
+		}
+		return n
+	}
+
+	if _, err := c.DNSListContext(ctx, "example.com"); err != nil {
+		t.Fatalf("DNSList() error = %v", err)
+	}
+	steps := []struct {
+		name       string
+		do         func() error
+		retrieves  int
+		wantBefore string
+	}{
+		{"edit listed record", func() error {
+			return c.DNSUpdateContext(ctx, "example.com", www, "A", "192.0.2.10", api.DNSCreateOpts{Name: "www"})
+		}, 1, "192.0.2.1"},
+		{"delete listed record", func() error { return c.DNSDeleteContext(ctx, "example.com", mail) }, 1, "192.0.2.2"},
+		{"edit edited record", func() error {
+			return c.DNSUpdateContext(ctx, "example.com", www, "A", "192.0.2.11", api.DNSCreateOpts{Name: "www"})
+		}, 2, "192.0.2.10"},
+		{"edit after edit by name and type", func() error {
+			if err := c.DNSUpdateByTypeAndSubdomainContext(ctx, "example.com", "A", "ftp", "192.0.2.30", api.DNSCreateOpts{}); err != nil {
+				return err
+			}
+			return c.DNSUpdateContext(ctx, "example.com", ftp, "A", "192.0.2.31", api.DNSCreateOpts{Name: "ftp"})
+		}, 3, "192.0.2.30"},
+	}
+	for _, tt := range steps {
+		if err := tt.do(); err != nil {
+			t.Fatalf("%s: error = %v", tt.name, err)
+		}
+		if n := retrieves(); n != tt.retrieves {
+			t.Errorf("%s: %d record fetches, want %d", tt.name, n, tt.retrieves)
+		}
+		if before, ok := got[len(got)-1].Before.(api.DNSRecord); !ok || before.Content != tt.wantBefore {
+			t.Errorf("%s: before = %#v, want content %q", tt.name, got[len(got)-1].Before, tt.wantBefore)
+		}
+	}
+}
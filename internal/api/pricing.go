@@ -1,7 +1,11 @@
 package api
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
+// PricingURL is the default pricing endpoint; see config.Config.PricingURL.
 const PricingURL = "https://porkbun.com/api/json/v3"
 
 type Pricing struct {
@@ -19,9 +23,13 @@ type pricingResponse struct {
 }
 
 func (c *Client) PricingList() (map[string]Pricing, error) {
+	return c.PricingListContext(context.Background())
+}
+
+func (c *Client) PricingListContext(ctx context.Context) (map[string]Pricing, error) {
 	var resp pricingResponse
 	// Pricing endpoint uses porkbun.com (not api.porkbun.com)
-	err := c.postURL(PricingURL+"/pricing/get", map[string]string{}, &resp)
+	err := c.postURL(ctx, c.pricingURL, "/pricing/get", map[string]string{}, &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -29,13 +37,17 @@ func (c *Client) PricingList() (map[string]Pricing, error) {
 }
 
 func (c *Client) DomainCheck(domain string) (bool, float64, error) {
+	return c.DomainCheckContext(context.Background(), domain)
+}
+
+func (c *Client) DomainCheckContext(ctx context.Context, domain string) (bool, float64, error) {
 	var resp struct {
 		Response
 		Available string `json:"avail"`
 		Price     string `json:"price"`
 	}
 	// Auth not required for availability check
-	err := c.post("/domain/checkDomain/"+domain, map[string]string{}, &resp)
+	err := c.postIdempotent(ctx, "/domain/checkDomain/"+domain, map[string]string{}, &resp)
 	if err != nil {
 		return false, 0, err
 	}
@@ -1,6 +1,9 @@
 package api
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 type DomainCreateOpts struct {
 	Years        int
@@ -16,6 +19,10 @@ type domainCreateResponse struct {
 }
 
 func (c *Client) DomainRegister(domain string, opts DomainCreateOpts) error {
+	return c.DomainRegisterContext(context.Background(), domain, opts)
+}
+
+func (c *Client) DomainRegisterContext(ctx context.Context, domain string, opts DomainCreateOpts) error {
 	body := c.authBodyWith(map[string]any{})
 
 	if opts.Years > 0 {
@@ -35,10 +42,14 @@ func (c *Client) DomainRegister(domain string, opts DomainCreateOpts) error {
 	}
 
 	var resp domainCreateResponse
-	return c.post(fmt.Sprintf("/domain/create/%s", domain), body, &resp)
+	return c.post(ctx, fmt.Sprintf("/domain/create/%s", domain), body, &resp)
 }
 
 func (c *Client) DomainSetAutoRenew(domain string, enabled bool) error {
+	return c.DomainSetAutoRenewContext(context.Background(), domain, enabled)
+}
+
+func (c *Client) DomainSetAutoRenewContext(ctx context.Context, domain string, enabled bool) error {
 	status := "disable"
 	if enabled {
 		status = "enable"
@@ -47,5 +58,5 @@ func (c *Client) DomainSetAutoRenew(domain string, enabled bool) error {
 		"autoRenew": status,
 	})
 	var resp Response
-	return c.post(fmt.Sprintf("/domain/updateAutoRenew/%s", domain), body, &resp)
+	return c.post(ctx, fmt.Sprintf("/domain/updateAutoRenew/%s", domain), body, &resp)
 }
@@ -1,6 +1,9 @@
 package api
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 type SSLBundle struct {
 	IntermediateCertificate string `json:"intermediatecertificate"`
@@ -15,8 +18,12 @@ type sslResponse struct {
 }
 
 func (c *Client) SSLRetrieve(domain string) (*SSLBundle, error) {
+	return c.SSLRetrieveContext(context.Background(), domain)
+}
+
+func (c *Client) SSLRetrieveContext(ctx context.Context, domain string) (*SSLBundle, error) {
 	var resp sslResponse
-	err := c.post(fmt.Sprintf("/ssl/retrieve/%s", domain), c.authBody(), &resp)
+	err := c.postIdempotent(ctx, fmt.Sprintf("/ssl/retrieve/%s", domain), c.authBody(), &resp)
 	if err != nil {
 		return nil, err
 	}
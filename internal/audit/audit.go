@@ -0,0 +1,201 @@
+// Package audit keeps a local log of the changes made through the API, one
+// JSON object per line, so people sharing credentials can tell who changed
+// what.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is one state-changing API call.
+type Entry struct {
+	Time     time.Time      `json:"time"`
+	Profile  string         `json:"profile"`
+	User     string         `json:"user"`
+	Host     string         `json:"host,omitempty"`
+	Command  string         `json:"command"`
+	Endpoint string         `json:"endpoint"`
+	Domain   string         `json:"domain,omitempty"`
+	Params   map[string]any `json:"params,omitempty"`
+	// Before is the state the call changed, when it could be fetched.
+	Before any    `json:"before,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Log appends entries to a file. It is safe for concurrent use.
+type Log struct {
+	path string
+	mu   sync.Mutex
+}
+
+func Open(path string) *Log {
+	return &Log{path: path}
+}
+
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append writes e as a single line, creating the file (readable only by
+// its owner) if needed.
+func (l *Log) Append(e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return f.Close()
+}
+
+// Filter selects entries. Zero fields match everything.
+type Filter struct {
+	Domain string
+	Since  time.Time
+}
+
+func (f Filter) match(e Entry) bool {
+	if f.Domain != "" && !strings.EqualFold(e.Domain, f.Domain) {
+		return false
+	}
+	return f.Since.IsZero() || !e.Time.Before(f.Since)
+}
+
+// Read returns the entries of the log at path that match f, oldest first.
+// A missing log has no entries.
+func Read(path string, f Filter) ([]Entry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed to parse audit log line %d: %w", line, err)
+		}
+		if f.match(e) {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// Redacted replaces secret values.
+const Redacted = "[redacted]"
+
+// sensitive reports whether a parameter or flag name holds a secret.
+func sensitive(name string) bool {
+	name = strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(name))
+	if name == "apikey" {
+		return true
+	}
+	for _, s := range []string{"secret", "password", "passphrase", "token"} {
+		if strings.Contains(name, s) && !strings.HasSuffix(name, "command") {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns a copy of request parameters with secrets, such as the API
+// keys sent with every request, replaced by Redacted.
+func Redact(params map[string]any) map[string]any {
+	if params == nil {
+		return nil
+	}
+	out := make(map[string]any, len(params))
+	for k, v := range params {
+		switch {
+		case sensitive(k):
+			out[k] = Redacted
+		case isMap(v):
+			out[k] = Redact(v.(map[string]any))
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func isMap(v any) bool {
+	_, ok := v.(map[string]any)
+	return ok
+}
+
+// CommandLine joins a command's arguments for the log, redacting the values
+// of secret flags and quoting arguments with spaces.
+func CommandLine(args []string) string {
+	out := make([]string, len(args))
+	redactNext := false
+	for i, arg := range args {
+		switch {
+		case redactNext:
+			arg, redactNext = Redacted, false
+		case strings.HasPrefix(arg, "-"):
+			name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			if sensitive(name) {
+				if hasValue {
+					arg = arg[:strings.Index(arg, "=")+1] + Redacted
+				} else {
+					redactNext = true
+				}
+			}
+		}
+		if i == 0 {
+			arg = filepath.Base(arg)
+		}
+		if strings.ContainsAny(arg, " \t\"'") {
+			arg = fmt.Sprintf("%q", arg)
+		}
+		out[i] = arg
+	}
+	return strings.Join(out, " ")
+}
+
+// CurrentUser returns the name of the OS user running the command.
+func CurrentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	for _, env := range []string{"USER", "USERNAME"} {
+		if name := os.Getenv(env); name != "" {
+			return name
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "audit.jsonl")
+	log := Open(path)
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Time: start, User: "alice", Endpoint: "/dns/create/example.com", Domain: "example.com"},
+		{Time: start.Add(time.Hour), User: "bob", Endpoint: "/dns/delete/example.net/1", Domain: "example.net", Error: "boom"},
+		{Time: start.Add(2 * time.Hour), User: "alice", Endpoint: "/domain/updateNs/example.com", Domain: "example.com",
+			Before: map[string]any{"ns": []any{"a.ns.example"}}},
+	}
+	for _, e := range entries {
+		if err := log.Append(e); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("log file mode = %v, %v; want 0600", info.Mode(), err)
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"all", Filter{}, []string{"alice", "bob", "alice"}},
+		{"domain", Filter{Domain: "EXAMPLE.com"}, []string{"alice", "alice"}},
+		{"since", Filter{Since: start.Add(time.Hour)}, []string{"bob", "alice"}},
+		{"both", Filter{Domain: "example.net", Since: start.Add(90 * time.Minute)}, nil},
+	}
+	for _, tt := range tests {
+		got, err := Read(path, tt.filter)
+		if err != nil {
+			t.Fatalf("%s: Read() error = %v", tt.name, err)
+		}
+		var users []string
+		for _, e := range got {
+			users = append(users, e.User)
+		}
+		if len(users) != len(tt.want) {
+			t.Errorf("%s: users = %v, want %v", tt.name, users, tt.want)
+			continue
+		}
+		for i := range users {
+			if users[i] != tt.want[i] {
+				t.Errorf("%s: users = %v, want %v", tt.name, users, tt.want)
+				break
+			}
+		}
+	}
+
+	if got, err := Read(filepath.Join(t.TempDir(), "missing.jsonl"), Filter{}); err != nil || got != nil {
+		t.Errorf("Read(missing) = %v, %v; want no entries", got, err)
+	}
+	bad := filepath.Join(t.TempDir(), "bad.jsonl")
+	if err := os.WriteFile(bad, []byte("{\"user\":\"a\"}\nnot json\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(bad, Filter{}); err == nil {
+		t.Error("Read(malformed) succeeded, want error")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	got := Redact(map[string]any{
+		"apikey":       "pk1_x",
+		"secretapikey": "sk1_x",
+		"content":      "192.0.2.1",
+		"keyTag":       "12345",
+		"nested":       map[string]any{"token": "t", "ok": 1},
+	})
+	for k, want := range map[string]any{"apikey": Redacted, "secretapikey": Redacted, "content": "192.0.2.1", "keyTag": "12345"} {
+		if got[k] != want {
+			t.Errorf("Redact()[%q] = %v, want %v", k, got[k], want)
+		}
+	}
+	if nested := got["nested"].(map[string]any); nested["token"] != Redacted || nested["ok"] != 1 {
+		t.Errorf("Redact() nested = %v", nested)
+	}
+	if Redact(nil) != nil {
+		t.Error("Redact(nil) != nil")
+	}
+}
+
+func TestCommandLine(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"/usr/local/bin/opork", "dns", "create", "example.com", "A", "192.0.2.1"},
+			"opork dns create example.com A 192.0.2.1"},
+		{[]string{"opork", "config", "init", "--api-key", "pk1_x", "--secret-key=sk1_x"},
+			"opork config init --api-key [redacted] --secret-key=[redacted]"},
+		{[]string{"opork", "config", "init", "--secret-command", "pass show porkbun"},
+			`opork config init --secret-command "pass show porkbun"`},
+	}
+	for _, tt := range tests {
+		if got := CommandLine(tt.args); got != tt.want {
+			t.Errorf("CommandLine(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+// Package backup snapshots the state of an account's domains and restores
+// it.
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/OverseedAI/overpork/internal/api"
+)
+
+// Version is the snapshot format version written by this package.
+const Version = 1
+
+// Formats accepted by Write.
+const (
+	FormatJSON = "json"
+	FormatTar  = "tar.gz"
+)
+
+// Parts of a domain's state, used as keys in DomainSnapshot.Errors.
+const (
+	PartDNS         = "dns"
+	PartForwards    = "forwards"
+	PartNameservers = "nameservers"
+	PartGlue        = "glue"
+	PartDNSSEC      = "dnssec"
+	PartSettings    = "settings"
+)
+
+// Parts lists every part in restore order.
+var Parts = []string{PartSettings, PartGlue, PartNameservers, PartDNSSEC, PartForwards, PartDNS}
+
+// Snapshot is the saved state of one or more domains.
+type Snapshot struct {
+	Version   int              `json:"version"`
+	CreatedAt time.Time        `json:"createdAt"`
+	Domains   []DomainSnapshot `json:"domains"`
+}
+
+// DomainSnapshot is the saved state of one domain. Parts that could not be
+// read are listed in Errors and left alone on restore.
+type DomainSnapshot struct {
+	Domain      string             `json:"domain"`
+	Settings    api.Domain         `json:"settings"`
+	Nameservers []string           `json:"nameservers"`
+	Records     []api.DNSRecord    `json:"records"`
+	Forwards    []api.URLForward   `json:"forwards"`
+	Glue        []api.GlueRecord   `json:"glue"`
+	DNSSEC      []api.DNSSECRecord `json:"dnssec"`
+	Errors      map[string]string  `json:"errors,omitempty"`
+}
+
+// Has reports whether the snapshot holds the given part.
+func (d *DomainSnapshot) Has(part string) bool {
+	_, failed := d.Errors[part]
+	return !failed
+}
+
+// Find returns the snapshot of a domain, or nil.
+func (s *Snapshot) Find(domain string) *DomainSnapshot {
+	for i := range s.Domains {
+		if strings.EqualFold(s.Domains[i].Domain, domain) {
+			return &s.Domains[i]
+		}
+	}
+	return nil
+}
+
+// Reader is the subset of api.Client needed to take a snapshot.
+type Reader interface {
+	DNSListContext(ctx context.Context, domain string) ([]api.DNSRecord, error)
+	DomainGetForwardsContext(ctx context.Context, domain string) ([]api.URLForward, error)
+	DomainGetNameserversContext(ctx context.Context, domain string) ([]string, error)
+	GlueListContext(ctx context.Context, domain string) ([]api.GlueRecord, error)
+	DNSSECListContext(ctx context.Context, domain string) ([]api.DNSSECRecord, error)
+}
+
+// Collect snapshots the given domains. A part that cannot be read is
+// recorded in the domain's Errors rather than failing the whole backup;
+// only cancellation aborts. progress, if set, is called before each domain.
+func Collect(ctx context.Context, c Reader, domains []api.Domain, progress func(domain string)) (*Snapshot, error) {
+	snap := &Snapshot{Version: Version, CreatedAt: time.Now().UTC().Truncate(time.Second), Domains: []DomainSnapshot{}}
+	for _, d := range domains {
+		if progress != nil {
+			progress(d.Domain)
+		}
+		ds := DomainSnapshot{Domain: d.Domain, Settings: d}
+		record := func(part string, err error) {
+			if err != nil {
+				if ds.Errors == nil {
+					ds.Errors = map[string]string{}
+				}
+				ds.Errors[part] = err.Error()
+			}
+		}
+
+		var err error
+		ds.Records, err = c.DNSListContext(ctx, d.Domain)
+		record(PartDNS, err)
+		ds.Forwards, err = c.DomainGetForwardsContext(ctx, d.Domain)
+		record(PartForwards, err)
+		ds.Nameservers, err = c.DomainGetNameserversContext(ctx, d.Domain)
+		record(PartNameservers, err)
+		ds.Glue, err = c.GlueListContext(ctx, d.Domain)
+		record(PartGlue, err)
+		ds.DNSSEC, err = c.DNSSECListContext(ctx, d.Domain)
+		record(PartDNSSEC, err)
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		snap.Domains = append(snap.Domains, ds)
+	}
+	return snap, nil
+}
+
+// Write encodes a snapshot as indented JSON or as a gzipped tarball with a
+// manifest and one JSON file per domain.
+func Write(w io.Writer, snap *Snapshot, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(snap)
+	case FormatTar:
+		return writeTar(w, snap)
+	}
+	return fmt.Errorf("unknown backup format %q (use %s or %s)", format, FormatJSON, FormatTar)
+}
+
+// manifest is the top-level file of a tarball snapshot.
+type manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	Domains   []string  `json:"domains"`
+}
+
+func writeTar(w io.Writer, snap *Snapshot) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	m := manifest{Version: snap.Version, CreatedAt: snap.CreatedAt, Domains: []string{}}
+	for _, d := range snap.Domains {
+		m.Domains = append(m.Domains, d.Domain)
+	}
+	add := func(name string, v any) error {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		hdr := &tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: snap.CreatedAt}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	}
+
+	if err := add("manifest.json", m); err != nil {
+		return err
+	}
+	for _, d := range snap.Domains {
+		if err := add(path.Join("domains", d.Domain+".json"), d); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Read decodes a snapshot written by Write in either format.
+func Read(r io.Reader) (*Snapshot, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(2)
+
+	var snap *Snapshot
+	var err error
+	if bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		snap, err = readTar(br)
+	} else {
+		snap = &Snapshot{}
+		err = json.NewDecoder(br).Decode(snap)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	if snap.Version < 1 || snap.Version > Version {
+		return nil, fmt.Errorf("unsupported snapshot version %d (this build reads up to %d)", snap.Version, Version)
+	}
+	return snap, nil
+}
+
+func readTar(r io.Reader) (*Snapshot, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+
+	var m *manifest
+	domains := map[string]DomainSnapshot{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case hdr.Name == "manifest.json":
+			m = &manifest{}
+			if err := json.NewDecoder(tr).Decode(m); err != nil {
+				return nil, fmt.Errorf("manifest.json: %w", err)
+			}
+		case strings.HasPrefix(hdr.Name, "domains/") && strings.HasSuffix(hdr.Name, ".json"):
+			var d DomainSnapshot
+			if err := json.NewDecoder(tr).Decode(&d); err != nil {
+				return nil, fmt.Errorf("%s: %w", hdr.Name, err)
+			}
+			domains[d.Domain] = d
+		}
+	}
+	if m == nil {
+		return nil, errors.New("manifest.json missing from archive")
+	}
+
+	snap := &Snapshot{Version: m.Version, CreatedAt: m.CreatedAt, Domains: []DomainSnapshot{}}
+	for _, name := range m.Domains {
+		d, ok := domains[name]
+		if !ok {
+			return nil, fmt.Errorf("domains/%s.json missing from archive", name)
+		}
+		snap.Domains = append(snap.Domains, d)
+	}
+	return snap, nil
+}
@@ -0,0 +1,267 @@
+package backup_test
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"github.com/OverseedAI/overpork/internal/backup"
+	"github.com/OverseedAI/overpork/internal/porkbuntest"
+	"github.com/OverseedAI/overpork/internal/zone"
+)
+
+var _ backup.Client = (*api.Client)(nil)
+
+// seed fills example.com with one of everything a snapshot covers.
+func seed(t *testing.T) (*porkbuntest.Server, *api.Client) {
+	t.Helper()
+	srv := porkbuntest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddDomain(porkbuntest.Domain{Name: "example.com", AutoRenew: true, Nameservers: []string{"ns1.example.com", "ns2.example.com"}})
+	srv.AddDomain(porkbuntest.Domain{Name: "example.net"})
+	srv.AddRecord("example.com", porkbuntest.Record{Name: "www", Type: "A", Content: "192.0.2.1", TTL: "600"})
+	srv.AddRecord("example.com", porkbuntest.Record{Type: "MX", Content: "mail.example.com", TTL: "600", Prio: "10"})
+
+	c := api.NewClient(srv.Config())
+	ctx := context.Background()
+	if err := c.DomainAddForwardContext(ctx, "example.com", "https://example.org", api.ForwardOpts{Subdomain: "go", Type: "permanent"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.GlueCreateContext(ctx, "example.com", "ns1", []string{"192.0.2.53"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DNSSECCreateContext(ctx, "example.com", api.DNSSECRecord{KeyTag: "12345", Algorithm: "13", DigestType: "2", Digest: "ABCDEF"}); err != nil {
+		t.Fatal(err)
+	}
+	return srv, c
+}
+
+func collect(t *testing.T, c *api.Client) *backup.Snapshot {
+	t.Helper()
+	ctx := context.Background()
+	domains, err := c.DomainListAllContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap, err := backup.Collect(ctx, c, domains, nil)
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	return snap
+}
+
+func TestCollect(t *testing.T) {
+	_, c := seed(t)
+	snap := collect(t, c)
+
+	if snap.Version != backup.Version || len(snap.Domains) != 2 {
+		t.Fatalf("Collect() = version %d, %d domains", snap.Version, len(snap.Domains))
+	}
+	d := snap.Find("example.com")
+	if d == nil {
+		t.Fatal("Find(example.com) = nil")
+	}
+	if len(d.Records) != 2 || len(d.Forwards) != 1 || len(d.Glue) != 1 || len(d.DNSSEC) != 1 || len(d.Nameservers) != 2 {
+		t.Errorf("snapshot = %+v", d)
+	}
+	if len(d.Errors) != 0 {
+		t.Errorf("Errors = %v, want none", d.Errors)
+	}
+}
+
+func TestCollectPartialFailure(t *testing.T) {
+	srv, c := seed(t)
+	srv.Fail(porkbuntest.Failure{Endpoint: "/domain/getGlue", Message: "Glue lookup failed."})
+	snap := collect(t, c)
+
+	d := snap.Find("example.com")
+	if d.Has(backup.PartGlue) {
+		t.Error("Has(glue) = true after a failed read")
+	}
+	if !d.Has(backup.PartDNS) || len(d.Records) != 2 {
+		t.Errorf("DNS part missing after an unrelated failure: %+v", d)
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	_, c := seed(t)
+	snap := collect(t, c)
+
+	for _, format := range []string{backup.FormatJSON, backup.FormatTar} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := backup.Write(&buf, snap, format); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			got, err := backup.Read(&buf)
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if len(got.Domains) != len(snap.Domains) || !got.CreatedAt.Equal(snap.CreatedAt) {
+				t.Fatalf("Read() = %+v, want %+v", got, snap)
+			}
+			want, have := snap.Find("example.com"), got.Find("example.com")
+			if have == nil || len(have.Records) != len(want.Records) || have.DNSSEC[0] != want.DNSSEC[0] {
+				t.Errorf("example.com = %+v, want %+v", have, want)
+			}
+		})
+	}
+
+	if err := backup.Write(&bytes.Buffer{}, snap, "zip"); err == nil {
+		t.Error("Write(zip) error = nil, want error")
+	}
+	if _, err := backup.Read(bytes.NewBufferString(`{"version": 99}`)); err == nil {
+		t.Error("Read(version 99) error = nil, want error")
+	}
+}
+
+func TestRestore(t *testing.T) {
+	srv, c := seed(t)
+	ctx := context.Background()
+	snap := collect(t, c)
+	ds := snap.Find("example.com")
+
+	// Drift every part away from the snapshot.
+	for _, r := range srv.Records("example.com") {
+		if r.Type == "A" {
+			if err := c.DNSDeleteContext(ctx, "example.com", r.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	srv.AddRecord("example.com", porkbuntest.Record{Name: "extra", Type: "TXT", Content: "drift", TTL: "600"})
+	forwards, _ := c.DomainGetForwardsContext(ctx, "example.com")
+	if err := c.DomainDeleteForwardContext(ctx, "example.com", forwards[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.GlueUpdateContext(ctx, "example.com", "ns1", []string{"198.51.100.1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.GlueCreateContext(ctx, "example.com", "ns9", []string{"198.51.100.9"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DNSSECDeleteContext(ctx, "example.com", "12345"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DomainUpdateNameserversContext(ctx, "example.com", []string{"ns.other.net"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DomainSetAutoRenewContext(ctx, "example.com", false); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := backup.PlanRestore(ctx, c, ds, backup.RestoreOptions{})
+	if err != nil {
+		t.Fatalf("PlanRestore() error = %v", err)
+	}
+	create, update, del := plan.Counts()
+	// DNS: +A -TXT; forward +1; glue ~ns1 -ns9; DS +1; NS ~; auto-renew ~.
+	if create != 3 || update != 3 || del != 2 {
+		t.Fatalf("Counts() = %d/%d/%d, want 3/3/2; plan = %+v", create, update, del, plan)
+	}
+	if got := plan.Changes[0].Part; got != backup.PartSettings {
+		t.Errorf("first change part = %s, want settings", got)
+	}
+
+	if _, err := plan.Apply(ctx, c); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	again, err := backup.PlanRestore(ctx, c, ds, backup.RestoreOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.Empty() {
+		t.Errorf("plan after restore = %+v, want empty", again)
+	}
+}
+
+func TestRestoreOptions(t *testing.T) {
+	srv, c := seed(t)
+	ctx := context.Background()
+	snap := collect(t, c)
+	ds := snap.Find("example.com")
+
+	srv.AddRecord("example.com", porkbuntest.Record{Name: "extra", Type: "TXT", Content: "drift", TTL: "600"})
+	if err := c.GlueCreateContext(ctx, "example.com", "ns9", []string{"198.51.100.9"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		opts  backup.RestoreOptions
+		parts []string
+	}{
+		{"all", backup.RestoreOptions{}, []string{backup.PartGlue}},
+		{"only dns", backup.RestoreOptions{Parts: []string{backup.PartDNS}}, nil},
+		{"no delete", backup.RestoreOptions{NoDelete: true}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := backup.PlanRestore(ctx, c, ds, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var parts []string
+			for _, ch := range plan.Changes {
+				parts = append(parts, ch.Part)
+			}
+			if !slices.Equal(parts, tt.parts) {
+				t.Errorf("parts = %v, want %v", parts, tt.parts)
+			}
+			_, _, del := plan.DNS.Counts()
+			if wantDel := !tt.opts.NoDelete; (del == 1) != wantDel {
+				t.Errorf("DNS deletes = %d", del)
+			}
+		})
+	}
+
+	// A part that failed to back up is never touched.
+	ds.Errors = map[string]string{backup.PartGlue: "boom", backup.PartDNS: "boom"}
+	plan, err := backup.PlanRestore(ctx, c, ds, backup.RestoreOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.Empty() {
+		t.Errorf("plan with failed parts = %+v, want empty", plan)
+	}
+}
+
+func TestRestoreChangedForward(t *testing.T) {
+	_, c := seed(t)
+	ctx := context.Background()
+	snap := collect(t, c)
+	ds := snap.Find("example.com")
+
+	forwards, _ := c.DomainGetForwardsContext(ctx, "example.com")
+	if err := c.DomainDeleteForwardContext(ctx, "example.com", forwards[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DomainAddForwardContext(ctx, "example.com", "https://example.net", api.ForwardOpts{Subdomain: "go", Type: "temporary"}); err != nil {
+		t.Fatal(err)
+	}
+
+	kept, err := backup.PlanRestore(ctx, c, ds, backup.RestoreOptions{Parts: []string{backup.PartForwards}, NoDelete: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kept.Changes) != 0 || len(kept.Skipped) != 1 {
+		t.Errorf("plan with NoDelete = %+v, want the changed forward skipped", kept)
+	}
+
+	plan, err := backup.PlanRestore(ctx, c, ds, backup.RestoreOptions{Parts: []string{backup.PartForwards}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Changes) != 1 || plan.Changes[0].Action != zone.ActionUpdate {
+		t.Fatalf("plan = %+v, want one forward update", plan.Changes)
+	}
+	if _, err := plan.Apply(ctx, c); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	forwards, _ = c.DomainGetForwardsContext(ctx, "example.com")
+	if len(forwards) != 1 || forwards[0].Location != "https://example.org" {
+		t.Errorf("forwards after restore = %+v, want only the snapshot's", forwards)
+	}
+}
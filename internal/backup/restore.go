@@ -0,0 +1,369 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"github.com/OverseedAI/overpork/internal/zone"
+)
+
+// Client is the subset of api.Client needed to restore a snapshot.
+type Client interface {
+	Reader
+	zone.Client
+	DomainGetContext(ctx context.Context, domain string) (*api.Domain, error)
+	DomainSetAutoRenewContext(ctx context.Context, domain string, enabled bool) error
+	DomainUpdateNameserversContext(ctx context.Context, domain string, nameservers []string) error
+	DomainAddForwardContext(ctx context.Context, domain, location string, opts api.ForwardOpts) error
+	DomainDeleteForwardContext(ctx context.Context, domain, forwardID string) error
+	GlueCreateContext(ctx context.Context, domain, subdomain string, ips []string) error
+	GlueUpdateContext(ctx context.Context, domain, subdomain string, ips []string) error
+	GlueDeleteContext(ctx context.Context, domain, subdomain string) error
+	DNSSECCreateContext(ctx context.Context, domain string, record api.DNSSECRecord) error
+	DNSSECDeleteContext(ctx context.Context, domain, keyTag string) error
+}
+
+// RestoreOptions controls what a restore changes.
+type RestoreOptions struct {
+	// Parts limits the restore to these parts (all when empty).
+	Parts []string
+	// NoDelete keeps live records, forwards, glue and DS records that are
+	// not in the snapshot.
+	NoDelete bool
+}
+
+// Change is a planned change to a part other than DNS records.
+type Change struct {
+	Part   string      `json:"part"`
+	Action zone.Action `json:"action"`
+	Target string      `json:"target"`
+	Before string      `json:"before,omitempty"`
+	After  string      `json:"after,omitempty"`
+
+	apply func(ctx context.Context, c Client) error
+}
+
+// Plan is the set of changes that restores one domain. DNS record changes
+// are a zone plan; everything else is in Changes.
+type Plan struct {
+	Domain  string     `json:"domain"`
+	Changes []Change   `json:"changes"`
+	DNS     *zone.Plan `json:"dns,omitempty"`
+	// Skipped describes differences left alone because of NoDelete.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// Empty reports whether the plan changes nothing.
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0 && (p.DNS == nil || p.DNS.Empty())
+}
+
+// Counts returns the number of creates, updates and deletes.
+func (p *Plan) Counts() (create, update, del int) {
+	if p.DNS != nil {
+		create, update, del = p.DNS.Counts()
+	}
+	for _, ch := range p.Changes {
+		switch ch.Action {
+		case zone.ActionCreate:
+			create++
+		case zone.ActionUpdate:
+			update++
+		case zone.ActionDelete:
+			del++
+		}
+	}
+	return create, update, del
+}
+
+// PlanRestore compares a domain's snapshot with its live state.
+func PlanRestore(ctx context.Context, c Client, snap *DomainSnapshot, opts RestoreOptions) (*Plan, error) {
+	p := &Plan{Domain: snap.Domain, Changes: []Change{}}
+	want := func(part string) bool {
+		return snap.Has(part) && (len(opts.Parts) == 0 || slices.Contains(opts.Parts, part))
+	}
+
+	if want(PartSettings) {
+		if err := p.planSettings(ctx, c, snap); err != nil {
+			return nil, err
+		}
+	}
+	if want(PartGlue) {
+		if err := p.planGlue(ctx, c, snap, opts.NoDelete); err != nil {
+			return nil, err
+		}
+	}
+	if want(PartNameservers) {
+		if err := p.planNameservers(ctx, c, snap); err != nil {
+			return nil, err
+		}
+	}
+	if want(PartDNSSEC) {
+		if err := p.planDNSSEC(ctx, c, snap, opts.NoDelete); err != nil {
+			return nil, err
+		}
+	}
+	if want(PartForwards) {
+		if err := p.planForwards(ctx, c, snap, opts.NoDelete); err != nil {
+			return nil, err
+		}
+	}
+	if want(PartDNS) {
+		live, err := c.DNSListContext(ctx, snap.Domain)
+		if err != nil {
+			return nil, err
+		}
+		p.DNS = zone.Diff(snap.Domain, zone.FromAPI(snap.Domain, withoutApexNS(snap.Domain, snap.Records)),
+			withoutApexNS(snap.Domain, live), zone.DiffOptions{NoDelete: opts.NoDelete})
+	}
+	return p, nil
+}
+
+func (p *Plan) add(part string, action zone.Action, target, before, after string, apply func(ctx context.Context, c Client) error) {
+	p.Changes = append(p.Changes, Change{Part: part, Action: action, Target: target, Before: before, After: after, apply: apply})
+}
+
+func (p *Plan) planSettings(ctx context.Context, c Client, snap *DomainSnapshot) error {
+	live, err := c.DomainGetContext(ctx, snap.Domain)
+	if err != nil {
+		return err
+	}
+	want, have := enabled(snap.Settings.AutoRenew), enabled(live.AutoRenew)
+	if want != have {
+		p.add(PartSettings, zone.ActionUpdate, "auto-renew", onOff(have), onOff(want), func(ctx context.Context, c Client) error {
+			return c.DomainSetAutoRenewContext(ctx, snap.Domain, want)
+		})
+	}
+	return nil
+}
+
+func (p *Plan) planNameservers(ctx context.Context, c Client, snap *DomainSnapshot) error {
+	if len(snap.Nameservers) == 0 {
+		return nil
+	}
+	live, err := c.DomainGetNameserversContext(ctx, snap.Domain)
+	if err != nil {
+		return err
+	}
+	if !sameFold(live, snap.Nameservers) {
+		ns := snap.Nameservers
+		p.add(PartNameservers, zone.ActionUpdate, "nameservers", strings.Join(live, ", "), strings.Join(ns, ", "), func(ctx context.Context, c Client) error {
+			return c.DomainUpdateNameserversContext(ctx, snap.Domain, ns)
+		})
+	}
+	return nil
+}
+
+func (p *Plan) planGlue(ctx context.Context, c Client, snap *DomainSnapshot, noDelete bool) error {
+	live, err := c.GlueListContext(ctx, snap.Domain)
+	if err != nil {
+		return err
+	}
+	for _, g := range snap.Glue {
+		i := slices.IndexFunc(live, func(l api.GlueRecord) bool { return strings.EqualFold(l.Subdomain, g.Subdomain) })
+		switch {
+		case i < 0:
+			p.add(PartGlue, zone.ActionCreate, g.Subdomain, "", strings.Join(g.IPs, ", "), func(ctx context.Context, c Client) error {
+				return c.GlueCreateContext(ctx, snap.Domain, g.Subdomain, g.IPs)
+			})
+		case !sameFold(live[i].IPs, g.IPs):
+			p.add(PartGlue, zone.ActionUpdate, g.Subdomain, strings.Join(live[i].IPs, ", "), strings.Join(g.IPs, ", "), func(ctx context.Context, c Client) error {
+				return c.GlueUpdateContext(ctx, snap.Domain, g.Subdomain, g.IPs)
+			})
+		}
+	}
+	if noDelete {
+		return nil
+	}
+	for _, l := range live {
+		if !slices.ContainsFunc(snap.Glue, func(g api.GlueRecord) bool { return strings.EqualFold(l.Subdomain, g.Subdomain) }) {
+			p.add(PartGlue, zone.ActionDelete, l.Subdomain, strings.Join(l.IPs, ", "), "", func(ctx context.Context, c Client) error {
+				return c.GlueDeleteContext(ctx, snap.Domain, l.Subdomain)
+			})
+		}
+	}
+	return nil
+}
+
+func (p *Plan) planDNSSEC(ctx context.Context, c Client, snap *DomainSnapshot, noDelete bool) error {
+	live, err := c.DNSSECListContext(ctx, snap.Domain)
+	if err != nil {
+		return err
+	}
+	for _, ds := range snap.DNSSEC {
+		i := slices.IndexFunc(live, func(l api.DNSSECRecord) bool { return l.KeyTag == ds.KeyTag })
+		if i >= 0 && sameDS(live[i], ds) {
+			continue
+		}
+		action, before := zone.ActionCreate, ""
+		if i >= 0 {
+			// DS records cannot be edited, so replace the old one.
+			action, before = zone.ActionUpdate, describeDS(live[i])
+		}
+		p.add(PartDNSSEC, action, "key tag "+ds.KeyTag, before, describeDS(ds), func(ctx context.Context, c Client) error {
+			if action == zone.ActionUpdate {
+				if err := c.DNSSECDeleteContext(ctx, snap.Domain, ds.KeyTag); err != nil {
+					return err
+				}
+			}
+			return c.DNSSECCreateContext(ctx, snap.Domain, ds)
+		})
+	}
+	if noDelete {
+		return nil
+	}
+	for _, l := range live {
+		if !slices.ContainsFunc(snap.DNSSEC, func(ds api.DNSSECRecord) bool { return ds.KeyTag == l.KeyTag }) {
+			p.add(PartDNSSEC, zone.ActionDelete, "key tag "+l.KeyTag, describeDS(l), "", func(ctx context.Context, c Client) error {
+				return c.DNSSECDeleteContext(ctx, snap.Domain, l.KeyTag)
+			})
+		}
+	}
+	return nil
+}
+
+func (p *Plan) planForwards(ctx context.Context, c Client, snap *DomainSnapshot, noDelete bool) error {
+	live, err := c.DomainGetForwardsContext(ctx, snap.Domain)
+	if err != nil {
+		return err
+	}
+	inSnapshot := func(l api.URLForward) bool {
+		return slices.ContainsFunc(snap.Forwards, func(f api.URLForward) bool { return sameForward(l, f) })
+	}
+
+	// Forwards cannot be edited; a changed forward (one on the same
+	// subdomain) is deleted before it is re-added, so the subdomain never
+	// has two forwards.
+	replaced := map[string]bool{}
+	for _, f := range snap.Forwards {
+		if slices.ContainsFunc(live, func(l api.URLForward) bool { return sameForward(l, f) }) {
+			continue
+		}
+		add := func(ctx context.Context, c Client) error {
+			return c.DomainAddForwardContext(ctx, snap.Domain, f.Location, api.ForwardOpts{
+				Type:        f.Type,
+				IncludePath: enabled(f.IncludePath),
+				Wildcard:    enabled(f.Wildcard),
+				Subdomain:   f.Subdomain,
+			})
+		}
+		i := slices.IndexFunc(live, func(l api.URLForward) bool {
+			return !replaced[l.ID] && strings.EqualFold(l.Subdomain, f.Subdomain) && !inSnapshot(l)
+		})
+		if i < 0 {
+			p.add(PartForwards, zone.ActionCreate, forwardName(f), "", describeForward(f), add)
+			continue
+		}
+		old := live[i]
+		replaced[old.ID] = true
+		if noDelete {
+			p.Skipped = append(p.Skipped, fmt.Sprintf("forward %s: live %s differs from %s, not replaced with --no-delete",
+				forwardName(f), describeForward(old), describeForward(f)))
+			continue
+		}
+		p.add(PartForwards, zone.ActionUpdate, forwardName(f), describeForward(old), describeForward(f), func(ctx context.Context, c Client) error {
+			if err := c.DomainDeleteForwardContext(ctx, snap.Domain, old.ID); err != nil {
+				return err
+			}
+			return add(ctx, c)
+		})
+	}
+	if noDelete {
+		return nil
+	}
+	for _, l := range live {
+		if !replaced[l.ID] && !inSnapshot(l) {
+			p.add(PartForwards, zone.ActionDelete, forwardName(l), describeForward(l), "", func(ctx context.Context, c Client) error {
+				return c.DomainDeleteForwardContext(ctx, snap.Domain, l.ID)
+			})
+		}
+	}
+	return nil
+}
+
+// Apply makes the changes in order, non-DNS parts first, stopping at the
+// first error. It returns the number of changes applied.
+func (p *Plan) Apply(ctx context.Context, c Client) (int, error) {
+	applied := 0
+	for _, ch := range p.Changes {
+		if err := ch.apply(ctx, c); err != nil {
+			return applied, fmt.Errorf("%s %s %s: %w", ch.Action, ch.Part, ch.Target, err)
+		}
+		applied++
+	}
+	if p.DNS != nil {
+		n, err := p.DNS.Apply(ctx, c)
+		applied += n
+		if err != nil {
+			return applied, err
+		}
+	}
+	return applied, nil
+}
+
+// withoutApexNS drops the apex NS records, which Porkbun manages itself
+// and which follow the nameservers part instead.
+func withoutApexNS(domain string, records []api.DNSRecord) []api.DNSRecord {
+	var kept []api.DNSRecord
+	for _, r := range records {
+		if !(zone.RelativeName(r.Name, domain) == "" && strings.EqualFold(r.Type, "NS")) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+func enabled(v string) bool {
+	switch strings.ToLower(v) {
+	case "1", "yes", "true", "on", "enabled":
+		return true
+	}
+	return false
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// sameFold compares two lists as sets, ignoring case.
+func sameFold(a, b []string) bool {
+	norm := func(in []string) []string {
+		out := make([]string, len(in))
+		for i, s := range in {
+			out[i] = strings.ToLower(strings.TrimSuffix(s, "."))
+		}
+		slices.Sort(out)
+		return out
+	}
+	return slices.Equal(norm(a), norm(b))
+}
+
+func sameDS(a, b api.DNSSECRecord) bool {
+	return a.KeyTag == b.KeyTag && a.Algorithm == b.Algorithm && a.DigestType == b.DigestType && strings.EqualFold(a.Digest, b.Digest)
+}
+
+func describeDS(ds api.DNSSECRecord) string {
+	return fmt.Sprintf("alg %s digest %s %s", ds.Algorithm, ds.DigestType, ds.Digest)
+}
+
+func sameForward(a, b api.URLForward) bool {
+	return strings.EqualFold(a.Subdomain, b.Subdomain) && a.Location == b.Location && a.Type == b.Type &&
+		enabled(a.IncludePath) == enabled(b.IncludePath) && enabled(a.Wildcard) == enabled(b.Wildcard)
+}
+
+func forwardName(f api.URLForward) string {
+	if f.Subdomain == "" {
+		return "@"
+	}
+	return f.Subdomain
+}
+
+func describeForward(f api.URLForward) string {
+	return fmt.Sprintf("%s (%s)", f.Location, f.Type)
+}
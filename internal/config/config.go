@@ -2,42 +2,225 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/OverseedAI/overpork/internal/secrets"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
 	APIKey    string `mapstructure:"api_key"`
 	SecretKey string `mapstructure:"secret_key"`
+
+	// Profile is the name of the credential profile in use ("" when the
+	// top-level credentials are used).
+	Profile  string             `mapstructure:"profile"`
+	Profiles map[string]Profile `mapstructure:"profiles"`
+
+	// Retries is the number of extra attempts for idempotent API calls that
+	// fail with a network error, HTTP 429 or a 5xx response.
+	Retries int `mapstructure:"retries"`
+	// RateLimit caps outgoing requests per second (0 disables the limiter).
+	RateLimit float64 `mapstructure:"rate_limit"`
+	// RateBurst is the number of requests allowed back to back before the
+	// limiter kicks in.
+	RateBurst int `mapstructure:"rate_burst"`
+
+	// SecretStore is where the credentials live when they are not in the
+	// file: StoreKeyring or StoreFile.
+	SecretStore string `mapstructure:"secret_store"`
+	// SecretCommand and APIKeyCommand are shell commands that print the
+	// secret key and API key, e.g. "pass show porkbun".
+	SecretCommand string `mapstructure:"secret_command"`
+	APIKeyCommand string `mapstructure:"api_key_command"`
+
+	// BaseURL and PricingURL override the API endpoints, e.g. to point at
+	// a test server. Empty uses Porkbun's.
+	BaseURL    string `mapstructure:"base_url"`
+	PricingURL string `mapstructure:"pricing_url"`
+}
+
+// Profile is a named set of credentials under "profiles:" in config.yaml.
+type Profile struct {
+	APIKey    string `mapstructure:"api_key" yaml:"api_key"`
+	SecretKey string `mapstructure:"secret_key" yaml:"secret_key"`
+
+	SecretStore   string `mapstructure:"secret_store" yaml:"secret_store"`
+	SecretCommand string `mapstructure:"secret_command" yaml:"secret_command"`
+	APIKeyCommand string `mapstructure:"api_key_command" yaml:"api_key_command"`
+}
+
+const (
+	DefaultRetries   = 3
+	DefaultRateBurst = 1
+
+	// DefaultProfile names the top-level credentials in profile commands.
+	DefaultProfile = "default"
+
+	// Secret stores accepted for "secret_store".
+	StoreKeyring = "keyring"
+	StoreFile    = "file"
+)
+
+// Passphrase supplies the passphrase for the encrypted secrets file. It
+// reads PORKBUN_PASSPHRASE by default; the CLI replaces it with a prompt.
+var Passphrase = func() (string, error) {
+	return os.Getenv("PORKBUN_PASSPHRASE"), nil
 }
 
+// Load reads the configuration using the active profile.
 func Load() (*Config, error) {
+	return LoadProfile("")
+}
+
+// LoadProfile reads the configuration with credentials from the named
+// profile. An empty name falls back to PORKBUN_PROFILE, then to the
+// "profile" key in the config file. PORKBUN_API_KEY and PORKBUN_SECRET_KEY
+// override whatever the profile contains; otherwise keys held in a secret
+// store or behind a command are resolved here.
+func LoadProfile(name string) (*Config, error) {
+	v := viper.New()
+
 	// Env vars take precedence
-	viper.SetEnvPrefix("PORKBUN")
-	_ = viper.BindEnv("api_key")
-	_ = viper.BindEnv("secret_key")
+	v.SetEnvPrefix("PORKBUN")
+	_ = v.BindEnv("api_key")
+	_ = v.BindEnv("secret_key")
+	_ = v.BindEnv("profile")
+	_ = v.BindEnv("retries")
+	_ = v.BindEnv("rate_limit")
+	_ = v.BindEnv("rate_burst")
+	_ = v.BindEnv("base_url")
+	_ = v.BindEnv("pricing_url")
+
+	v.SetDefault("retries", DefaultRetries)
+	v.SetDefault("rate_limit", 0)
+	v.SetDefault("rate_burst", DefaultRateBurst)
 
 	// XDG config
-	configDir, err := os.UserConfigDir()
-	if err == nil {
-		viper.AddConfigPath(filepath.Join(configDir, "overpork"))
+	if configDir, err := ConfigDir(); err == nil {
+		v.AddConfigPath(configDir)
 	}
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
+	v.SetConfigName("config")
+	v.SetConfigType("yaml")
 
 	// Read config file (ignore if not found)
-	_ = viper.ReadInConfig()
+	_ = v.ReadInConfig()
 
 	var cfg Config
-	if err := viper.Unmarshal(&cfg); err != nil {
+	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
+	if name != "" {
+		cfg.Profile = name
+	}
+	// Viper lowercases map keys, so profile names are case-insensitive.
+	cfg.Profile = strings.ToLower(cfg.Profile)
+	if cfg.Profile == DefaultProfile {
+		cfg.Profile = ""
+	}
+	if cfg.Profile != "" {
+		p, ok := cfg.Profiles[cfg.Profile]
+		if !ok {
+			return nil, fmt.Errorf("profile %q not found in config file", cfg.Profile)
+		}
+		if _, ok := os.LookupEnv("PORKBUN_API_KEY"); !ok {
+			cfg.APIKey = p.APIKey
+		}
+		if _, ok := os.LookupEnv("PORKBUN_SECRET_KEY"); !ok {
+			cfg.SecretKey = p.SecretKey
+		}
+		cfg.SecretStore = p.SecretStore
+		cfg.SecretCommand = p.SecretCommand
+		cfg.APIKeyCommand = p.APIKeyCommand
+	}
+
+	if err := cfg.resolveSecrets(); err != nil {
+		return nil, err
+	}
 	return &cfg, nil
 }
 
+// resolveSecrets fills in keys that live outside config.yaml. Environment
+// variables still win over every other source.
+func (c *Config) resolveSecrets() error {
+	_, apiKeyEnv := os.LookupEnv("PORKBUN_API_KEY")
+	_, secretKeyEnv := os.LookupEnv("PORKBUN_SECRET_KEY")
+	if apiKeyEnv && secretKeyEnv {
+		return nil
+	}
+
+	if c.SecretStore != "" {
+		store, err := c.Store()
+		if err != nil {
+			return err
+		}
+		creds, err := store.Get(c.Account())
+		if err != nil {
+			return fmt.Errorf("failed to read credentials from %s store: %w", c.SecretStore, err)
+		}
+		if !apiKeyEnv {
+			c.APIKey = creds.APIKey
+		}
+		if !secretKeyEnv {
+			c.SecretKey = creds.SecretKey
+		}
+	}
+
+	if c.APIKeyCommand != "" && !apiKeyEnv {
+		key, err := secrets.RunCommand(c.APIKeyCommand)
+		if err != nil {
+			return fmt.Errorf("failed to run api_key_command: %w", err)
+		}
+		c.APIKey = key
+	}
+	if c.SecretCommand != "" && !secretKeyEnv {
+		key, err := secrets.RunCommand(c.SecretCommand)
+		if err != nil {
+			return fmt.Errorf("failed to run secret_command: %w", err)
+		}
+		c.SecretKey = key
+	}
+	return nil
+}
+
+// Account is the name credentials are stored under in a secret store.
+func (c *Config) Account() string {
+	return AccountName(c.Profile)
+}
+
+// AccountName returns the secret store account for a profile name. Profile
+// names are case-insensitive, so accounts are lowercase.
+func AccountName(profile string) string {
+	if isDefault(profile) {
+		return DefaultProfile
+	}
+	return strings.ToLower(profile)
+}
+
+// Store returns the secret store configured for the active profile.
+func (c *Config) Store() (secrets.Store, error) {
+	return OpenStore(c.SecretStore)
+}
+
+// OpenStore returns the named secret store.
+func OpenStore(name string) (secrets.Store, error) {
+	switch name {
+	case StoreKeyring:
+		return secrets.Keyring(), nil
+	case StoreFile:
+		path, err := SecretsFilePath()
+		if err != nil {
+			return nil, err
+		}
+		return secrets.NewFileStore(path, Passphrase), nil
+	}
+	return nil, fmt.Errorf("unknown secret store %q (use %s or %s)", name, StoreKeyring, StoreFile)
+}
+
 func (c *Config) Validate() error {
 	if c.APIKey == "" {
 		return fmt.Errorf("API key not set (use PORKBUN_API_KEY env var or config file)")
@@ -45,6 +228,21 @@ func (c *Config) Validate() error {
 	if c.SecretKey == "" {
 		return fmt.Errorf("secret key not set (use PORKBUN_SECRET_KEY env var or config file)")
 	}
+	if c.Retries < 0 {
+		return fmt.Errorf("retries must not be negative")
+	}
+	if c.RateLimit < 0 {
+		return fmt.Errorf("rate limit must not be negative")
+	}
+	for _, u := range []struct{ name, value string }{{"base URL", c.BaseURL}, {"pricing URL", c.PricingURL}} {
+		if u.value == "" {
+			continue
+		}
+		parsed, err := url.Parse(u.value)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid %s %q (expected http:// or https:// URL)", u.name, u.value)
+		}
+	}
 	return nil
 }
 
@@ -55,3 +253,38 @@ func ConfigDir() (string, error) {
 	}
 	return filepath.Join(configDir, "overpork"), nil
 }
+
+// FilePath returns the path of config.yaml.
+func FilePath() (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "config.yaml"), nil
+}
+
+// SecretsFilePath returns the path of the encrypted secrets file.
+func SecretsFilePath() (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "secrets.enc"), nil
+}
+
+// AuditLogPath returns the path of the audit log of API changes:
+// PORKBUN_AUDIT_LOG if set, otherwise audit.jsonl in the config directory.
+// It returns "" when PORKBUN_AUDIT_LOG is "off".
+func AuditLogPath() (string, error) {
+	if path := os.Getenv("PORKBUN_AUDIT_LOG"); path != "" {
+		if path == "off" {
+			return "", nil
+		}
+		return path, nil
+	}
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "audit.jsonl"), nil
+}
@@ -27,6 +27,26 @@ func TestLoadFromEnv(t *testing.T) {
 	}
 }
 
+func TestLoadRetryDefaults(t *testing.T) {
+	os.Setenv("PORKBUN_RATE_LIMIT", "2.5")
+	defer os.Unsetenv("PORKBUN_RATE_LIMIT")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Retries != DefaultRetries {
+		t.Errorf("Retries = %d, want %d", cfg.Retries, DefaultRetries)
+	}
+	if cfg.RateLimit != 2.5 {
+		t.Errorf("RateLimit = %v, want 2.5", cfg.RateLimit)
+	}
+	if cfg.RateBurst != DefaultRateBurst {
+		t.Errorf("RateBurst = %d, want %d", cfg.RateBurst, DefaultRateBurst)
+	}
+}
+
 func TestValidate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -53,6 +73,26 @@ func TestValidate(t *testing.T) {
 			cfg:     Config{},
 			wantErr: true,
 		},
+		{
+			name:    "negative retries",
+			cfg:     Config{APIKey: "pk1_xxx", SecretKey: "sk1_xxx", Retries: -1},
+			wantErr: true,
+		},
+		{
+			name:    "custom base url",
+			cfg:     Config{APIKey: "pk1_xxx", SecretKey: "sk1_xxx", BaseURL: "http://127.0.0.1:8080/api/json/v3"},
+			wantErr: false,
+		},
+		{
+			name:    "base url without scheme",
+			cfg:     Config{APIKey: "pk1_xxx", SecretKey: "sk1_xxx", BaseURL: "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "pricing url with bad scheme",
+			cfg:     Config{APIKey: "pk1_xxx", SecretKey: "sk1_xxx", PricingURL: "ftp://porkbun.com"},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
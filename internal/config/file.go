@@ -0,0 +1,232 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// File is config.yaml opened for editing. Changes are made on the YAML
+// node tree so unrelated keys and comments survive a Save.
+type File struct {
+	path string
+	doc  yaml.Node
+}
+
+// OpenFile reads config.yaml, or starts an empty document if it doesn't
+// exist yet.
+func OpenFile() (*File, error) {
+	path, err := FilePath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return openFile(path)
+}
+
+func openFile(path string) (*File, error) {
+	f := &File{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if len(data) > 0 {
+		if err := yaml.Unmarshal(data, &f.doc); err != nil {
+			return nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
+	}
+	if f.doc.Kind == 0 {
+		f.doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	if f.root().Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse config file: top level is not a mapping")
+	}
+	return f, nil
+}
+
+// Path returns the location of the file.
+func (f *File) Path() string {
+	return f.path
+}
+
+// Save writes the file, creating the config directory if needed.
+func (f *File) Save() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&f.doc); err != nil {
+		return fmt.Errorf("failed to encode config file: %w", err)
+	}
+	if err := os.WriteFile(f.path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// Profiles returns the names of the profiles defined in the file, sorted.
+func (f *File) Profiles() []string {
+	var names []string
+	if profiles := lookup(f.root(), "profiles"); profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 0; i < len(profiles.Content); i += 2 {
+			names = append(names, profiles.Content[i].Value)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasProfile reports whether name exists. The default profile exists when
+// top-level credentials, a secret store or a key command are set.
+func (f *File) HasProfile(name string) bool {
+	if isDefault(name) {
+		return f.Get("", "api_key") != "" || f.Get("", "secret_store") != "" || f.Get("", "api_key_command") != ""
+	}
+	return f.profile(name, false) != nil
+}
+
+// ActiveProfile returns the profile selected with "profiles use", or "".
+func (f *File) ActiveProfile() string {
+	if n := lookup(f.root(), "profile"); n != nil {
+		return n.Value
+	}
+	return ""
+}
+
+// UseProfile makes name the active profile. The default profile clears the
+// selection.
+func (f *File) UseProfile(name string) error {
+	if isDefault(name) {
+		remove(f.root(), "profile")
+		return nil
+	}
+	if f.profile(name, false) == nil {
+		return fmt.Errorf("profile %q not found", name)
+	}
+	set(f.root(), "profile", name)
+	return nil
+}
+
+// Get returns a string key of a profile ("" or "default" for top level).
+func (f *File) Get(profile, key string) string {
+	m := f.root()
+	if !isDefault(profile) {
+		if m = f.profile(profile, false); m == nil {
+			return ""
+		}
+	}
+	if n := lookup(m, key); n != nil {
+		return n.Value
+	}
+	return ""
+}
+
+// Set sets a string key of a profile, creating the profile if needed.
+func (f *File) Set(profile, key, value string) {
+	m := f.root()
+	if !isDefault(profile) {
+		m = f.profile(profile, true)
+	}
+	set(m, key, value)
+}
+
+// Unset removes a key from a profile.
+func (f *File) Unset(profile, key string) {
+	m := f.root()
+	if !isDefault(profile) {
+		if m = f.profile(profile, false); m == nil {
+			return
+		}
+	}
+	remove(m, key)
+}
+
+// RemoveProfile deletes a profile, clearing the active selection if it
+// pointed at it.
+func (f *File) RemoveProfile(name string) error {
+	if isDefault(name) {
+		return fmt.Errorf("cannot remove the default profile")
+	}
+	profiles := lookup(f.root(), "profiles")
+	if profiles == nil || lookup(profiles, strings.ToLower(name)) == nil {
+		return fmt.Errorf("profile %q not found", name)
+	}
+	remove(profiles, strings.ToLower(name))
+	if len(profiles.Content) == 0 {
+		remove(f.root(), "profiles")
+	}
+	if strings.EqualFold(f.ActiveProfile(), name) {
+		remove(f.root(), "profile")
+	}
+	return nil
+}
+
+func (f *File) root() *yaml.Node {
+	return f.doc.Content[0]
+}
+
+func (f *File) profile(name string, create bool) *yaml.Node {
+	name = strings.ToLower(name)
+	profiles := lookup(f.root(), "profiles")
+	if profiles == nil || profiles.Kind != yaml.MappingNode {
+		if !create {
+			return nil
+		}
+		profiles = &yaml.Node{Kind: yaml.MappingNode}
+		setNode(f.root(), "profiles", profiles)
+	}
+	p := lookup(profiles, name)
+	if p == nil || p.Kind != yaml.MappingNode {
+		if !create {
+			return nil
+		}
+		p = &yaml.Node{Kind: yaml.MappingNode}
+		setNode(profiles, name, p)
+	}
+	return p
+}
+
+func isDefault(name string) bool {
+	return name == "" || strings.EqualFold(name, DefaultProfile)
+}
+
+func lookup(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func set(m *yaml.Node, key, value string) {
+	setNode(m, key, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+}
+
+func setNode(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+}
+
+func remove(m *yaml.Node, key string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/OverseedAI/overpork/internal/secrets"
+)
+
+func TestFileProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("# my settings\nretries: 5\napi_key: pk1_default\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := openFile(path)
+	if err != nil {
+		t.Fatalf("openFile() error = %v", err)
+	}
+	f.Set("Clients", "api_key", "pk1_clients")
+	f.Set("clients", "secret_key", "sk1_clients")
+	f.Set("staging", "api_key", "pk1_staging")
+	if err := f.UseProfile("clients"); err != nil {
+		t.Fatalf("UseProfile() error = %v", err)
+	}
+	if err := f.UseProfile("missing"); err == nil {
+		t.Error("UseProfile(missing) error = nil, want error")
+	}
+	if err := f.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	f, err = openFile(path)
+	if err != nil {
+		t.Fatalf("openFile() after save error = %v", err)
+	}
+	if got := f.Profiles(); strings.Join(got, ",") != "clients,staging" {
+		t.Errorf("Profiles() = %v, want [clients staging]", got)
+	}
+	if got := f.ActiveProfile(); got != "clients" {
+		t.Errorf("ActiveProfile() = %q, want clients", got)
+	}
+	if got := f.Get("clients", "secret_key"); got != "sk1_clients" {
+		t.Errorf("Get(clients, secret_key) = %q, want sk1_clients", got)
+	}
+	if !f.HasProfile("default") {
+		t.Error("HasProfile(default) = false, want true")
+	}
+
+	if err := f.RemoveProfile("clients"); err != nil {
+		t.Fatalf("RemoveProfile() error = %v", err)
+	}
+	if f.ActiveProfile() != "" {
+		t.Errorf("ActiveProfile() after removal = %q, want empty", f.ActiveProfile())
+	}
+	if err := f.RemoveProfile("default"); err == nil {
+		t.Error("RemoveProfile(default) error = nil, want error")
+	}
+	if err := f.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "# my settings") || !strings.Contains(string(data), "retries: 5") {
+		t.Errorf("Save() lost unrelated content:\n%s", data)
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	os.Unsetenv("PORKBUN_API_KEY")
+	os.Unsetenv("PORKBUN_SECRET_KEY")
+	os.Unsetenv("PORKBUN_PROFILE")
+
+	path, err := FilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	content := `api_key: pk1_default
+secret_key: sk1_default
+profile: personal
+profiles:
+  personal:
+    api_key: pk1_personal
+    secret_key: sk1_personal
+  staging:
+    api_key: pk1_staging
+    secret_key: sk1_staging
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		profile string
+		env     string
+		want    string
+		wantErr bool
+	}{
+		{name: "active from file", want: "pk1_personal"},
+		{name: "flag", profile: "staging", want: "pk1_staging"},
+		{name: "env", env: "staging", want: "pk1_staging"},
+		{name: "flag beats env", profile: "personal", env: "staging", want: "pk1_personal"},
+		{name: "default", profile: "default", want: "pk1_default"},
+		{name: "missing", profile: "nope", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv("PORKBUN_PROFILE", tt.env)
+			}
+			cfg, err := LoadProfile(tt.profile)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("LoadProfile() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadProfile() error = %v", err)
+			}
+			if cfg.APIKey != tt.want {
+				t.Errorf("APIKey = %q, want %q", cfg.APIKey, tt.want)
+			}
+		})
+	}
+
+	t.Run("env key overrides profile", func(t *testing.T) {
+		t.Setenv("PORKBUN_API_KEY", "pk1_env")
+		cfg, err := LoadProfile("staging")
+		if err != nil {
+			t.Fatalf("LoadProfile() error = %v", err)
+		}
+		if cfg.APIKey != "pk1_env" || cfg.SecretKey != "sk1_staging" {
+			t.Errorf("keys = %q/%q, want pk1_env/sk1_staging", cfg.APIKey, cfg.SecretKey)
+		}
+	})
+}
+
+func TestLoadProfileSecrets(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("PORKBUN_PASSPHRASE", "hunter2")
+	os.Unsetenv("PORKBUN_API_KEY")
+	os.Unsetenv("PORKBUN_SECRET_KEY")
+	os.Unsetenv("PORKBUN_PROFILE")
+
+	path, err := FilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	content := `secret_store: file
+profiles:
+  pass:
+    api_key: pk1_pass
+    secret_command: echo sk1_from_command
+  broken:
+    api_key: pk1_broken
+    secret_command: exit 1
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := OpenStore(StoreFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(DefaultProfile, secrets.Credentials{APIKey: "pk1_stored", SecretKey: "sk1_stored"}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	tests := []struct {
+		profile    string
+		wantAPI    string
+		wantSecret string
+		wantErr    bool
+	}{
+		{profile: "", wantAPI: "pk1_stored", wantSecret: "sk1_stored"},
+		{profile: "pass", wantAPI: "pk1_pass", wantSecret: "sk1_from_command"},
+		{profile: "broken", wantErr: true},
+	}
+	for _, tt := range tests {
+		cfg, err := LoadProfile(tt.profile)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("LoadProfile(%q) error = nil, want error", tt.profile)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("LoadProfile(%q) error = %v", tt.profile, err)
+		}
+		if cfg.APIKey != tt.wantAPI || cfg.SecretKey != tt.wantSecret {
+			t.Errorf("LoadProfile(%q) keys = %q/%q, want %q/%q", tt.profile, cfg.APIKey, cfg.SecretKey, tt.wantAPI, tt.wantSecret)
+		}
+	}
+
+	t.Setenv("PORKBUN_PASSPHRASE", "wrong")
+	if _, err := LoadProfile(""); err == nil {
+		t.Error("LoadProfile() with wrong passphrase error = nil, want error")
+	}
+	t.Setenv("PORKBUN_API_KEY", "pk1_env")
+	t.Setenv("PORKBUN_SECRET_KEY", "sk1_env")
+	if cfg, err := LoadProfile(""); err != nil || cfg.SecretKey != "sk1_env" {
+		t.Errorf("LoadProfile() with env keys = %v, want env keys without touching the store", err)
+	}
+}
+
+func TestMixedCaseProfileSecrets(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("PORKBUN_PASSPHRASE", "hunter2")
+	os.Unsetenv("PORKBUN_API_KEY")
+	os.Unsetenv("PORKBUN_SECRET_KEY")
+	os.Unsetenv("PORKBUN_PROFILE")
+
+	// What "config init --profile Work --store file" writes.
+	f, err := OpenFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Set("Work", "secret_store", StoreFile)
+	if err := f.Save(); err != nil {
+		t.Fatal(err)
+	}
+	store, err := OpenStore(StoreFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(AccountName("Work"), secrets.Credentials{APIKey: "pk1_work", SecretKey: "sk1_work"}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	for _, name := range []string{"Work", "work", "WORK"} {
+		cfg, err := LoadProfile(name)
+		if err != nil {
+			t.Fatalf("LoadProfile(%q) error = %v", name, err)
+		}
+		if cfg.APIKey != "pk1_work" || cfg.SecretKey != "sk1_work" {
+			t.Errorf("LoadProfile(%q) keys = %q/%q, want pk1_work/sk1_work", name, cfg.APIKey, cfg.SecretKey)
+		}
+	}
+	if got := AccountName("Default"); got != DefaultProfile {
+		t.Errorf("AccountName(Default) = %q, want %q", got, DefaultProfile)
+	}
+}
@@ -0,0 +1,231 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"github.com/OverseedAI/overpork/internal/zone"
+)
+
+func TestFromHTTP(t *testing.T) {
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("203.0.113.7\n"))
+	}))
+	defer good.Close()
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html>nope</html>"))
+	}))
+	defer bad.Close()
+	v6 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("2001:db8::1"))
+	}))
+	defer v6.Close()
+
+	addr, err := FromHTTP(context.Background(), []string{bad.URL, v6.URL, good.URL}, false)
+	if err != nil {
+		t.Fatalf("FromHTTP() error = %v", err)
+	}
+	if addr != netip.MustParseAddr("203.0.113.7") {
+		t.Errorf("FromHTTP() = %v, want 203.0.113.7", addr)
+	}
+
+	_, err = FromHTTP(context.Background(), []string{bad.URL, v6.URL}, false)
+	if err == nil || !strings.Contains(err.Error(), "unexpected response") || !strings.Contains(err.Error(), "got AAAA address") {
+		t.Errorf("FromHTTP() error = %v, want errors from both endpoints", err)
+	}
+}
+
+func TestPickAddr(t *testing.T) {
+	addrs := []net.Addr{
+		&net.IPNet{IP: net.ParseIP("127.0.0.1"), Mask: net.CIDRMask(8, 32)},
+		&net.IPNet{IP: net.ParseIP("192.168.1.10"), Mask: net.CIDRMask(24, 32)},
+		&net.IPNet{IP: net.ParseIP("fe80::1"), Mask: net.CIDRMask(64, 128)},
+		&net.IPNet{IP: net.ParseIP("198.51.100.4"), Mask: net.CIDRMask(24, 32)},
+		&net.IPNet{IP: net.ParseIP("2001:db8::5"), Mask: net.CIDRMask(64, 128)},
+	}
+	tests := []struct {
+		addrs   []net.Addr
+		ipv6    bool
+		want    string
+		wantErr bool
+	}{
+		{addrs, false, "198.51.100.4", false},
+		{addrs, true, "2001:db8::5", false},
+		{addrs[:3], false, "192.168.1.10", false},
+		{addrs[:3], true, "", true},
+	}
+	for _, tt := range tests {
+		got, err := pickAddr(tt.addrs, tt.ipv6, "eth0")
+		if (err != nil) != tt.wantErr || (err == nil && got.String() != tt.want) {
+			t.Errorf("pickAddr(ipv6=%v) = %v, %v; want %q", tt.ipv6, got, err, tt.want)
+		}
+	}
+}
+
+func TestState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "ddns.json")
+	s, err := LoadState(path)
+	if err != nil {
+		t.Fatalf("LoadState() on missing file error = %v", err)
+	}
+	s.Records["home.example.com/A"] = Entry{Address: "203.0.113.7"}
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	s, err = LoadState(path)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if s.Records["home.example.com/A"].Address != "203.0.113.7" {
+		t.Errorf("Records = %+v, want saved entry", s.Records)
+	}
+}
+
+type fakeClient struct {
+	records []api.DNSRecord
+	calls   []string
+	fail    bool
+}
+
+func (f *fakeClient) DNSListByTypeAndSubdomainContext(ctx context.Context, domain, recordType, subdomain string) ([]api.DNSRecord, error) {
+	f.calls = append(f.calls, "list "+recordType+" "+subdomain)
+	if f.fail {
+		return nil, errors.New("boom")
+	}
+	var out []api.DNSRecord
+	for _, r := range f.records {
+		if r.Type == recordType {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeClient) DNSUpdateByTypeAndSubdomainContext(ctx context.Context, domain, recordType, subdomain, content string, opts api.DNSCreateOpts) error {
+	f.calls = append(f.calls, "update "+recordType+" "+content)
+	return nil
+}
+
+func (f *fakeClient) DNSCreateContext(ctx context.Context, domain, recordType, content string, opts api.DNSCreateOpts) (int64, error) {
+	f.calls = append(f.calls, "create "+recordType+" "+content)
+	return 1, nil
+}
+
+func TestSync(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	v4 := netip.MustParseAddr("203.0.113.7")
+	v6 := netip.MustParseAddr("2001:db8::1")
+
+	tests := []struct {
+		name      string
+		records   []api.DNSRecord
+		state     map[string]Entry
+		force     bool
+		addr      netip.Addr
+		want      string
+		wantCalls []string
+	}{
+		{
+			name:      "create",
+			addr:      v4,
+			want:      ActionCreated,
+			wantCalls: []string{"list A home", "create A 203.0.113.7"},
+		},
+		{
+			name:      "update",
+			records:   []api.DNSRecord{{Type: "AAAA", Content: "2001:db8::99"}},
+			addr:      v6,
+			want:      ActionUpdated,
+			wantCalls: []string{"list AAAA home", "update AAAA 2001:db8::1"},
+		},
+		{
+			name:      "unchanged",
+			records:   []api.DNSRecord{{Type: "A", Content: "203.0.113.7"}},
+			addr:      v4,
+			want:      ActionUnchanged,
+			wantCalls: []string{"list A home"},
+		},
+		{
+			name:  "cached",
+			state: map[string]Entry{"home.example.com/A": {Address: "203.0.113.7", CheckedAt: now.Add(-time.Hour)}},
+			addr:  v4,
+			want:  ActionCached,
+		},
+		{
+			name:      "stale cache",
+			records:   []api.DNSRecord{{Type: "A", Content: "203.0.113.7"}},
+			state:     map[string]Entry{"home.example.com/A": {Address: "203.0.113.7", CheckedAt: now.Add(-48 * time.Hour)}},
+			addr:      v4,
+			want:      ActionUnchanged,
+			wantCalls: []string{"list A home"},
+		},
+		{
+			name:      "forced",
+			records:   []api.DNSRecord{{Type: "A", Content: "198.51.100.1"}},
+			state:     map[string]Entry{"home.example.com/A": {Address: "203.0.113.7", CheckedAt: now}},
+			force:     true,
+			addr:      v4,
+			want:      ActionUpdated,
+			wantCalls: []string{"list A home", "update A 203.0.113.7"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc := &fakeClient{records: tt.records}
+			state := &State{Records: map[string]Entry{}}
+			for k, v := range tt.state {
+				state.Records[k] = v
+			}
+			u := &Updater{Client: fc, Domain: "example.com", Name: "home", State: state, MaxAge: 24 * time.Hour, Force: tt.force, now: func() time.Time { return now }}
+
+			res, err := u.Sync(context.Background(), tt.addr)
+			if err != nil {
+				t.Fatalf("Sync() error = %v", err)
+			}
+			if res.Action != tt.want {
+				t.Errorf("Action = %q, want %q", res.Action, tt.want)
+			}
+			if strings.Join(fc.calls, ",") != strings.Join(tt.wantCalls, ",") {
+				t.Errorf("calls = %v, want %v", fc.calls, tt.wantCalls)
+			}
+			key := "home.example.com/" + RecordType(tt.addr.Is6())
+			if state.Records[key].Address != tt.addr.String() {
+				t.Errorf("state = %+v, want %s recorded", state.Records, tt.addr)
+			}
+		})
+	}
+
+	fc := &fakeClient{fail: true}
+	u := &Updater{Client: fc, Domain: "example.com", State: &State{Records: map[string]Entry{}}}
+	if _, err := u.Sync(context.Background(), v4); err == nil {
+		t.Error("Sync() with failing client error = nil, want error")
+	}
+	if len(u.State.Records) != 0 {
+		t.Errorf("state after failure = %+v, want empty", u.State.Records)
+	}
+	var checked []zone.Record
+	reject := func(ctx context.Context, rec zone.Record) error {
+		checked = append(checked, rec)
+		return errors.New("invalid")
+	}
+	fc = &fakeClient{records: []api.DNSRecord{{Type: "A", Content: v4.String()}}}
+	u = &Updater{Client: fc, Domain: "example.com", Name: "home", State: &State{Records: map[string]Entry{}}, Check: reject}
+	if _, err := u.Sync(context.Background(), v4); err != nil || len(checked) != 0 {
+		t.Errorf("Sync() of unchanged record = %v, checked %v; want no check", err, checked)
+	}
+	if _, err := u.Sync(context.Background(), netip.MustParseAddr("203.0.113.8")); err == nil {
+		t.Error("Sync() with rejecting check error = nil, want error")
+	}
+	if len(checked) != 1 || checked[0].Name != "home" || checked[0].Content != "203.0.113.8" || len(fc.calls) != 2 {
+		t.Errorf("checked = %+v, calls = %v; want one check and no write", checked, fc.calls)
+	}
+}
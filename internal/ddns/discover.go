@@ -0,0 +1,132 @@
+// Package ddns keeps A/AAAA records pointed at the machine's current
+// address.
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// Default echo endpoints that reply with the caller's address in plain text.
+var (
+	DefaultIPv4URLs = []string{"https://api.ipify.org", "https://ipv4.icanhazip.com"}
+	DefaultIPv6URLs = []string{"https://api6.ipify.org", "https://ipv6.icanhazip.com"}
+)
+
+// RecordType returns the record type for addresses of the given family.
+func RecordType(ipv6 bool) string {
+	if ipv6 {
+		return "AAAA"
+	}
+	return "A"
+}
+
+// FromHTTP asks each echo endpoint in turn for the public address and
+// returns the first valid answer of the requested family. Connections are
+// forced over IPv4 or IPv6 so dual-stack hosts report the right address.
+func FromHTTP(ctx context.Context, urls []string, ipv6 bool) (netip.Addr, error) {
+	network := "tcp4"
+	if ipv6 {
+		network = "tcp6"
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	client := &http.Client{
+		Timeout: 15 * time.Second,
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, addr)
+			},
+		},
+	}
+
+	var errs []error
+	for _, url := range urls {
+		addr, err := fetchAddr(ctx, client, url)
+		if err == nil && addr.Is6() != ipv6 {
+			err = fmt.Errorf("got %s address %s", RecordType(addr.Is6()), addr)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return netip.Addr{}, ctx.Err()
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", url, err))
+			continue
+		}
+		return addr, nil
+	}
+	if len(errs) == 0 {
+		return netip.Addr{}, fmt.Errorf("no IP echo endpoints configured")
+	}
+	return netip.Addr{}, fmt.Errorf("failed to discover public %s address: %w", RecordType(ipv6), errors.Join(errs...))
+}
+
+func fetchAddr(ctx context.Context, client *http.Client, url string) (netip.Addr, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return netip.Addr{}, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	addr, err := netip.ParseAddr(strings.TrimSpace(string(body)))
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("unexpected response %q", strings.TrimSpace(string(body)))
+	}
+	return addr.Unmap(), nil
+}
+
+// FromInterface returns an address of the given family assigned to the
+// named network interface, preferring public over private addresses.
+func FromInterface(name string, ipv6 bool) (netip.Addr, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("failed to list addresses of %s: %w", name, err)
+	}
+	return pickAddr(addrs, ipv6, name)
+}
+
+func pickAddr(addrs []net.Addr, ipv6 bool, name string) (netip.Addr, error) {
+	var private netip.Addr
+	for _, a := range addrs {
+		prefix, err := netip.ParsePrefix(a.String())
+		if err != nil {
+			continue
+		}
+		addr := prefix.Addr().Unmap()
+		if addr.Is6() != ipv6 || !addr.IsGlobalUnicast() {
+			continue
+		}
+		if !addr.IsPrivate() {
+			return addr, nil
+		}
+		if !private.IsValid() {
+			private = addr
+		}
+	}
+	if private.IsValid() {
+		return private, nil
+	}
+	return netip.Addr{}, fmt.Errorf("no %s address on interface %s", RecordType(ipv6), name)
+}
@@ -0,0 +1,72 @@
+package ddns
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State remembers the last address published for each record so repeated
+// runs with an unchanged address make no API calls.
+type State struct {
+	path    string
+	Records map[string]Entry `json:"records"`
+}
+
+// Entry is the last known content of one record.
+type Entry struct {
+	Address   string    `json:"address"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// DefaultStatePath returns the state file location in the user cache
+// directory.
+func DefaultStatePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "overpork", "ddns.json"), nil
+}
+
+// LoadState reads the state file at path. A missing file yields empty state.
+func LoadState(path string) (*State, error) {
+	s := &State{path: path, Records: map[string]Entry{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	if s.Records == nil {
+		s.Records = map[string]Entry{}
+	}
+	return s, nil
+}
+
+// Save writes the state back to its file.
+func (s *State) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
+
+func stateKey(fqdn, recordType string) string {
+	return fqdn + "/" + recordType
+}
@@ -0,0 +1,115 @@
+package ddns
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"github.com/OverseedAI/overpork/internal/zone"
+)
+
+// Client is the subset of api.Client the updater needs.
+type Client interface {
+	DNSListByTypeAndSubdomainContext(ctx context.Context, domain, recordType, subdomain string) ([]api.DNSRecord, error)
+	DNSUpdateByTypeAndSubdomainContext(ctx context.Context, domain, recordType, subdomain, content string, opts api.DNSCreateOpts) error
+	DNSCreateContext(ctx context.Context, domain, recordType, content string, opts api.DNSCreateOpts) (int64, error)
+}
+
+// Actions reported in a Result.
+const (
+	ActionCached    = "cached"    // state file matched, no API calls made
+	ActionUnchanged = "unchanged" // live record already matched
+	ActionUpdated   = "updated"
+	ActionCreated   = "created"
+)
+
+// Result describes what Sync did for one record.
+type Result struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Address  string `json:"address"`
+	Previous string `json:"previous,omitempty"`
+	Action   string `json:"action"`
+}
+
+// Updater publishes addresses for one name. Name is the subdomain ("" for
+// the apex).
+type Updater struct {
+	Client Client
+	Domain string
+	Name   string
+	TTL    string
+	State  *State
+	// MaxAge is how long a state entry is trusted before the live record
+	// is checked again (0 always checks).
+	MaxAge time.Duration
+	// Force ignores the state file.
+	Force bool
+	// Check, if set, vets a record before it is created or updated.
+	Check func(ctx context.Context, rec zone.Record) error
+
+	now func() time.Time
+}
+
+// FQDN returns the fully qualified record name.
+func (u *Updater) FQDN() string {
+	if u.Name == "" {
+		return u.Domain
+	}
+	return u.Name + "." + u.Domain
+}
+
+// Sync makes the record of addr's family point at addr, calling the API only
+// when the address changed.
+func (u *Updater) Sync(ctx context.Context, addr netip.Addr) (Result, error) {
+	now := time.Now
+	if u.now != nil {
+		now = u.now
+	}
+	recordType := RecordType(addr.Is6())
+	content := addr.String()
+	res := Result{Name: u.FQDN(), Type: recordType, Address: content}
+	key := stateKey(u.FQDN(), recordType)
+
+	if e, ok := u.State.Records[key]; ok && !u.Force && e.Address == content && now().Sub(e.CheckedAt) < u.MaxAge {
+		res.Action = ActionCached
+		return res, nil
+	}
+
+	records, err := u.Client.DNSListByTypeAndSubdomainContext(ctx, u.Domain, recordType, u.Name)
+	if err != nil {
+		return res, err
+	}
+
+	unchanged := len(records) == 1 && records[0].Content == content && (u.TTL == "" || records[0].TTL == u.TTL)
+	if !unchanged && u.Check != nil {
+		if err := u.Check(ctx, zone.Record{Name: u.Name, Type: recordType, Content: content, TTL: u.TTL}); err != nil {
+			return res, err
+		}
+	}
+
+	opts := api.DNSCreateOpts{Name: u.Name, TTL: u.TTL}
+	switch {
+	case len(records) == 0:
+		if _, err := u.Client.DNSCreateContext(ctx, u.Domain, recordType, content, opts); err != nil {
+			return res, err
+		}
+		res.Action = ActionCreated
+	case unchanged:
+		res.Action = ActionUnchanged
+	default:
+		res.Previous = records[0].Content
+		if len(records) > 1 {
+			res.Previous = fmt.Sprintf("%s (+%d more)", res.Previous, len(records)-1)
+		}
+		if err := u.Client.DNSUpdateByTypeAndSubdomainContext(ctx, u.Domain, recordType, u.Name, content, opts); err != nil {
+			return res, err
+		}
+		res.Action = ActionUpdated
+	}
+
+	u.State.Records[key] = Entry{Address: content, CheckedAt: now()}
+	return res, nil
+}
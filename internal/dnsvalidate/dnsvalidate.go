@@ -0,0 +1,397 @@
+// Package dnsvalidate checks DNS records locally before they are sent to
+// the API, so that typos fail with a clear message instead of an opaque
+// API error or, worse, a record that is accepted but broken.
+package dnsvalidate
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/OverseedAI/overpork/internal/zone"
+)
+
+// Porkbun's TTL limits: it doesn't go below 600 seconds, and TTLs are
+// 31-bit values (RFC 2181, section 8).
+const (
+	MinTTL = 600
+	MaxTTL = 2147483647
+)
+
+// Types are the record types Porkbun supports.
+var Types = []string{"A", "AAAA", "CNAME", "ALIAS", "MX", "TXT", "NS", "SRV", "TLSA", "CAA", "HTTPS", "SVCB", "SSHFP"}
+
+var parsers = map[string]func(string) error{
+	"A":     parseA,
+	"AAAA":  parseAAAA,
+	"CNAME": parseTarget,
+	"ALIAS": parseTarget,
+	"NS":    parseTarget,
+	"MX":    parseMX,
+	"TXT":   parseTXT,
+	"SRV":   parseSRV,
+	"TLSA":  parseTLSA,
+	"CAA":   parseCAA,
+	"HTTPS": parseSVCB,
+	"SVCB":  parseSVCB,
+	"SSHFP": parseSSHFP,
+}
+
+// Content checks that content is valid for a record type.
+func Content(recordType, content string) error {
+	recordType = strings.ToUpper(recordType)
+	parse, ok := parsers[recordType]
+	if !ok {
+		return fmt.Errorf("unsupported record type %q (supported: %s)", recordType, strings.Join(Types, ", "))
+	}
+	if strings.TrimSpace(content) == "" {
+		return fmt.Errorf("%s content is empty", recordType)
+	}
+	if err := parse(content); err != nil {
+		return fmt.Errorf("invalid %s content %q: %w", recordType, content, err)
+	}
+	return nil
+}
+
+// Record checks a record's content, TTL and priority. Name is relative to
+// the domain, as in zone files.
+func Record(r zone.Record) error {
+	recordType := strings.ToUpper(r.Type)
+	if err := Content(recordType, r.Content); err != nil {
+		return err
+	}
+	if r.Name != "" {
+		if err := checkName(r.Name, true); err != nil {
+			return fmt.Errorf("invalid name %q: %w", r.Name, err)
+		}
+	}
+	if r.TTL != "" {
+		ttl, err := strconv.Atoi(r.TTL)
+		if err != nil || ttl < MinTTL || ttl > MaxTTL {
+			return fmt.Errorf("invalid TTL %q: must be a number from %d to %d", r.TTL, MinTTL, MaxTTL)
+		}
+	}
+	switch recordType {
+	case "MX", "SRV":
+		if r.Prio == "" {
+			return fmt.Errorf("%s records need a priority", recordType)
+		}
+		fallthrough
+	case "HTTPS", "SVCB":
+		if r.Prio != "" {
+			if _, err := uint16Field(r.Prio); err != nil {
+				return fmt.Errorf("invalid priority %q: %w", r.Prio, err)
+			}
+		}
+	}
+	if recordType == "CNAME" && r.Name == "" {
+		return fmt.Errorf("a CNAME cannot be at the apex; use ALIAS instead")
+	}
+	return nil
+}
+
+// Check validates the records about to be written and checks that they fit
+// with existing, the records that stay as they are: a CNAME must be the
+// only record at its name. Problems among existing records alone are not
+// reported. All problems are returned, joined.
+func Check(existing, changed []zone.Record) error {
+	var errs []error
+	for _, r := range changed {
+		if err := Record(r); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", strings.ToUpper(r.Type), displayName(r.Name), err))
+		}
+	}
+
+	touched := map[string]bool{}
+	for _, r := range changed {
+		touched[strings.ToLower(r.Name)] = true
+	}
+	byName := map[string][]zone.Record{}
+	var names []string
+	for _, r := range append(append([]zone.Record{}, existing...), changed...) {
+		name := strings.ToLower(r.Name)
+		if !touched[name] {
+			continue
+		}
+		if _, ok := byName[name]; !ok {
+			names = append(names, name)
+		}
+		byName[name] = append(byName[name], r)
+	}
+	for _, name := range names {
+		records := byName[name]
+		cnames := 0
+		for _, r := range records {
+			if strings.EqualFold(r.Type, "CNAME") {
+				cnames++
+			}
+		}
+		if cnames > 0 && len(records) > 1 {
+			errs = append(errs, fmt.Errorf("CNAME %s: a CNAME cannot coexist with other records at the same name (%d records)", displayName(name), len(records)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func parseA(s string) error {
+	addr, err := netip.ParseAddr(s)
+	if err != nil || !addr.Is4() {
+		return fmt.Errorf("not an IPv4 address")
+	}
+	return nil
+}
+
+func parseAAAA(s string) error {
+	addr, err := netip.ParseAddr(s)
+	if err != nil || !addr.Is6() || addr.Is4In6() || addr.Zone() != "" {
+		return fmt.Errorf("not an IPv6 address")
+	}
+	return nil
+}
+
+func parseTarget(s string) error {
+	if _, err := netip.ParseAddr(s); err == nil {
+		return fmt.Errorf("must be a hostname, not an IP address")
+	}
+	return checkName(s, false)
+}
+
+func parseMX(s string) error {
+	if s == "." {
+		return nil // Null MX (RFC 7505)
+	}
+	if fields := strings.Fields(s); len(fields) > 1 {
+		return fmt.Errorf("must be only the mail server's hostname; set the priority separately")
+	}
+	return parseTarget(s)
+}
+
+func parseTXT(s string) error {
+	if !strings.HasPrefix(strings.TrimSpace(s), `"`) {
+		return nil
+	}
+	inQuote, escaped := false, false
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && inQuote:
+			escaped = true
+		case r == '"':
+			inQuote = !inQuote
+		}
+	}
+	if inQuote {
+		return fmt.Errorf("unbalanced quotes")
+	}
+	return nil
+}
+
+func parseSRV(s string) error {
+	fields := strings.Fields(s)
+	if len(fields) != 3 {
+		return fmt.Errorf("must be \"weight port target\"; set the priority separately")
+	}
+	if _, err := uint16Field(fields[0]); err != nil {
+		return fmt.Errorf("weight: %w", err)
+	}
+	if _, err := uint16Field(fields[1]); err != nil {
+		return fmt.Errorf("port: %w", err)
+	}
+	if fields[2] == "." {
+		return nil
+	}
+	return parseTarget(fields[2])
+}
+
+func parseTLSA(s string) error {
+	fields := strings.Fields(s)
+	if len(fields) < 4 {
+		return fmt.Errorf("must be \"usage selector matching-type data\"")
+	}
+	limits := []struct {
+		name string
+		max  int
+	}{{"usage", 3}, {"selector", 1}, {"matching type", 2}}
+	values := make([]int, 3)
+	for i, l := range limits {
+		n, err := strconv.Atoi(fields[i])
+		if err != nil || n < 0 || n > l.max {
+			return fmt.Errorf("%s must be a number from 0 to %d", l.name, l.max)
+		}
+		values[i] = n
+	}
+	return checkHex("data", strings.Join(fields[3:], ""), map[int]int{1: 64, 2: 128}[values[2]], "matching type", values[2])
+}
+
+func parseSSHFP(s string) error {
+	fields := strings.Fields(s)
+	if len(fields) < 3 {
+		return fmt.Errorf("must be \"algorithm fingerprint-type fingerprint\"")
+	}
+	if n, err := strconv.Atoi(fields[0]); err != nil || n < 0 || n > 255 {
+		return fmt.Errorf("algorithm must be a number from 0 to 255")
+	}
+	fpType, err := strconv.Atoi(fields[1])
+	if err != nil || fpType < 0 || fpType > 255 {
+		return fmt.Errorf("fingerprint type must be a number from 0 to 255")
+	}
+	return checkHex("fingerprint", strings.Join(fields[2:], ""), map[int]int{1: 40, 2: 64}[fpType], "fingerprint type", fpType)
+}
+
+// checkHex checks the hex digest of a TLSA or SSHFP record. want is the
+// number of digits the digest type calls for, or 0 if unknown.
+func checkHex(field, data string, want int, kind string, value int) error {
+	for _, r := range data {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return fmt.Errorf("%s must be hexadecimal", field)
+		}
+	}
+	switch {
+	case want > 0 && len(data) != want:
+		return fmt.Errorf("%s must be %d hex digits for %s %d", field, want, kind, value)
+	case len(data)%2 != 0:
+		return fmt.Errorf("%s has an odd number of hex digits", field)
+	}
+	return nil
+}
+
+func parseCAA(s string) error {
+	fields := strings.SplitN(strings.TrimSpace(s), " ", 3)
+	if len(fields) != 3 {
+		return fmt.Errorf("must be \"flags tag value\", e.g. 0 issue \"letsencrypt.org\"")
+	}
+	if n, err := strconv.Atoi(fields[0]); err != nil || n < 0 || n > 255 {
+		return fmt.Errorf("flags must be a number from 0 to 255")
+	}
+	tag := fields[1]
+	if tag == "" {
+		return fmt.Errorf("missing tag")
+	}
+	for _, r := range tag {
+		if !isAlnum(r) {
+			return fmt.Errorf("tag %q must be letters and digits", tag)
+		}
+	}
+	value := strings.TrimSpace(fields[2])
+	if strings.HasPrefix(value, `"`) && (len(value) < 2 || !strings.HasSuffix(value, `"`)) {
+		return fmt.Errorf("unbalanced quotes in value")
+	}
+	return nil
+}
+
+// parseSVCB accepts "[priority] target [key=value...]". Porkbun usually
+// takes the priority as a separate field.
+func parseSVCB(s string) error {
+	fields := strings.Fields(s)
+	if len(fields) > 0 {
+		if _, err := strconv.Atoi(fields[0]); err == nil {
+			if _, err := uint16Field(fields[0]); err != nil {
+				return fmt.Errorf("priority: %w", err)
+			}
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("missing target (use . for the owner name)")
+	}
+	if fields[0] != "." {
+		if err := parseTarget(fields[0]); err != nil {
+			return fmt.Errorf("target: %w", err)
+		}
+	}
+
+	for _, param := range fields[1:] {
+		key, value, hasValue := strings.Cut(param, "=")
+		value = strings.Trim(value, `"`)
+		switch key = strings.ToLower(key); {
+		case key == "no-default-alpn":
+			if hasValue {
+				return fmt.Errorf("no-default-alpn takes no value")
+			}
+			continue
+		case !hasValue || value == "":
+			return fmt.Errorf("parameter %q needs a value", key)
+		}
+		var err error
+		switch key {
+		case "mandatory", "alpn", "ech":
+		case "port":
+			_, err = uint16Field(value)
+		case "ipv4hint":
+			err = eachValue(value, parseA)
+		case "ipv6hint":
+			err = eachValue(value, parseAAAA)
+		default:
+			if n, ok := strings.CutPrefix(key, "key"); !ok || n == "" {
+				err = fmt.Errorf("unknown parameter")
+			} else {
+				_, err = uint16Field(n)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func eachValue(list string, parse func(string) error) error {
+	for _, v := range strings.Split(list, ",") {
+		if err := parse(v); err != nil {
+			return fmt.Errorf("%q: %w", v, err)
+		}
+	}
+	return nil
+}
+
+func uint16Field(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n > 65535 {
+		return 0, fmt.Errorf("must be a number from 0 to 65535")
+	}
+	return n, nil
+}
+
+// checkName checks a hostname. Record names may also use the wildcard
+// label "*" and, like targets, underscore labels such as _dmarc.
+func checkName(name string, recordName bool) error {
+	name = strings.TrimSuffix(name, ".")
+	if name == "" {
+		return fmt.Errorf("empty name")
+	}
+	if len(name) > 253 {
+		return fmt.Errorf("longer than 253 characters")
+	}
+	for i, label := range strings.Split(name, ".") {
+		switch {
+		case label == "":
+			return fmt.Errorf("empty label")
+		case len(label) > 63:
+			return fmt.Errorf("label %q is longer than 63 characters", label)
+		case label == "*" && recordName && i == 0:
+			continue
+		case strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-"):
+			return fmt.Errorf("label %q starts or ends with a hyphen", label)
+		}
+		for _, r := range label {
+			if !isAlnum(r) && r != '-' && r != '_' {
+				return fmt.Errorf("label %q contains %q", label, r)
+			}
+		}
+	}
+	return nil
+}
+
+func isAlnum(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
+
+func displayName(name string) string {
+	if name == "" {
+		return "@"
+	}
+	return name
+}
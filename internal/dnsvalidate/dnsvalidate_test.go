@@ -0,0 +1,135 @@
+package dnsvalidate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/OverseedAI/overpork/internal/zone"
+)
+
+func TestContent(t *testing.T) {
+	sha256 := strings.Repeat("ab", 32)
+	tests := []struct {
+		recordType, content string
+		valid               bool
+	}{
+		{"A", "192.0.2.1", true},
+		{"a", "192.0.2.1", true},
+		{"A", "www.example.com", false},
+		{"A", "2001:db8::1", false},
+		{"A", "192.0.2.256", false},
+		{"AAAA", "2001:db8::1", true},
+		{"AAAA", "192.0.2.1", false},
+		{"AAAA", "::ffff:192.0.2.1", false},
+		{"CNAME", "www.example.com", true},
+		{"CNAME", "www.example.com.", true},
+		{"CNAME", "192.0.2.1", false},
+		{"CNAME", "bad..example.com", false},
+		{"CNAME", "-bad.example.com", false},
+		{"CNAME", "has space.example.com", false},
+		{"ALIAS", "lb.example.net", true},
+		{"NS", "ns1.example.net", true},
+		{"MX", "mail.example.com", true},
+		{"MX", ".", true},
+		{"MX", "10 mail.example.com", false},
+		{"TXT", "v=spf1 -all", true},
+		{"TXT", `"part one" "part two"`, true},
+		{"TXT", `"unterminated`, false},
+		{"TXT", " ", false},
+		{"SRV", "5 5060 sip.example.com", true},
+		{"SRV", "0 0 .", true},
+		{"SRV", "10 5 5060 sip.example.com", false},
+		{"SRV", "5 70000 sip.example.com", false},
+		{"TLSA", "3 1 1 " + sha256, true},
+		{"TLSA", "3 1 1 abcd", false},
+		{"TLSA", "4 1 1 " + sha256, false},
+		{"TLSA", "3 1 0 zz", false},
+		{"SSHFP", "4 2 " + sha256, true},
+		{"SSHFP", "1 1 " + sha256[:40], true},
+		{"SSHFP", "4 2 " + sha256[:40], false},
+		{"SSHFP", "4 2", false},
+		{"SSHFP", "x 2 " + sha256, false},
+		{"CAA", `0 issue "letsencrypt.org"`, true},
+		{"CAA", `0 iodef "mailto:security@example.com"`, true},
+		{"CAA", `256 issue "letsencrypt.org"`, false},
+		{"CAA", `0 issue`, false},
+		{"CAA", `0 is-sue "x"`, false},
+		{"HTTPS", "1 . alpn=h3,h2", true},
+		{"HTTPS", ". alpn=h2 ipv4hint=192.0.2.1,192.0.2.2 port=8443", true},
+		{"SVCB", "1 svc.example.net no-default-alpn key65000=x", true},
+		{"HTTPS", "1", false},
+		{"HTTPS", "1 . port=http", false},
+		{"HTTPS", "1 . ipv6hint=192.0.2.1", false},
+		{"HTTPS", "1 . bogus=1", false},
+		{"PTR", "host.example.com", false},
+	}
+	for _, tt := range tests {
+		err := Content(tt.recordType, tt.content)
+		if (err == nil) != tt.valid {
+			t.Errorf("Content(%s, %q) error = %v, want valid %v", tt.recordType, tt.content, err, tt.valid)
+		}
+	}
+}
+
+func TestRecord(t *testing.T) {
+	tests := []struct {
+		rec   zone.Record
+		valid bool
+	}{
+		{zone.Record{Name: "www", Type: "A", Content: "192.0.2.1", TTL: "600"}, true},
+		{zone.Record{Name: "*", Type: "A", Content: "192.0.2.1"}, true},
+		{zone.Record{Name: "_dmarc", Type: "TXT", Content: "v=DMARC1; p=none"}, true},
+		{zone.Record{Name: "www", Type: "A", Content: "192.0.2.1", TTL: "60"}, false},
+		{zone.Record{Name: "www", Type: "A", Content: "192.0.2.1", TTL: "ten"}, false},
+		{zone.Record{Name: "a.*", Type: "A", Content: "192.0.2.1"}, false},
+		{zone.Record{Type: "CNAME", Content: "example.net"}, false},
+		{zone.Record{Type: "ALIAS", Content: "example.net"}, true},
+		{zone.Record{Type: "MX", Content: "mail.example.com", Prio: "10"}, true},
+		{zone.Record{Type: "MX", Content: "mail.example.com"}, false},
+		{zone.Record{Type: "MX", Content: "mail.example.com", Prio: "-1"}, false},
+		{zone.Record{Name: "_sip._tcp", Type: "SRV", Content: "5 5060 sip.example.com"}, false},
+		{zone.Record{Type: "HTTPS", Content: ". alpn=h2"}, true},
+	}
+	for _, tt := range tests {
+		err := Record(tt.rec)
+		if (err == nil) != tt.valid {
+			t.Errorf("Record(%+v) error = %v, want valid %v", tt.rec, err, tt.valid)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	existing := []zone.Record{
+		{Name: "www", Type: "A", Content: "192.0.2.1"},
+		{Name: "mail", Type: "A", Content: "192.0.2.2"},
+		// Already broken live records are not our business.
+		{Name: "old", Type: "CNAME", Content: "a.example.net"},
+		{Name: "old", Type: "TXT", Content: "x"},
+	}
+	tests := []struct {
+		name    string
+		changed []zone.Record
+		errs    int
+	}{
+		{"new name", []zone.Record{{Name: "blog", Type: "CNAME", Content: "example.net"}}, 0},
+		{"CNAME next to A", []zone.Record{{Name: "WWW", Type: "CNAME", Content: "example.net"}}, 1},
+		{"A next to CNAME", []zone.Record{
+			{Name: "docs", Type: "CNAME", Content: "example.net"},
+			{Name: "docs", Type: "A", Content: "192.0.2.3"},
+		}, 1},
+		{"invalid and conflicting", []zone.Record{
+			{Name: "mail", Type: "CNAME", Content: "192.0.2.9"},
+			{Type: "MX", Content: "mail.example.com"},
+		}, 3},
+	}
+	for _, tt := range tests {
+		err := Check(existing, tt.changed)
+		got := 0
+		if err != nil {
+			got = len(strings.Split(err.Error(), "\n"))
+		}
+		if got != tt.errs {
+			t.Errorf("%s: Check() = %v, want %d errors", tt.name, err, tt.errs)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Package expiry reports domains that are about to expire.
+package expiry
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OverseedAI/overpork/internal/api"
+)
+
+// dateLayout is the format of Porkbun's createDate and expireDate.
+const dateLayout = time.DateTime
+
+// Entry is a domain expiring inside the report window.
+type Entry struct {
+	Domain     string    `json:"domain"`
+	ExpireDate time.Time `json:"expireDate"`
+	DaysLeft   int       `json:"daysLeft"`
+	AutoRenew  bool      `json:"autoRenew"`
+	// Renewal is the one-year renewal price in USD; PriceKnown is false
+	// when the TLD is missing from the price list.
+	Renewal    float64 `json:"renewal"`
+	PriceKnown bool    `json:"priceKnown"`
+}
+
+// AtRisk reports whether the domain will lapse unless someone acts.
+func (e Entry) AtRisk() bool {
+	return !e.AutoRenew
+}
+
+// Report lists the domains expiring inside a window, soonest first.
+type Report struct {
+	Entries []Entry `json:"domains"`
+	// Total is the renewal cost of every listed domain with a known price.
+	Total float64 `json:"total"`
+	// AtRisk counts domains without auto-renew.
+	AtRisk int `json:"atRisk"`
+	// Skipped lists domains whose expiry date could not be parsed.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// Build selects the domains that expire before now+within (including those
+// already expired) and prices their renewal from the TLD price list.
+func Build(domains []api.Domain, pricing map[string]api.Pricing, now time.Time, within time.Duration) *Report {
+	r := &Report{Entries: []Entry{}}
+	deadline := now.Add(within)
+	for _, d := range domains {
+		expires, err := time.ParseInLocation(dateLayout, d.ExpireDate, time.UTC)
+		if err != nil {
+			r.Skipped = append(r.Skipped, d.Domain)
+			continue
+		}
+		if expires.After(deadline) {
+			continue
+		}
+
+		e := Entry{
+			Domain:     d.Domain,
+			ExpireDate: expires,
+			DaysLeft:   int(expires.Sub(now).Hours() / 24),
+			AutoRenew:  d.AutoRenew == "1" || strings.EqualFold(d.AutoRenew, "yes"),
+		}
+		if p, ok := pricing[tld(d)]; ok {
+			if price, err := strconv.ParseFloat(p.Renewal, 64); err == nil {
+				e.Renewal, e.PriceKnown = price, true
+				r.Total += price
+			}
+		}
+		if e.AtRisk() {
+			r.AtRisk++
+		}
+		r.Entries = append(r.Entries, e)
+	}
+	sort.SliceStable(r.Entries, func(i, j int) bool {
+		return r.Entries[i].ExpireDate.Before(r.Entries[j].ExpireDate)
+	})
+	return r
+}
+
+func tld(d api.Domain) string {
+	if d.TLD != "" {
+		return strings.ToLower(d.TLD)
+	}
+	_, after, _ := strings.Cut(strings.ToLower(d.Domain), ".")
+	return after
+}
+
+// ParseWithin parses a window such as "30d", "2w" or any Go duration.
+func ParseWithin(s string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			if days, err := strconv.Atoi(n); err == nil && days >= 0 {
+				return time.Duration(days) * unit, nil
+			}
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid window %q (use e.g. 30d, 2w or 72h)", s)
+	}
+	return d, nil
+}
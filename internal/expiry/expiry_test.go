@@ -0,0 +1,77 @@
+package expiry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OverseedAI/overpork/internal/api"
+)
+
+func TestParseWithin(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30d", 30 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"72h", 72 * time.Hour, false},
+		{"0d", 0, false},
+		{"-1d", 0, true},
+		{"soon", 0, true},
+		{"d", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseWithin(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseWithin(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	domains := []api.Domain{
+		{Domain: "later.com", TLD: "com", ExpireDate: "2025-12-01 00:00:00", AutoRenew: "0"},
+		{Domain: "soon.com", TLD: "com", ExpireDate: "2025-06-20 00:00:00", AutoRenew: "1"},
+		{Domain: "sooner.dev", ExpireDate: "2025-06-10 12:00:00", AutoRenew: "0"},
+		{Domain: "gone.io", TLD: "io", ExpireDate: "2025-05-30 00:00:00", AutoRenew: "0"},
+		{Domain: "odd.com", TLD: "com", ExpireDate: "never"},
+	}
+	pricing := map[string]api.Pricing{
+		"com": {Renewal: "10.50"},
+		"dev": {Renewal: "12.00"},
+	}
+
+	r := Build(domains, pricing, now, 30*24*time.Hour)
+
+	var names []string
+	for _, e := range r.Entries {
+		names = append(names, e.Domain)
+	}
+	want := []string{"gone.io", "sooner.dev", "soon.com"}
+	if len(names) != len(want) {
+		t.Fatalf("entries = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("entries = %v, want %v", names, want)
+		}
+	}
+
+	if r.Total != 22.50 {
+		t.Errorf("Total = %v, want 22.50", r.Total)
+	}
+	if r.AtRisk != 2 {
+		t.Errorf("AtRisk = %d, want 2", r.AtRisk)
+	}
+	if e := r.Entries[0]; e.PriceKnown || e.DaysLeft != -2 {
+		t.Errorf("gone.io = %+v, want unknown price and -2 days", e)
+	}
+	if e := r.Entries[1]; e.DaysLeft != 9 || !e.PriceKnown {
+		t.Errorf("sooner.dev = %+v, want 9 days with price from its name", e)
+	}
+	if len(r.Skipped) != 1 || r.Skipped[0] != "odd.com" {
+		t.Errorf("Skipped = %v, want [odd.com]", r.Skipped)
+	}
+}
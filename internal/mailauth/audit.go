@@ -0,0 +1,197 @@
+package mailauth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"github.com/OverseedAI/overpork/internal/zone"
+)
+
+// Severity ranks audit findings. Errors break or endanger mail delivery.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Finding is a problem found in one record, or a missing record.
+type Finding struct {
+	Severity Severity `json:"severity"`
+	Check    string   `json:"check"` // spf, dmarc or dkim
+	Name     string   `json:"name"`  // relative record name, "" for the apex
+	Message  string   `json:"message"`
+}
+
+// Report is the result of auditing a domain's TXT records.
+type Report struct {
+	Domain   string    `json:"domain"`
+	SPF      string    `json:"spf,omitempty"`
+	Lookups  int       `json:"spfLookups"`
+	DMARC    string    `json:"dmarc,omitempty"`
+	DKIM     []string  `json:"dkimSelectors"`
+	Findings []Finding `json:"findings"`
+}
+
+// Count returns the number of findings of the given severity.
+func (r *Report) Count(s Severity) int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity == s {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *Report) add(s Severity, check, name, format string, args ...any) {
+	r.Findings = append(r.Findings, Finding{Severity: s, Check: check, Name: name, Message: fmt.Sprintf(format, args...)})
+}
+
+// Audit checks the SPF, DMARC and DKIM records among a domain's TXT
+// records. lookup resolves the policies of SPF includes to count DNS
+// lookups; with a nil lookup only the domain's own terms are counted.
+func Audit(ctx context.Context, domain string, records []api.DNSRecord, lookup TXTLookup) *Report {
+	r := &Report{Domain: domain, DKIM: []string{}, Findings: []Finding{}}
+
+	spfByName := map[string][]string{}
+	var spfNames []string
+	var dmarc []string
+	for _, rec := range records {
+		if !strings.EqualFold(rec.Type, "TXT") {
+			continue
+		}
+		name := zone.RelativeName(rec.Name, domain)
+		switch {
+		case IsSPF(rec.Content):
+			if _, ok := spfByName[name]; !ok {
+				spfNames = append(spfNames, name)
+			}
+			spfByName[name] = append(spfByName[name], rec.Content)
+		case IsDMARC(rec.Content):
+			if name != "_dmarc" {
+				r.add(SeverityWarning, "dmarc", name, "DMARC record is ignored here; it belongs at _dmarc")
+				continue
+			}
+			dmarc = append(dmarc, rec.Content)
+		case name == "_domainkey" || strings.HasSuffix(name, "._domainkey"):
+			r.auditDKIM(name, rec.Content)
+		}
+	}
+
+	sort.Strings(spfNames)
+	if _, ok := spfByName[""]; !ok {
+		r.add(SeverityWarning, "spf", "", "no SPF record; receivers cannot tell which servers may send mail for %s", domain)
+	}
+	for _, name := range spfNames {
+		r.auditSPF(ctx, name, spfByName[name], lookup)
+	}
+	r.auditDMARC(dmarc)
+	if len(r.DKIM) == 0 {
+		r.add(SeverityInfo, "dkim", "", "no DKIM keys in TXT records (selectors delegated with CNAME are not checked)")
+	}
+	return r
+}
+
+func (r *Report) auditSPF(ctx context.Context, name string, contents []string, lookup TXTLookup) {
+	if len(contents) > 1 {
+		r.add(SeverityError, "spf", name, "%d SPF records; receivers treat this as a permanent error, merge them into one", len(contents))
+	}
+	for _, content := range contents {
+		spf, err := ParseSPF(content)
+		if err != nil {
+			r.add(SeverityError, "spf", name, "%v", err)
+			continue
+		}
+		if name == "" {
+			r.SPF = spf.String()
+		}
+
+		if all := spf.All(); all != nil {
+			switch all.Qualifier {
+			case "", "+":
+				r.add(SeverityError, "spf", name, "%s lets any server send mail as this domain", all)
+			case "?":
+				r.add(SeverityWarning, "spf", name, "?all is neutral and gives no protection; use ~all or -all")
+			}
+		} else if spf.Redirect() == "" {
+			r.add(SeverityWarning, "spf", name, "no all mechanism; mail from unlisted servers gets a neutral result")
+		}
+		for _, t := range spf.Terms {
+			if !t.Modifier && t.Name == "ptr" {
+				r.add(SeverityWarning, "spf", name, "the ptr mechanism is deprecated and many receivers ignore it")
+				break
+			}
+		}
+
+		count, unresolved := spf.Lookups(ctx, lookup)
+		if name == "" {
+			r.Lookups = count
+		}
+		if count > MaxLookups {
+			r.add(SeverityError, "spf", name, "needs %d DNS lookups, more than the limit of %d", count, MaxLookups)
+		}
+		if lookup != nil {
+			for _, u := range unresolved {
+				r.add(SeverityWarning, "spf", name, "could not read the SPF policy of %s; the lookup count may be too low", u)
+			}
+		}
+	}
+}
+
+func (r *Report) auditDMARC(contents []string) {
+	const name = "_dmarc"
+	switch len(contents) {
+	case 0:
+		r.add(SeverityWarning, "dmarc", name, "no DMARC record; receivers apply their own policy to spoofed mail")
+		return
+	case 1:
+	default:
+		r.add(SeverityError, "dmarc", name, "%d DMARC records; receivers ignore all of them", len(contents))
+		return
+	}
+
+	tags, err := ParseDMARC(contents[0])
+	if err != nil {
+		r.add(SeverityError, "dmarc", name, "%v", err)
+		return
+	}
+	r.DMARC = tags.String()
+	if tags.Get("p") == "none" {
+		r.add(SeverityWarning, "dmarc", name, "p=none only monitors; spoofed mail is still delivered")
+	}
+	if pct := tags.Get("pct"); pct != "" {
+		if n, _ := strconv.Atoi(pct); n < 100 {
+			r.add(SeverityInfo, "dmarc", name, "pct=%d applies the policy to only part of failing mail", n)
+		}
+	}
+	if !tags.Has("rua") {
+		r.add(SeverityInfo, "dmarc", name, "no rua= address; you won't receive aggregate reports")
+	}
+}
+
+func (r *Report) auditDKIM(name, content string) {
+	selector := strings.TrimSuffix(strings.TrimSuffix(name, "_domainkey"), ".")
+	key, err := ParseDKIM(content)
+	if err != nil {
+		r.add(SeverityError, "dkim", name, "%v", err)
+		return
+	}
+	r.DKIM = append(r.DKIM, selector)
+	switch {
+	case key.Revoked:
+		r.add(SeverityInfo, "dkim", name, "key is revoked (empty p=)")
+	case key.KeyType == "rsa" && key.Bits < 1024:
+		r.add(SeverityError, "dkim", name, "%d-bit RSA key; receivers reject keys under 1024 bits", key.Bits)
+	case key.KeyType == "rsa" && key.Bits < 2048:
+		r.add(SeverityWarning, "dkim", name, "%d-bit RSA key; 2048 bits is recommended", key.Bits)
+	}
+	if key.Testing {
+		r.add(SeverityInfo, "dkim", name, "key is in testing mode (t=y)")
+	}
+}
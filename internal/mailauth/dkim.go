@@ -0,0 +1,84 @@
+package mailauth
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// DKIMKey is a parsed DKIM key record (RFC 6376, section 3.6.1).
+type DKIMKey struct {
+	KeyType string // "rsa" or "ed25519"
+	// Bits is the RSA key size, or 0 if unknown.
+	Bits int
+	// Revoked is set when the record has an empty p= tag.
+	Revoked bool
+	Testing bool
+}
+
+// ParseDKIM parses a DKIM key record.
+func ParseDKIM(content string) (*DKIMKey, error) {
+	tags, err := ParseTags(content)
+	if err != nil {
+		return nil, err
+	}
+	if v := tags.Get("v"); tags.Has("v") && v != "DKIM1" {
+		return nil, fmt.Errorf("unsupported version v=%s", v)
+	}
+	if !tags.Has("p") {
+		return nil, fmt.Errorf("missing required p= tag")
+	}
+
+	key := &DKIMKey{KeyType: strings.ToLower(tags.Get("k"))}
+	if key.KeyType == "" {
+		key.KeyType = "rsa"
+	}
+	for _, flag := range strings.Split(tags.Get("t"), ":") {
+		if strings.TrimSpace(flag) == "y" {
+			key.Testing = true
+		}
+	}
+
+	p := strings.Join(strings.Fields(tags.Get("p")), "")
+	if p == "" {
+		key.Revoked = true
+		return key, nil
+	}
+	der, err := base64.StdEncoding.DecodeString(p)
+	if err != nil {
+		return nil, fmt.Errorf("p= is not valid base64: %w", err)
+	}
+	switch key.KeyType {
+	case "rsa":
+		pub, err := parseRSA(der)
+		if err != nil {
+			return nil, err
+		}
+		key.Bits = pub.N.BitLen()
+	case "ed25519":
+		if len(der) != 32 {
+			return nil, fmt.Errorf("ed25519 key is %d bytes, want 32", len(der))
+		}
+	default:
+		return nil, fmt.Errorf("unknown key type k=%s", key.KeyType)
+	}
+	return key, nil
+}
+
+// parseRSA accepts both SubjectPublicKeyInfo, which is what DKIM specifies,
+// and bare PKCS #1 keys, which some generators emit.
+func parseRSA(der []byte) (*rsa.PublicKey, error) {
+	if pub, err := x509.ParsePKIXPublicKey(der); err == nil {
+		if rsaPub, ok := pub.(*rsa.PublicKey); ok {
+			return rsaPub, nil
+		}
+		return nil, fmt.Errorf("p= is not an RSA key")
+	}
+	pub, err := x509.ParsePKCS1PublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("p= is not a valid RSA public key")
+	}
+	return pub, nil
+}
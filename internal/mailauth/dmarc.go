@@ -0,0 +1,151 @@
+package mailauth
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Policies are the values of the DMARC p= and sp= tags.
+var Policies = []string{"none", "quarantine", "reject"}
+
+// Tags is a tag=value list as used by DMARC and DKIM records. Order is
+// kept so that editing a record changes as little as possible.
+type Tags []Tag
+
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// ParseTags parses "v=DMARC1; p=none; rua=mailto:x@example.com".
+func ParseTags(content string) (Tags, error) {
+	var tags Tags
+	for _, part := range strings.Split(TXTValue(content), ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("malformed tag %q", part)
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if tags.Has(name) {
+			return nil, fmt.Errorf("duplicate tag %q", name)
+		}
+		tags = append(tags, Tag{Name: name, Value: strings.TrimSpace(value)})
+	}
+	return tags, nil
+}
+
+// Get returns the value of a tag, or "".
+func (t Tags) Get(name string) string {
+	for _, tag := range t {
+		if tag.Name == name {
+			return tag.Value
+		}
+	}
+	return ""
+}
+
+// Has reports whether a tag is present.
+func (t Tags) Has(name string) bool {
+	for _, tag := range t {
+		if tag.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Set changes a tag in place, or appends it.
+func (t *Tags) Set(name, value string) {
+	for i := range *t {
+		if (*t)[i].Name == name {
+			(*t)[i].Value = value
+			return
+		}
+	}
+	*t = append(*t, Tag{Name: name, Value: value})
+}
+
+// Delete removes a tag.
+func (t *Tags) Delete(name string) {
+	for i := range *t {
+		if (*t)[i].Name == name {
+			*t = append((*t)[:i], (*t)[i+1:]...)
+			return
+		}
+	}
+}
+
+func (t Tags) String() string {
+	parts := make([]string, len(t))
+	for i, tag := range t {
+		parts[i] = tag.Name + "=" + tag.Value
+	}
+	return strings.Join(parts, "; ")
+}
+
+// IsDMARC reports whether TXT content is a DMARC record.
+func IsDMARC(content string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.ReplaceAll(TXTValue(content), " ", "")), "v=dmarc1")
+}
+
+// ParseDMARC parses a DMARC record and checks its tags (RFC 7489,
+// section 6.3).
+func ParseDMARC(content string) (Tags, error) {
+	tags, err := ParseTags(content)
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 || tags[0].Name != "v" || tags[0].Value != "DMARC1" {
+		return nil, fmt.Errorf("must start with v=DMARC1")
+	}
+	if !tags.Has("p") {
+		return nil, fmt.Errorf("missing required p= tag")
+	}
+	return tags, ValidateDMARC(tags)
+}
+
+// ValidateDMARC checks the values of known DMARC tags.
+func ValidateDMARC(tags Tags) error {
+	for _, tag := range tags {
+		var err error
+		switch tag.Name {
+		case "p", "sp":
+			if !isPolicy(tag.Value) {
+				err = fmt.Errorf("must be one of %s", strings.Join(Policies, ", "))
+			}
+		case "pct":
+			if n, convErr := strconv.Atoi(tag.Value); convErr != nil || n < 0 || n > 100 {
+				err = fmt.Errorf("must be a number from 0 to 100")
+			}
+		case "adkim", "aspf":
+			if tag.Value != "r" && tag.Value != "s" {
+				err = fmt.Errorf("must be r or s")
+			}
+		case "rua", "ruf":
+			for _, uri := range strings.Split(tag.Value, ",") {
+				if !strings.HasPrefix(strings.ToLower(strings.TrimSpace(uri)), "mailto:") {
+					err = fmt.Errorf("%q is not a mailto: URI", strings.TrimSpace(uri))
+					break
+				}
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s=%s: %w", tag.Name, tag.Value, err)
+		}
+	}
+	return nil
+}
+
+func isPolicy(s string) bool {
+	for _, p := range Policies {
+		if s == p {
+			return true
+		}
+	}
+	return false
+}
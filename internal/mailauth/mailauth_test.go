@@ -0,0 +1,227 @@
+package mailauth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/OverseedAI/overpork/internal/api"
+)
+
+func TestParseSPF(t *testing.T) {
+	tests := []struct {
+		content, want string
+		direct        int
+		wantErr       bool
+	}{
+		{"v=spf1 include:_spf.google.com ~all", "v=spf1 include:_spf.google.com ~all", 1, false},
+		{`"v=spf1 ip4:192.0.2.0/24 " "a mx -all"`, "v=spf1 ip4:192.0.2.0/24 a mx -all", 2, false},
+		{"V=SPF1 a/24 redirect=_spf.example.net", "v=spf1 a/24 redirect=_spf.example.net", 2, false},
+		{"v=spf1 exists:%{i}.example.net ?all", "v=spf1 exists:%{i}.example.net ?all", 1, false},
+		{"v=spf1", "v=spf1", 0, false},
+		{"v=spf2 -all", "", 0, true},
+		{"v=spf1 includes:example.net -all", "", 0, true},
+		{"v=spf1 include: -all", "", 0, true},
+	}
+	for _, tt := range tests {
+		spf, err := ParseSPF(tt.content)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSPF(%q) error = %v, wantErr %v", tt.content, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got := spf.String(); got != tt.want {
+			t.Errorf("ParseSPF(%q).String() = %q, want %q", tt.content, got, tt.want)
+		}
+		if got := spf.DirectLookups(); got != tt.direct {
+			t.Errorf("ParseSPF(%q).DirectLookups() = %d, want %d", tt.content, got, tt.direct)
+		}
+	}
+}
+
+func TestSPFIncludes(t *testing.T) {
+	tests := []struct {
+		content, add, want string
+	}{
+		{"v=spf1 include:a.example -all", "b.example", "v=spf1 include:a.example include:b.example -all"},
+		{"v=spf1 mx redirect=r.example", "b.example", "v=spf1 mx include:b.example redirect=r.example"},
+		{"v=spf1 ip4:192.0.2.1", "b.example", "v=spf1 ip4:192.0.2.1 include:b.example"},
+		{"v=spf1 include:B.example. ~all", "b.example", "v=spf1 include:B.example. ~all"},
+	}
+	for _, tt := range tests {
+		spf, err := ParseSPF(tt.content)
+		if err != nil {
+			t.Fatalf("ParseSPF(%q) error = %v", tt.content, err)
+		}
+		added := spf.AddInclude(tt.add)
+		if got := spf.String(); got != tt.want {
+			t.Errorf("AddInclude(%q) on %q = %q, want %q", tt.add, tt.content, got, tt.want)
+		}
+		if added != (tt.content != tt.want) {
+			t.Errorf("AddInclude(%q) on %q reported %v", tt.add, tt.content, added)
+		}
+	}
+
+	spf, _ := ParseSPF("v=spf1 include:a.example include:b.example -all")
+	if !spf.RemoveInclude("A.example") || spf.String() != "v=spf1 include:b.example -all" {
+		t.Errorf("RemoveInclude() = %q", spf.String())
+	}
+	if spf.RemoveInclude("c.example") {
+		t.Error("RemoveInclude(missing) = true")
+	}
+}
+
+func fakeLookup(policies map[string]string) TXTLookup {
+	return func(ctx context.Context, name string) ([]string, error) {
+		if p, ok := policies[name]; ok {
+			return []string{"some-verification=1", p}, nil
+		}
+		return nil, fmt.Errorf("no such host %s", name)
+	}
+}
+
+func TestSPFLookups(t *testing.T) {
+	lookup := fakeLookup(map[string]string{
+		"a.example":    "v=spf1 include:b.example mx -all",
+		"b.example":    "v=spf1 a include:a.example ~all",
+		"many.example": "v=spf1 " + strings.Repeat("a ", 9) + "-all",
+	})
+	tests := []struct {
+		content    string
+		want       int
+		unresolved int
+	}{
+		{"v=spf1 include:a.example -all", 5, 0}, // include:a, include:b, a, include:a (loop, not followed), mx
+		{"v=spf1 ip4:192.0.2.1 -all", 0, 0},
+		{"v=spf1 include:many.example mx -all", 11, 0},
+		{"v=spf1 include:missing.example -all", 1, 1},
+	}
+	for _, tt := range tests {
+		spf, err := ParseSPF(tt.content)
+		if err != nil {
+			t.Fatalf("ParseSPF(%q) error = %v", tt.content, err)
+		}
+		got, unresolved := spf.Lookups(context.Background(), lookup)
+		if got != tt.want || len(unresolved) != tt.unresolved {
+			t.Errorf("Lookups(%q) = %d, %v; want %d with %d unresolved", tt.content, got, unresolved, tt.want, tt.unresolved)
+		}
+	}
+}
+
+func TestParseDMARC(t *testing.T) {
+	tests := []struct {
+		content string
+		wantErr bool
+	}{
+		{"v=DMARC1; p=reject; rua=mailto:d@example.com", false},
+		{`"v=DMARC1; p=none; pct=50; adkim=s"`, false},
+		{"v=DMARC1; rua=mailto:d@example.com", true},
+		{"p=none; v=DMARC1", true},
+		{"v=DMARC1; p=block", true},
+		{"v=DMARC1; p=none; pct=150", true},
+		{"v=DMARC1; p=none; rua=https://example.com", true},
+		{"v=DMARC1; p=none; p=reject", true},
+	}
+	for _, tt := range tests {
+		if _, err := ParseDMARC(tt.content); (err != nil) != tt.wantErr {
+			t.Errorf("ParseDMARC(%q) error = %v, wantErr %v", tt.content, err, tt.wantErr)
+		}
+	}
+
+	tags, _ := ParseDMARC("v=DMARC1; p=none; rua=mailto:d@example.com")
+	tags.Set("p", "quarantine")
+	tags.Set("pct", "25")
+	if got, want := tags.String(), "v=DMARC1; p=quarantine; rua=mailto:d@example.com; pct=25"; got != want {
+		t.Errorf("Tags.String() = %q, want %q", got, want)
+	}
+}
+
+func rsaKey(t *testing.T, bits int) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(der)
+}
+
+func TestParseDKIM(t *testing.T) {
+	strong := rsaKey(t, 2048)
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	key, err := ParseDKIM("v=DKIM1; k=rsa; p=" + strong)
+	if err != nil || key.Bits != 2048 || key.Revoked {
+		t.Errorf("ParseDKIM(rsa 2048) = %+v, %v", key, err)
+	}
+	key, err = ParseDKIM("v=DKIM1; k=ed25519; t=y; p=" + base64.StdEncoding.EncodeToString(pub))
+	if err != nil || key.KeyType != "ed25519" || !key.Testing {
+		t.Errorf("ParseDKIM(ed25519) = %+v, %v", key, err)
+	}
+	key, err = ParseDKIM("v=DKIM1; p=")
+	if err != nil || !key.Revoked {
+		t.Errorf("ParseDKIM(revoked) = %+v, %v", key, err)
+	}
+
+	for _, content := range []string{
+		"v=DKIM1; k=rsa",
+		"v=DKIM1; p=not*base64",
+		"v=DKIM1; p=" + base64.StdEncoding.EncodeToString([]byte("garbage")),
+		"v=DKIM2; p=" + strong,
+		"v=DKIM1; k=dsa; p=" + strong,
+	} {
+		if _, err := ParseDKIM(content); err == nil {
+			t.Errorf("ParseDKIM(%q) succeeded, want error", content)
+		}
+	}
+}
+
+func TestAudit(t *testing.T) {
+	weak := rsaKey(t, 1024)
+	records := []api.DNSRecord{
+		{Name: "example.com", Type: "TXT", Content: "v=spf1 include:a.example +all"},
+		{Name: "example.com", Type: "TXT", Content: "v=spf1 mx -all"},
+		{Name: "example.com", Type: "TXT", Content: "google-site-verification=abc"},
+		{Name: "example.com", Type: "A", Content: "v=spf1 not really"},
+		{Name: "_dmarc.example.com", Type: "TXT", Content: "v=DMARC1; p=none"},
+		{Name: "s1._domainkey.example.com", Type: "TXT", Content: "v=DKIM1; k=rsa; p=" + weak},
+		{Name: "s2._domainkey.example.com", Type: "TXT", Content: "v=DKIM1; p=%%%"},
+	}
+	lookup := fakeLookup(map[string]string{"a.example": "v=spf1 -all"})
+	r := Audit(context.Background(), "example.com", records, lookup)
+
+	var got []string
+	for _, f := range r.Findings {
+		got = append(got, fmt.Sprintf("%s %s %s", f.Severity, f.Check, f.Name))
+	}
+	want := []string{
+		"warning dkim s1._domainkey",
+		"error dkim s2._domainkey",
+		"error spf ", // two SPF records
+		"error spf ", // +all
+		"warning dmarc _dmarc",
+		"info dmarc _dmarc",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("findings:\n got %q\nwant %q", got, want)
+	}
+	if r.Count(SeverityError) != 3 || len(r.DKIM) != 1 || r.DKIM[0] != "s1" {
+		t.Errorf("report = %+v", r)
+	}
+
+	r = Audit(context.Background(), "example.com", nil, nil)
+	if r.Count(SeverityWarning) != 2 || r.Count(SeverityInfo) != 1 {
+		t.Errorf("empty zone findings = %+v", r.Findings)
+	}
+}
@@ -0,0 +1,269 @@
+// Package mailauth parses, checks and edits the SPF, DKIM and DMARC TXT
+// records that authenticate a domain's mail.
+package mailauth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MaxLookups is the number of DNS-querying terms an SPF evaluation may use
+// (RFC 7208, section 4.6.4).
+const MaxLookups = 10
+
+// SPF is a parsed SPF policy. Terms keep their order and spelling so that
+// String reproduces the record apart from whitespace.
+type SPF struct {
+	Terms []Term
+}
+
+// Term is a mechanism such as "~all" or "include:_spf.example.net", or a
+// modifier such as "redirect=example.net".
+type Term struct {
+	Qualifier string // "+", "-", "~", "?" or "" (meaning "+")
+	Name      string // lower-cased mechanism or modifier name
+	Value     string // after ":" or "=", or a "/" prefix length; may be empty
+	Modifier  bool
+	raw       string
+}
+
+func (t Term) String() string {
+	if t.raw != "" {
+		return t.raw
+	}
+	switch {
+	case t.Modifier:
+		return t.Name + "=" + t.Value
+	case t.Value != "":
+		return t.Qualifier + t.Name + ":" + t.Value
+	}
+	return t.Qualifier + t.Name
+}
+
+var mechanisms = map[string]bool{
+	"all": true, "include": true, "a": true, "mx": true,
+	"ptr": true, "ip4": true, "ip6": true, "exists": true,
+}
+
+// IsSPF reports whether TXT content is an SPF record.
+func IsSPF(content string) bool {
+	v := strings.ToLower(TXTValue(content))
+	return v == "v=spf1" || strings.HasPrefix(v, "v=spf1 ")
+}
+
+// ParseSPF parses the content of an SPF TXT record.
+func ParseSPF(content string) (*SPF, error) {
+	fields := strings.Fields(TXTValue(content))
+	if len(fields) == 0 || !strings.EqualFold(fields[0], "v=spf1") {
+		return nil, fmt.Errorf("not an SPF record (must start with v=spf1)")
+	}
+
+	spf := &SPF{}
+	for _, f := range fields[1:] {
+		t := Term{raw: f}
+		body := f
+		if strings.ContainsRune("+-~?", rune(body[0])) {
+			t.Qualifier, body = body[:1], body[1:]
+		}
+		name, value, isMod := strings.Cut(body, "=")
+		if isMod && t.Qualifier == "" && !strings.ContainsAny(name, ":/") {
+			t.Name, t.Value, t.Modifier = strings.ToLower(name), value, true
+			spf.Terms = append(spf.Terms, t)
+			continue
+		}
+		i := strings.IndexAny(body, ":/")
+		if i < 0 {
+			t.Name = strings.ToLower(body)
+		} else {
+			t.Name, t.Value = strings.ToLower(body[:i]), strings.TrimPrefix(body[i:], ":")
+		}
+		if !mechanisms[t.Name] {
+			return nil, fmt.Errorf("unknown SPF mechanism %q", f)
+		}
+		if (t.Name == "include" || t.Name == "exists") && t.Value == "" {
+			return nil, fmt.Errorf("%s needs a domain", t.Name)
+		}
+		spf.Terms = append(spf.Terms, t)
+	}
+	return spf, nil
+}
+
+// String renders the policy as TXT record content.
+func (s *SPF) String() string {
+	parts := []string{"v=spf1"}
+	for _, t := range s.Terms {
+		parts = append(parts, t.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// All returns the "all" mechanism, or nil.
+func (s *SPF) All() *Term {
+	for i := range s.Terms {
+		if !s.Terms[i].Modifier && s.Terms[i].Name == "all" {
+			return &s.Terms[i]
+		}
+	}
+	return nil
+}
+
+// Redirect returns the domain of the redirect modifier, or "".
+func (s *SPF) Redirect() string {
+	for _, t := range s.Terms {
+		if t.Modifier && t.Name == "redirect" {
+			return t.Value
+		}
+	}
+	return ""
+}
+
+// Includes returns the domains of the include mechanisms.
+func (s *SPF) Includes() []string {
+	var out []string
+	for _, t := range s.Terms {
+		if !t.Modifier && t.Name == "include" {
+			out = append(out, t.Value)
+		}
+	}
+	return out
+}
+
+// HasInclude reports whether the policy includes domain.
+func (s *SPF) HasInclude(domain string) bool {
+	for _, inc := range s.Includes() {
+		if strings.EqualFold(strings.TrimSuffix(inc, "."), strings.TrimSuffix(domain, ".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddInclude adds "include:domain" in front of the "all" mechanism and any
+// modifiers, so it is evaluated before the policy's default result. It
+// reports false if the domain is already included.
+func (s *SPF) AddInclude(domain string) bool {
+	if s.HasInclude(domain) {
+		return false
+	}
+	at := len(s.Terms)
+	for i, t := range s.Terms {
+		if t.Modifier || t.Name == "all" {
+			at = i
+			break
+		}
+	}
+	inc := Term{Name: "include", Value: domain}
+	s.Terms = append(s.Terms[:at], append([]Term{inc}, s.Terms[at:]...)...)
+	return true
+}
+
+// RemoveInclude drops "include:domain" and reports whether it was there.
+func (s *SPF) RemoveInclude(domain string) bool {
+	for i, t := range s.Terms {
+		if !t.Modifier && t.Name == "include" && strings.EqualFold(strings.TrimSuffix(t.Value, "."), strings.TrimSuffix(domain, ".")) {
+			s.Terms = append(s.Terms[:i], s.Terms[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// DirectLookups counts the terms of this record that cost a DNS lookup,
+// without following includes.
+func (s *SPF) DirectLookups() int {
+	n := 0
+	for _, t := range s.Terms {
+		if costsLookup(t) {
+			n++
+		}
+	}
+	return n
+}
+
+func costsLookup(t Term) bool {
+	if t.Modifier {
+		return t.Name == "redirect"
+	}
+	switch t.Name {
+	case "include", "a", "mx", "ptr", "exists":
+		return true
+	}
+	return false
+}
+
+// TXTLookup returns the TXT records of a name, like net.Resolver.LookupTXT.
+type TXTLookup func(ctx context.Context, name string) ([]string, error)
+
+// Lookups counts the DNS lookups needed to evaluate the policy, following
+// include and redirect into the policies they name. Names whose policy
+// cannot be fetched are counted once and reported in unresolved.
+func (s *SPF) Lookups(ctx context.Context, lookup TXTLookup) (count int, unresolved []string) {
+	seen := map[string]bool{}
+	var walk func(spf *SPF, depth int)
+	walk = func(spf *SPF, depth int) {
+		for _, t := range spf.Terms {
+			if !costsLookup(t) {
+				continue
+			}
+			count++
+			if t.Name != "include" && t.Name != "redirect" {
+				continue
+			}
+			name := strings.ToLower(strings.TrimSuffix(t.Value, "."))
+			if seen[name] || depth > MaxLookups || count > MaxLookups*2 {
+				continue
+			}
+			seen[name] = true
+			child, err := fetchSPF(ctx, lookup, name)
+			if err != nil {
+				unresolved = append(unresolved, name)
+				continue
+			}
+			walk(child, depth+1)
+		}
+	}
+	walk(s, 0)
+	return count, unresolved
+}
+
+func fetchSPF(ctx context.Context, lookup TXTLookup, name string) (*SPF, error) {
+	if lookup == nil {
+		return nil, fmt.Errorf("lookups disabled")
+	}
+	txts, err := lookup(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, txt := range txts {
+		if IsSPF(txt) {
+			return ParseSPF(txt)
+		}
+	}
+	return nil, fmt.Errorf("%s has no SPF record", name)
+}
+
+// TXTValue returns the text of TXT record content, joining the quoted
+// strings of a record like "v=spf1 " "-all" the way resolvers do.
+func TXTValue(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, `"`) {
+		return content
+	}
+	var b strings.Builder
+	inQuote, escaped := false, false
+	for _, r := range content {
+		switch {
+		case escaped:
+			b.WriteRune(r)
+			escaped = false
+		case r == '\\' && inQuote:
+			escaped = true
+		case r == '"':
+			inQuote = !inQuote
+		case inQuote:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,481 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Output formats accepted by --output.
+const (
+	FormatTable  = "table"
+	FormatJSON   = "json"
+	FormatYAML   = "yaml"
+	FormatCSV    = "csv"
+	FormatTSV    = "tsv"
+	FormatNDJSON = "ndjson"
+	// FormatTemplate is selected with go-template=TEMPLATE or
+	// go-template-file=PATH.
+	FormatTemplate = "go-template"
+)
+
+// Formats lists every output format.
+var Formats = []string{FormatTable, FormatJSON, FormatYAML, FormatCSV, FormatTSV, FormatNDJSON}
+
+// tmpl is the template of FormatTemplate.
+var tmpl *template.Template
+
+// SetFormat selects the output format. Besides the names in Formats it
+// accepts go-template=TEMPLATE (or template=TEMPLATE) and
+// go-template-file=PATH.
+func SetFormat(name string) error {
+	kind, arg, hasArg := strings.Cut(name, "=")
+	switch strings.ToLower(kind) {
+	case "go-template", "template":
+		if !hasArg {
+			break
+		}
+		return setTemplate(arg)
+	case "go-template-file", "template-file":
+		if !hasArg {
+			break
+		}
+		data, err := os.ReadFile(arg)
+		if err != nil {
+			return fmt.Errorf("failed to read template: %w", err)
+		}
+		return setTemplate(string(data))
+	}
+
+	name = strings.ToLower(name)
+	if !slices.Contains(Formats, name) {
+		return fmt.Errorf("invalid output format %q (use %s, go-template=TEMPLATE or go-template-file=PATH)", name, strings.Join(Formats, ", "))
+	}
+	Format = name
+	return nil
+}
+
+func setTemplate(text string) error {
+	t, err := template.New("output").Option("missingkey=error").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+		"join": func(sep string, v []string) string { return strings.Join(v, sep) },
+	}).Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	tmpl = t
+	Format = FormatTemplate
+	return nil
+}
+
+func executeTemplate(w io.Writer, v any) error {
+	if err := tmpl.Execute(w, v); err != nil {
+		return fmt.Errorf("failed to execute template: %w", err)
+	}
+	return nil
+}
+
+// SetFields selects the fields to print, e.g. "id,name,content". Names
+// match JSON field names or table headers, ignoring case. Templates pick
+// their own fields, so the two cannot be combined.
+func SetFields(fields []string) error {
+	var cleaned []string
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			cleaned = append(cleaned, f)
+		}
+	}
+	if len(cleaned) > 0 && Format == FormatTemplate {
+		return fmt.Errorf("--fields cannot be combined with a template")
+	}
+	Fields = cleaned
+	return nil
+}
+
+// object is a decoded JSON object that remembers its key order, so that
+// YAML and CSV output list fields in struct order.
+type object struct {
+	keys   []string
+	values map[string]any
+}
+
+func (o *object) set(key string, v any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = v
+}
+
+func toList(objs []*object) []any {
+	list := make([]any, len(objs))
+	for i, o := range objs {
+		list[i] = o
+	}
+	return list
+}
+
+// encode writes v in a structured format, keeping only the selected
+// fields.
+func encode(w io.Writer, format string, v any) error {
+	switch {
+	case format == FormatTemplate:
+		return executeTemplate(w, v)
+	case (format == FormatJSON || format == FormatTable) && len(Fields) == 0:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tree, err := decodeOrdered(data)
+	if err != nil {
+		return err
+	}
+	if len(Fields) > 0 {
+		if tree, err = selectFields(tree, Fields); err != nil {
+			return err
+		}
+	}
+	return encodeTree(w, format, tree)
+}
+
+// selectFields keeps the named fields of an object or of every object in a
+// list, in the order given.
+func selectFields(tree any, fields []string) (any, error) {
+	items, isList := tree.([]any)
+	if !isList {
+		items = []any{tree}
+	}
+
+	var available []string
+	for _, item := range items {
+		if obj, ok := item.(*object); ok {
+			for _, k := range obj.keys {
+				if !slices.Contains(available, k) {
+					available = append(available, k)
+				}
+			}
+		}
+	}
+	keys := make([]string, len(fields))
+	for i, f := range fields {
+		j := slices.IndexFunc(available, func(k string) bool { return strings.EqualFold(k, f) })
+		if j < 0 && len(available) > 0 {
+			return nil, fmt.Errorf("unknown field %q (available: %s)", f, strings.Join(available, ", "))
+		}
+		keys[i] = f
+		if j >= 0 {
+			keys[i] = available[j]
+		}
+	}
+
+	selected := make([]any, len(items))
+	for i, item := range items {
+		obj, ok := item.(*object)
+		if !ok {
+			selected[i] = item
+			continue
+		}
+		out := &object{values: map[string]any{}}
+		for _, k := range keys {
+			out.set(k, obj.values[k])
+		}
+		selected[i] = out
+	}
+	if !isList {
+		return selected[0], nil
+	}
+	return selected, nil
+}
+
+// selectColumns keeps the named table columns, in the order given.
+func selectColumns(headers []string, rows [][]string, fields []string) ([]string, [][]string, error) {
+	idx := make([]int, len(fields))
+	for i, f := range fields {
+		idx[i] = slices.IndexFunc(headers, func(h string) bool { return strings.EqualFold(h, f) })
+		if idx[i] < 0 {
+			return nil, nil, fmt.Errorf("unknown field %q (available: %s)", f, strings.ToLower(strings.Join(headers, ", ")))
+		}
+	}
+	selHeaders := make([]string, len(idx))
+	for i, j := range idx {
+		selHeaders[i] = headers[j]
+	}
+	selRows := make([][]string, len(rows))
+	for r, row := range rows {
+		selRows[r] = make([]string, len(idx))
+		for i, j := range idx {
+			if j < len(row) {
+				selRows[r][i] = row[j]
+			}
+		}
+	}
+	return selHeaders, selRows, nil
+}
+
+// rowMaps gives templates table rows keyed by header.
+func rowMaps(headers []string, rows [][]string) []map[string]string {
+	data := make([]map[string]string, len(rows))
+	for i, row := range rows {
+		data[i] = map[string]string{}
+		for j, h := range headers {
+			if j < len(row) {
+				data[i][h] = row[j]
+			}
+		}
+	}
+	return data
+}
+
+// encodeTree writes a value produced by decodeOrdered.
+func encodeTree(w io.Writer, format string, tree any) error {
+	switch format {
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(yamlNode(tree)); err != nil {
+			return err
+		}
+		return enc.Close()
+	case FormatNDJSON:
+		items, ok := tree.([]any)
+		if !ok {
+			items = []any{tree}
+		}
+		for _, item := range items {
+			if err := writeCompact(w, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatCSV, FormatTSV:
+		headers, rows := flatten(tree)
+		return writeDelimited(w, format, headers, rows)
+	case FormatTable:
+		headers, rows := flatten(tree)
+		for i, h := range headers {
+			headers[i] = strings.ToUpper(h)
+		}
+		writeTable(w, headers, rows)
+		return nil
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jsonValue(tree))
+}
+
+func writeCompact(w io.Writer, v any) error {
+	data, err := json.Marshal(jsonValue(v))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+func writeDelimited(w io.Writer, format string, headers []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if format == FormatTSV {
+		cw.Comma = '\t'
+	}
+	if err := cw.Write(headers); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if format == FormatTSV {
+			// TSV has no quoting; keep every record on one line.
+			row = slices.Clone(row)
+			for i, cell := range row {
+				row[i] = strings.NewReplacer("\t", " ", "\n", " ", "\r", "").Replace(cell)
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// flatten turns a list of objects into a header and rows. A single object
+// becomes one row and a scalar list becomes one "value" column. Nested
+// values are rendered as compact JSON.
+func flatten(tree any) ([]string, [][]string) {
+	items, ok := tree.([]any)
+	if !ok {
+		items = []any{tree}
+	}
+
+	var headers []string
+	seen := map[string]bool{}
+	for _, item := range items {
+		obj, ok := item.(*object)
+		if !ok {
+			continue
+		}
+		for _, k := range obj.keys {
+			if !seen[k] {
+				seen[k] = true
+				headers = append(headers, k)
+			}
+		}
+	}
+
+	rows := make([][]string, 0, len(items))
+	if len(headers) == 0 {
+		for _, item := range items {
+			rows = append(rows, []string{cell(item)})
+		}
+		return []string{"value"}, rows
+	}
+	for _, item := range items {
+		row := make([]string, len(headers))
+		if obj, ok := item.(*object); ok {
+			for i, h := range headers {
+				row[i] = cell(obj.values[h])
+			}
+		}
+		rows = append(rows, row)
+	}
+	return headers, rows
+}
+
+func cell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	data, _ := json.Marshal(jsonValue(v))
+	return string(data)
+}
+
+// decodeOrdered decodes JSON into strings, json.Numbers, bools, nil,
+// []any and *object.
+func decodeOrdered(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return decodeValue(dec)
+}
+
+func decodeValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := &object{values: map[string]any{}}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(key.(string), v)
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		list := []any{}
+		for dec.More() {
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		_, err := dec.Token()
+		return list, err
+	}
+	return tok, nil
+}
+
+// jsonValue converts a decoded tree back into values encoding/json writes
+// in the original order.
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case *object:
+		return orderedJSON{v}
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = jsonValue(item)
+		}
+		return out
+	}
+	return v
+}
+
+type orderedJSON struct{ *object }
+
+func (o orderedJSON) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		val, err := json.Marshal(jsonValue(o.values[k]))
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func yamlNode(v any) *yaml.Node {
+	switch v := v.(type) {
+	case *object:
+		n := &yaml.Node{Kind: yaml.MappingNode}
+		for _, k := range v.keys {
+			n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: k}, yamlNode(v.values[k]))
+		}
+		return n
+	case []any:
+		n := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range v {
+			n.Content = append(n.Content, yamlNode(item))
+		}
+		return n
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(v.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: fmt.Sprint(v)}
+}
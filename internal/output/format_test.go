@@ -0,0 +1,189 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+type testRecord struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	TTL     int      `json:"ttl"`
+	Enabled bool     `json:"enabled"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+func TestPrintJSONFormats(t *testing.T) {
+	records := []testRecord{
+		{ID: "1", Name: "www", TTL: 600, Enabled: true},
+		{ID: "2", Name: "mail, smtp", TTL: 3600, Tags: []string{"a", "b"}},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{FormatCSV, "id,name,ttl,enabled,tags\n1,www,600,true,\n2,\"mail, smtp\",3600,false,\"[\"\"a\"\",\"\"b\"\"]\"\n"},
+		{FormatTSV, "id\tname\tttl\tenabled\ttags\n1\twww\t600\ttrue\t\n2\tmail, smtp\t3600\tfalse\t\"[\"\"a\"\",\"\"b\"\"]\"\n"},
+		{FormatNDJSON, "{\"id\":\"1\",\"name\":\"www\",\"ttl\":600,\"enabled\":true}\n{\"id\":\"2\",\"name\":\"mail, smtp\",\"ttl\":3600,\"enabled\":false,\"tags\":[\"a\",\"b\"]}\n"},
+		{FormatYAML, "- id: \"1\"\n  name: www\n  ttl: 600\n  enabled: true\n- id: \"2\"\n  name: mail, smtp\n  ttl: 3600\n  enabled: false\n  tags:\n    - a\n    - b\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			Stdout = &buf
+			Format = tt.format
+			defer func() { Format = FormatTable }()
+
+			PrintJSON(records)
+
+			if got := buf.String(); got != tt.want {
+				t.Errorf("PrintJSON() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintJSONSingleObject(t *testing.T) {
+	var buf bytes.Buffer
+	Stdout = &buf
+	Format = FormatCSV
+	defer func() { Format = FormatTable }()
+
+	PrintJSON(map[string]any{"status": "created", "id": 7})
+
+	if got, want := buf.String(), "id,status\n7,created\n"; got != want {
+		t.Errorf("PrintJSON() = %q, want %q", got, want)
+	}
+}
+
+func TestPrintTableFormats(t *testing.T) {
+	headers := []string{"NAME", "VALUE"}
+	rows := [][]string{{"foo", "a\tb"}}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{FormatCSV, "NAME,VALUE\nfoo,a\tb\n"},
+		{FormatTSV, "NAME\tVALUE\nfoo\ta b\n"},
+		{FormatNDJSON, "{\"NAME\":\"foo\",\"VALUE\":\"a\\tb\"}\n"},
+		{FormatYAML, "- NAME: foo\n  VALUE: \"a\\tb\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			Stdout = &buf
+			Format = tt.format
+			defer func() { Format = FormatTable }()
+
+			PrintTable(headers, rows)
+
+			if got := buf.String(); got != tt.want {
+				t.Errorf("PrintTable() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetFormat(t *testing.T) {
+	defer func() { Format = FormatTable }()
+	if err := SetFormat("YAML"); err != nil || Format != FormatYAML || !Structured() {
+		t.Errorf("SetFormat(YAML) = %v, Format = %q", err, Format)
+	}
+	if err := SetFormat("xml"); err == nil {
+		t.Error("SetFormat(xml) error = nil, want error")
+	}
+}
+
+func TestFields(t *testing.T) {
+	records := []testRecord{
+		{ID: "1", Name: "www", TTL: 600},
+		{ID: "2", Name: "mail", TTL: 3600},
+	}
+
+	tests := []struct {
+		name    string
+		format  string
+		fields  []string
+		want    string
+		wantErr bool
+	}{
+		{"table", FormatTable, []string{"name", "ID"}, "NAME  ID\nwww   1\nmail  2\n", false},
+		{"csv", FormatCSV, []string{"ttl"}, "ttl\n600\n3600\n", false},
+		{"json", FormatNDJSON, []string{"Name"}, "{\"name\":\"www\"}\n{\"name\":\"mail\"}\n", false},
+		{"unknown", FormatCSV, []string{"notes"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			Stdout = &buf
+			Format = tt.format
+			printErr = nil
+			defer func() { Format, Fields, printErr = FormatTable, nil, nil }()
+			if err := SetFields(tt.fields); err != nil {
+				t.Fatal(err)
+			}
+
+			PrintJSON(records)
+
+			if (Err() != nil) != tt.wantErr {
+				t.Fatalf("Err() = %v, wantErr %v", Err(), tt.wantErr)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("PrintJSON() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldsTable(t *testing.T) {
+	var buf bytes.Buffer
+	Stdout = &buf
+	Fields = []string{"value"}
+	defer func() { Fields, printErr = nil, nil }()
+
+	PrintTable([]string{"NAME", "VALUE"}, [][]string{{"foo", "bar"}})
+
+	if got, want := buf.String(), "VALUE\nbar\n"; got != want {
+		t.Errorf("PrintTable() = %q, want %q", got, want)
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	defer func() { Format, printErr = FormatTable, nil }()
+
+	if err := SetFormat(`go-template={{range .}}{{.Name}} {{.TTL}}{{"\n"}}{{end}}`); err != nil {
+		t.Fatalf("SetFormat() error = %v", err)
+	}
+	var buf bytes.Buffer
+	Stdout = &buf
+	PrintJSON([]testRecord{{Name: "www", TTL: 600}, {Name: "mail", TTL: 3600}})
+	if got, want := buf.String(), "www 600\nmail 3600\n"; got != want {
+		t.Errorf("template output = %q, want %q", got, want)
+	}
+
+	if err := SetFormat(`go-template={{range .}}{{.NAME}}={{.VALUE}}{{end}}`); err != nil {
+		t.Fatalf("SetFormat() error = %v", err)
+	}
+	buf.Reset()
+	PrintTable([]string{"NAME", "VALUE"}, [][]string{{"foo", "bar"}})
+	if got, want := buf.String(), "foo=bar"; got != want {
+		t.Errorf("template on table rows = %q, want %q", got, want)
+	}
+
+	if err := SetFormat("go-template={{.missing}}"); err != nil {
+		t.Fatal(err)
+	}
+	PrintJSON(map[string]string{"name": "www"})
+	if Err() == nil {
+		t.Error("Err() = nil after a template referenced a missing key")
+	}
+
+	if err := SetFormat("go-template={{.Name"); err == nil {
+		t.Error("SetFormat(invalid template) error = nil, want error")
+	}
+	if err := SetFields([]string{"name"}); err == nil {
+		t.Error("SetFields() with a template error = nil, want error")
+	}
+}
@@ -1,7 +1,6 @@
 package output
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -9,44 +8,99 @@ import (
 )
 
 var (
-	JSONOutput bool
-	Stdout     io.Writer = os.Stdout
-	Stderr     io.Writer = os.Stderr
+	// Format is the output format selected with --output. Every format but
+	// FormatTable is machine-readable; see Structured.
+	Format = FormatTable
+	// Fields, when set, selects the fields to print (see SetFields).
+	Fields []string
+	Stdout io.Writer = os.Stdout
+	Stderr io.Writer = os.Stderr
+
+	// printErr is the first error met while printing; see Err.
+	printErr error
 )
 
+// Err returns the first error met while printing, such as an unknown
+// field or a failing template, so the command can still exit non-zero.
+func Err() error {
+	return printErr
+}
+
+func fail(err error) {
+	if printErr == nil {
+		printErr = err
+	}
+}
+
+// Structured reports whether commands should print their data with
+// PrintJSON or PrintTable and skip human-oriented messages. That is the
+// case for machine-readable formats, and for tables when --fields picks
+// the columns.
+func Structured() bool {
+	return Format != FormatTable || len(Fields) > 0
+}
+
 func Print(v any) {
-	if JSONOutput {
-		enc := json.NewEncoder(Stdout)
-		enc.SetIndent("", "  ")
-		_ = enc.Encode(v)
+	if Structured() {
+		PrintJSON(v)
 		return
 	}
 	fmt.Fprintln(Stdout, v)
 }
 
+// PrintJSON prints v in the selected structured format. Despite the name
+// it handles every format: v is encoded through its JSON representation,
+// so struct tags decide the field names everywhere. Templates are the
+// exception and see v itself.
 func PrintJSON(v any) {
-	enc := json.NewEncoder(Stdout)
-	enc.SetIndent("", "  ")
-	_ = enc.Encode(v)
+	if err := encode(Stdout, Format, v); err != nil {
+		fail(err)
+	}
 }
 
 func PrintTable(headers []string, rows [][]string) {
-	if JSONOutput {
-		data := make([]map[string]string, len(rows))
+	if len(Fields) > 0 {
+		var err error
+		if headers, rows, err = selectColumns(headers, rows, Fields); err != nil {
+			fail(err)
+			return
+		}
+	}
+
+	switch Format {
+	case FormatTable:
+	case FormatCSV, FormatTSV:
+		if err := writeDelimited(Stdout, Format, headers, rows); err != nil {
+			fail(err)
+		}
+		return
+	default:
+		data := make([]*object, len(rows))
 		for i, row := range rows {
-			m := make(map[string]string)
+			obj := &object{values: map[string]any{}}
 			for j, h := range headers {
 				if j < len(row) {
-					m[h] = row[j]
+					obj.set(h, row[j])
 				}
 			}
-			data[i] = m
+			data[i] = obj
+		}
+		var err error
+		if Format == FormatTemplate {
+			err = executeTemplate(Stdout, rowMaps(headers, rows))
+		} else {
+			err = encodeTree(Stdout, Format, toList(data))
+		}
+		if err != nil {
+			fail(err)
 		}
-		PrintJSON(data)
 		return
 	}
+	writeTable(Stdout, headers, rows)
+}
 
-	w := tabwriter.NewWriter(Stdout, 0, 0, 2, ' ', 0)
+func writeTable(out io.Writer, headers []string, rows [][]string) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
 	for i, h := range headers {
 		if i > 0 {
 			fmt.Fprint(w, "\t")
@@ -74,3 +128,7 @@ func Error(format string, args ...any) {
 func Success(format string, args ...any) {
 	fmt.Fprintf(Stdout, format+"\n", args...)
 }
+
+func Warn(format string, args ...any) {
+	fmt.Fprintf(Stderr, "warning: "+format+"\n", args...)
+}
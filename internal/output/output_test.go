@@ -36,7 +36,7 @@ func TestPrintJSON(t *testing.T) {
 func TestPrintTable(t *testing.T) {
 	var buf bytes.Buffer
 	Stdout = &buf
-	JSONOutput = false
+	Format = FormatTable
 
 	headers := []string{"NAME", "VALUE"}
 	rows := [][]string{
@@ -58,7 +58,7 @@ func TestPrintTable(t *testing.T) {
 func TestPrintTableJSON(t *testing.T) {
 	var buf bytes.Buffer
 	Stdout = &buf
-	JSONOutput = true
+	Format = FormatJSON
 
 	headers := []string{"name", "value"}
 	rows := [][]string{
@@ -75,7 +75,7 @@ func TestPrintTableJSON(t *testing.T) {
 		t.Errorf("PrintTable() JSON = %v, want [{name:foo value:bar}]", got)
 	}
 
-	JSONOutput = false
+	Format = FormatTable
 }
 
 func TestError(t *testing.T) {
@@ -89,6 +89,17 @@ func TestError(t *testing.T) {
 	}
 }
 
+func TestWarn(t *testing.T) {
+	var buf bytes.Buffer
+	Stderr = &buf
+
+	Warn("keyring %s", "unavailable")
+
+	if got := buf.String(); got != "warning: keyring unavailable\n" {
+		t.Errorf("Warn() = %q, want %q", got, "warning: keyring unavailable\n")
+	}
+}
+
 func TestSuccess(t *testing.T) {
 	var buf bytes.Buffer
 	Stdout = &buf
@@ -0,0 +1,970 @@
+// Package porkbuntest provides an in-memory fake of the Porkbun v3 API for
+// tests that must run offline.
+//
+// The fake implements the endpoints used by api.Client with Porkbun's wire
+// format and error messages, and can inject failures per endpoint:
+//
+//	srv := porkbuntest.NewServer()
+//	defer srv.Close()
+//	srv.AddDomain(porkbuntest.Domain{Name: "example.com"})
+//	client := api.NewClient(srv.Config())
+package porkbuntest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OverseedAI/overpork/internal/config"
+)
+
+// Credentials accepted by a new Server.
+const (
+	APIKey    = "pk1_test"
+	SecretKey = "sk1_test"
+)
+
+// DefaultPageSize is how many domains /domain/listAll returns per page.
+const DefaultPageSize = 1000
+
+// prefix is the path under which the API is served, as on api.porkbun.com.
+const prefix = "/api/json/v3"
+
+// minTTL is Porkbun's lowest accepted TTL; smaller values are raised.
+const minTTL = 600
+
+// Record is a DNS record in Porkbun's wire format.
+type Record struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Content string `json:"content"`
+	TTL     string `json:"ttl"`
+	Prio    string `json:"prio"`
+	Notes   string `json:"notes"`
+}
+
+// Forward is a URL forward.
+type Forward struct {
+	ID          string `json:"id"`
+	Subdomain   string `json:"subdomain"`
+	Location    string `json:"location"`
+	Type        string `json:"type"`
+	IncludePath string `json:"includePath"`
+	Wildcard    string `json:"wildcard"`
+}
+
+// Glue is a glue record for a nameserver host under the domain.
+type Glue struct {
+	Subdomain string   `json:"subdomain"`
+	IPs       []string `json:"ips"`
+}
+
+// DNSSEC is a DS record registered at the registry.
+type DNSSEC struct {
+	KeyTag     string `json:"keyTag"`
+	Algorithm  string `json:"algorithm"`
+	DigestType string `json:"digestType"`
+	Digest     string `json:"digest"`
+	PublicKey  string `json:"publicKey,omitempty"`
+	Flags      string `json:"flags,omitempty"`
+}
+
+// SSL is a certificate bundle.
+type SSL struct {
+	IntermediateCertificate string `json:"intermediatecertificate"`
+	CertificateChain        string `json:"certificatechain"`
+	PrivateKey              string `json:"privatekey"`
+	PublicKey               string `json:"publickey"`
+}
+
+// Pricing is the price list entry of a TLD.
+type Pricing struct {
+	Registration string `json:"registration"`
+	Renewal      string `json:"renewal"`
+	Transfer     string `json:"transfer"`
+}
+
+// Domain is a domain in the fake account. Zero values get defaults from
+// AddDomain.
+type Domain struct {
+	Name         string
+	Status       string    // default "ACTIVE"
+	CreateDate   time.Time // default now
+	ExpireDate   time.Time // default one year after CreateDate
+	AutoRenew    bool
+	SecurityLock bool
+	WhoisPrivacy bool
+	// NoAPIAccess makes every domain-specific call fail as if API access
+	// was never enabled for the domain.
+	NoAPIAccess bool
+	Nameservers []string
+	SSL         *SSL
+
+	records  []Record
+	forwards []Forward
+	glue     []Glue
+	dnssec   []DNSSEC
+}
+
+// Failure makes matching requests fail instead of being handled.
+type Failure struct {
+	// Endpoint is matched as a prefix of the path after /api/json/v3, e.g.
+	// "/dns/create". Empty matches every request.
+	Endpoint string
+	// Status is the HTTP status to return (default 400 with Message, 500
+	// without).
+	Status int
+	// Message, if set, is returned as a Porkbun {"status":"ERROR"} body.
+	// Otherwise Body is sent as is.
+	Message string
+	Body    string
+	// RetryAfter sets the Retry-After header.
+	RetryAfter string
+	// Drop closes the connection without a response.
+	Drop bool
+	// Times is how many requests fail before the failure expires (0 fails
+	// until ClearFailures).
+	Times int
+}
+
+// Request is a logged API call.
+type Request struct {
+	Endpoint string
+	Body     map[string]any
+}
+
+// Server is a running fake. It is safe for concurrent use.
+type Server struct {
+	srv *httptest.Server
+
+	// URL is the API base URL to configure the client with.
+	URL string
+
+	mu        sync.Mutex
+	apiKey    string
+	secretKey string
+	pageSize  int
+	domains   map[string]*Domain
+	pricing   map[string]Pricing
+	failures  []*Failure
+	requests  []Request
+	nextID    int64
+}
+
+// NewServer starts a fake with no domains and pricing for a few TLDs.
+func NewServer() *Server {
+	s := &Server{
+		apiKey:    APIKey,
+		secretKey: SecretKey,
+		pageSize:  DefaultPageSize,
+		domains:   map[string]*Domain{},
+		pricing: map[string]Pricing{
+			"com": {Registration: "10.37", Renewal: "10.37", Transfer: "10.37"},
+			"net": {Registration: "11.52", Renewal: "11.52", Transfer: "11.52"},
+			"org": {Registration: "6.88", Renewal: "10.74", Transfer: "10.74"},
+			"dev": {Registration: "10.81", Renewal: "10.81", Transfer: "10.81"},
+		},
+		nextID: 100000000,
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL + prefix
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Config returns a client configuration pointing at the fake with valid
+// credentials and no retries.
+func (s *Server) Config() *config.Config {
+	return &config.Config{
+		APIKey:     s.apiKey,
+		SecretKey:  s.secretKey,
+		BaseURL:    s.URL,
+		PricingURL: s.URL,
+		RateBurst:  config.DefaultRateBurst,
+	}
+}
+
+// SetCredentials changes the accepted API key pair.
+func (s *Server) SetCredentials(apiKey, secretKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apiKey, s.secretKey = apiKey, secretKey
+}
+
+// SetPageSize changes how many domains /domain/listAll returns per page.
+func (s *Server) SetPageSize(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pageSize = n
+}
+
+// AddDomain adds a domain to the account, replacing any existing one.
+func (s *Server) AddDomain(d Domain) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addDomain(d)
+}
+
+func (s *Server) addDomain(d Domain) *Domain {
+	d.Name = strings.ToLower(d.Name)
+	if d.Status == "" {
+		d.Status = "ACTIVE"
+	}
+	if d.CreateDate.IsZero() {
+		d.CreateDate = time.Now().UTC().Truncate(time.Second)
+	}
+	if d.ExpireDate.IsZero() {
+		d.ExpireDate = d.CreateDate.AddDate(1, 0, 0)
+	}
+	if d.Nameservers == nil {
+		d.Nameservers = []string{"curitiba.ns.porkbun.com", "fortaleza.ns.porkbun.com", "maceio.ns.porkbun.com", "salvador.ns.porkbun.com"}
+	}
+	p := &d
+	s.domains[d.Name] = p
+	return p
+}
+
+// AddRecord adds a DNS record to a domain and returns its ID. Name is
+// relative to the domain ("" for the apex).
+func (s *Server) AddRecord(domain string, r Record) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.domains[strings.ToLower(domain)]
+	if !ok {
+		panic("porkbuntest: unknown domain " + domain)
+	}
+	r.Name = fqdn(r.Name, d.Name)
+	r.Type = strings.ToUpper(r.Type)
+	r.TTL = normalizeTTL(r.TTL)
+	if r.Prio == "" {
+		r.Prio = "0"
+	}
+	if r.ID == "" {
+		r.ID = s.newID()
+	}
+	d.records = append(d.records, r)
+	return r.ID
+}
+
+// Records returns a copy of a domain's DNS records.
+func (s *Server) Records(domain string) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d, ok := s.domains[strings.ToLower(domain)]; ok {
+		return slices.Clone(d.records)
+	}
+	return nil
+}
+
+// SetPricing sets the price list entry for a TLD.
+func (s *Server) SetPricing(tld string, p Pricing) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pricing[strings.ToLower(tld)] = p
+}
+
+// Fail injects a failure. Failures are checked in the order added.
+func (s *Server) Fail(f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, &f)
+}
+
+// ClearFailures removes all injected failures.
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = nil
+}
+
+// Requests returns the calls received so far, including failed ones.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+func (s *Server) newID() string {
+	s.nextID++
+	return strconv.FormatInt(s.nextID, 10)
+}
+
+// apiError is a Porkbun error response.
+type apiError struct {
+	status  int
+	message string
+}
+
+func errorf(format string, args ...any) *apiError {
+	return &apiError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+var (
+	errInvalidKey    = &apiError{http.StatusForbidden, "Invalid API key. (002)"}
+	errInvalidDomain = errorf("Invalid domain.")
+	errNotOptedIn    = errorf("Domain is not opted in to API access.")
+	errInvalidID     = errorf("Invalid record ID.")
+)
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	endpoint, ok := strings.CutPrefix(r.URL.Path, prefix)
+	if !ok || r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+
+	data, _ := io.ReadAll(r.Body)
+	body := map[string]any{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &body); err != nil {
+			writeError(w, errorf("Invalid JSON."))
+			return
+		}
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Endpoint: endpoint, Body: body})
+	if f := s.takeFailure(endpoint); f != nil {
+		s.mu.Unlock()
+		writeFailure(w, f)
+		return
+	}
+	resp, apiErr := s.handle(endpoint, body)
+	s.mu.Unlock()
+
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	resp["status"] = "SUCCESS"
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) takeFailure(endpoint string) *Failure {
+	for i, f := range s.failures {
+		if !strings.HasPrefix(endpoint, f.Endpoint) {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.failures = slices.Delete(s.failures, i, i+1)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+func writeFailure(w http.ResponseWriter, f *Failure) {
+	if f.Drop {
+		if hj, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				conn.Close()
+				return
+			}
+		}
+	}
+	if f.RetryAfter != "" {
+		w.Header().Set("Retry-After", f.RetryAfter)
+	}
+	status := f.Status
+	if f.Message != "" {
+		if status == 0 {
+			status = http.StatusBadRequest
+		}
+		writeJSON(w, status, map[string]any{"status": "ERROR", "message": f.Message})
+		return
+	}
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(f.Body))
+}
+
+func writeError(w http.ResponseWriter, e *apiError) {
+	writeJSON(w, e.status, map[string]any{"status": "ERROR", "message": e.message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// handle dispatches an authenticated call. It runs with s.mu held.
+func (s *Server) handle(endpoint string, body map[string]any) (map[string]any, *apiError) {
+	parts := strings.Split(strings.Trim(endpoint, "/"), "/")
+	route := parts[0]
+	if len(parts) > 1 {
+		route += "/" + parts[1]
+	}
+	args := parts[min(2, len(parts)):]
+
+	// Endpoints that need no credentials.
+	switch route {
+	case "pricing/get":
+		return s.pricingGet()
+	case "domain/checkDomain":
+		return s.checkDomain(args)
+	}
+
+	if str(body, "apikey") != s.apiKey || str(body, "secretapikey") != s.secretKey {
+		return nil, errInvalidKey
+	}
+
+	switch route {
+	case "ping":
+		return map[string]any{"yourIp": "127.0.0.1"}, nil
+	case "domain/listAll":
+		return s.listAll(body)
+	case "domain/create":
+		return s.register(args, body)
+	}
+
+	if len(args) == 0 {
+		return nil, errInvalidDomain
+	}
+	d, ok := s.domains[strings.ToLower(args[0])]
+	if !ok {
+		return nil, errInvalidDomain
+	}
+	if d.NoAPIAccess {
+		return nil, errNotOptedIn
+	}
+	args = args[1:]
+
+	switch route {
+	case "dns/retrieve":
+		return s.dnsRetrieve(d, args)
+	case "dns/retrieveByNameType":
+		return s.dnsRetrieveByNameType(d, args)
+	case "dns/create":
+		return s.dnsCreate(d, body)
+	case "dns/edit":
+		return s.dnsEdit(d, args, body)
+	case "dns/editByNameType":
+		return s.dnsEditByNameType(d, args, body)
+	case "dns/delete":
+		return s.dnsDelete(d, args)
+	case "dns/deleteByNameType":
+		return s.dnsDeleteByNameType(d, args)
+	case "dns/getDnssecRecords":
+		return map[string]any{"records": nonNil(d.dnssec)}, nil
+	case "dns/createDnssecRecord":
+		return s.dnssecCreate(d, body)
+	case "dns/deleteDnssecRecord":
+		return s.dnssecDelete(d, args)
+	case "domain/getDomain":
+		return domainJSON(d, "domainStatus"), nil
+	case "domain/updateNs":
+		return s.updateNS(d, body)
+	case "domain/getNs":
+		return map[string]any{"ns": d.Nameservers}, nil
+	case "domain/updateAutoRenew":
+		return s.updateAutoRenew(d, body)
+	case "domain/addUrlForward":
+		return s.addForward(d, body)
+	case "domain/getUrlForwarding":
+		return map[string]any{"forwards": nonNil(d.forwards)}, nil
+	case "domain/deleteUrlForward":
+		return s.deleteForward(d, args)
+	case "domain/getGlue":
+		return map[string]any{"records": nonNil(d.glue)}, nil
+	case "domain/createGlue", "domain/updateGlue":
+		return s.setGlue(d, args, body, route == "domain/createGlue")
+	case "domain/deleteGlue":
+		return s.deleteGlue(d, args)
+	case "ssl/retrieve":
+		if d.SSL == nil {
+			return nil, errorf("The SSL certificate is not ready for this domain.")
+		}
+		return map[string]any{
+			"intermediatecertificate": d.SSL.IntermediateCertificate,
+			"certificatechain":        d.SSL.CertificateChain,
+			"privatekey":              d.SSL.PrivateKey,
+			"publickey":               d.SSL.PublicKey,
+		}, nil
+	}
+	return nil, errorf("Invalid endpoint.")
+}
+
+func (s *Server) pricingGet() (map[string]any, *apiError) {
+	pricing := map[string]any{}
+	for tld, p := range s.pricing {
+		pricing[tld] = map[string]any{
+			"registration": p.Registration,
+			"renewal":      p.Renewal,
+			"transfer":     p.Transfer,
+			"coupons":      []any{},
+		}
+	}
+	return map[string]any{"pricing": pricing}, nil
+}
+
+func (s *Server) checkDomain(args []string) (map[string]any, *apiError) {
+	if len(args) == 0 {
+		return nil, errInvalidDomain
+	}
+	name := strings.ToLower(args[0])
+	p, ok := s.pricing[tldOf(name)]
+	if !ok {
+		return nil, errorf("TLD not supported.")
+	}
+	avail := "yes"
+	if _, taken := s.domains[name]; taken {
+		avail = "no"
+	}
+	return map[string]any{"avail": avail, "price": p.Registration}, nil
+}
+
+func (s *Server) listAll(body map[string]any) (map[string]any, *apiError) {
+	names := make([]string, 0, len(s.domains))
+	for name := range s.domains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	start := 0
+	if v, ok := body["start"]; ok {
+		n, err := strconv.Atoi(fmt.Sprint(v))
+		if err != nil || n < 0 {
+			return nil, errorf("Invalid start value.")
+		}
+		start = n
+	}
+
+	domains := []any{}
+	for i := start; i < len(names) && i < start+s.pageSize; i++ {
+		domains = append(domains, domainJSON(s.domains[names[i]], "status"))
+	}
+	return map[string]any{"domains": domains}, nil
+}
+
+func domainJSON(d *Domain, statusKey string) map[string]any {
+	return map[string]any{
+		"domain":       d.Name,
+		statusKey:      d.Status,
+		"tld":          tldOf(d.Name),
+		"createDate":   d.CreateDate.Format(time.DateTime),
+		"expireDate":   d.ExpireDate.Format(time.DateTime),
+		"securityLock": boolString(d.SecurityLock),
+		"whoisPrivacy": boolString(d.WhoisPrivacy),
+		"autoRenew":    boolString(d.AutoRenew),
+		"notLocal":     0,
+	}
+}
+
+func (s *Server) register(args []string, body map[string]any) (map[string]any, *apiError) {
+	if len(args) == 0 {
+		return nil, errInvalidDomain
+	}
+	name := strings.ToLower(args[0])
+	if _, ok := s.pricing[tldOf(name)]; !ok {
+		return nil, errorf("TLD not supported.")
+	}
+	if _, taken := s.domains[name]; taken {
+		return nil, errorf("Domain is not available.")
+	}
+	years := 1
+	if v, ok := body["years"]; ok {
+		n, err := strconv.Atoi(fmt.Sprint(v))
+		if err != nil || n < 1 || n > 10 {
+			return nil, errorf("Invalid number of years.")
+		}
+		years = n
+	}
+
+	d := Domain{Name: name, AutoRenew: str(body, "autoRenew") == "yes", WhoisPrivacy: str(body, "whoisPrivacy") == "yes"}
+	d.CreateDate = time.Now().UTC().Truncate(time.Second)
+	d.ExpireDate = d.CreateDate.AddDate(years, 0, 0)
+	if ns := strs(body, "ns"); len(ns) > 0 {
+		d.Nameservers = ns
+	}
+	s.addDomain(d)
+	return map[string]any{"domain": name}, nil
+}
+
+func (s *Server) dnsRetrieve(d *Domain, args []string) (map[string]any, *apiError) {
+	if len(args) == 0 {
+		return map[string]any{"records": nonNil(d.records)}, nil
+	}
+	for _, r := range d.records {
+		if r.ID == args[0] {
+			return map[string]any{"records": []Record{r}}, nil
+		}
+	}
+	return map[string]any{"records": []Record{}}, nil
+}
+
+// nameType parses the {type}/[{subdomain}] arguments of the ByNameType
+// endpoints.
+func nameType(d *Domain, args []string) (string, string, *apiError) {
+	if len(args) == 0 || !validType(args[0]) {
+		return "", "", errorf("Invalid type.")
+	}
+	sub := ""
+	if len(args) > 1 {
+		sub = args[1]
+	}
+	return strings.ToUpper(args[0]), fqdn(sub, d.Name), nil
+}
+
+func (s *Server) dnsRetrieveByNameType(d *Domain, args []string) (map[string]any, *apiError) {
+	recordType, name, err := nameType(d, args)
+	if err != nil {
+		return nil, err
+	}
+	records := []Record{}
+	for _, r := range d.records {
+		if r.Type == recordType && (len(args) < 2 || r.Name == name) {
+			records = append(records, r)
+		}
+	}
+	return map[string]any{"records": records}, nil
+}
+
+// recordFromBody validates the fields of a create or edit request.
+func recordFromBody(d *Domain, body map[string]any) (Record, *apiError) {
+	r := Record{
+		Name:    fqdn(str(body, "name"), d.Name),
+		Type:    strings.ToUpper(str(body, "type")),
+		Content: str(body, "content"),
+		TTL:     normalizeTTL(str(body, "ttl")),
+		Prio:    str(body, "prio"),
+		Notes:   str(body, "notes"),
+	}
+	if r.Type == "" || !validType(r.Type) {
+		return r, errorf("Invalid type.")
+	}
+	if r.Content == "" {
+		return r, errorf("Content is required.")
+	}
+	if r.Prio == "" {
+		r.Prio = "0"
+	} else if _, err := strconv.Atoi(r.Prio); err != nil {
+		return r, errorf("Invalid priority.")
+	}
+	if _, err := strconv.Atoi(r.TTL); err != nil {
+		return r, errorf("Invalid TTL.")
+	}
+	return r, nil
+}
+
+// conflicts reports whether adding r would break CNAME exclusivity.
+func conflicts(d *Domain, r Record, skipID string) bool {
+	for _, e := range d.records {
+		if e.ID == skipID || e.Name != r.Name {
+			continue
+		}
+		if r.Type == "CNAME" || e.Type == "CNAME" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) dnsCreate(d *Domain, body map[string]any) (map[string]any, *apiError) {
+	r, err := recordFromBody(d, body)
+	if err != nil {
+		return nil, err
+	}
+	if conflicts(d, r, "") {
+		return nil, errorf("Could not add DNS record: a CNAME record cannot coexist with other records of the same name.")
+	}
+	id := s.newID()
+	r.ID = id
+	d.records = append(d.records, r)
+	n, _ := strconv.ParseInt(id, 10, 64)
+	return map[string]any{"id": n}, nil
+}
+
+func (s *Server) dnsEdit(d *Domain, args []string, body map[string]any) (map[string]any, *apiError) {
+	if len(args) == 0 {
+		return nil, errInvalidID
+	}
+	for i, e := range d.records {
+		if e.ID != args[0] {
+			continue
+		}
+		r, err := recordFromBody(d, body)
+		if err != nil {
+			return nil, err
+		}
+		if conflicts(d, r, e.ID) {
+			return nil, errorf("Could not edit DNS record: a CNAME record cannot coexist with other records of the same name.")
+		}
+		r.ID = e.ID
+		d.records[i] = r
+		return map[string]any{}, nil
+	}
+	return nil, errInvalidID
+}
+
+func (s *Server) dnsEditByNameType(d *Domain, args []string, body map[string]any) (map[string]any, *apiError) {
+	recordType, name, err := nameType(d, args)
+	if err != nil {
+		return nil, err
+	}
+	content := str(body, "content")
+	if content == "" {
+		return nil, errorf("Content is required.")
+	}
+	found := false
+	for i, r := range d.records {
+		if r.Type != recordType || r.Name != name {
+			continue
+		}
+		found = true
+		d.records[i].Content = content
+		if ttl := str(body, "ttl"); ttl != "" {
+			d.records[i].TTL = normalizeTTL(ttl)
+		}
+		if prio := str(body, "prio"); prio != "" {
+			d.records[i].Prio = prio
+		}
+	}
+	if !found {
+		return nil, errorf("Could not find any records to edit.")
+	}
+	return map[string]any{}, nil
+}
+
+func (s *Server) dnsDelete(d *Domain, args []string) (map[string]any, *apiError) {
+	if len(args) == 0 {
+		return nil, errInvalidID
+	}
+	i := slices.IndexFunc(d.records, func(r Record) bool { return r.ID == args[0] })
+	if i < 0 {
+		return nil, errInvalidID
+	}
+	d.records = slices.Delete(d.records, i, i+1)
+	return map[string]any{}, nil
+}
+
+func (s *Server) dnsDeleteByNameType(d *Domain, args []string) (map[string]any, *apiError) {
+	recordType, name, err := nameType(d, args)
+	if err != nil {
+		return nil, err
+	}
+	n := len(d.records)
+	d.records = slices.DeleteFunc(d.records, func(r Record) bool { return r.Type == recordType && r.Name == name })
+	if len(d.records) == n {
+		return nil, errorf("Could not find any records to delete.")
+	}
+	return map[string]any{}, nil
+}
+
+func (s *Server) dnssecCreate(d *Domain, body map[string]any) (map[string]any, *apiError) {
+	rec := DNSSEC{
+		KeyTag:     str(body, "keyTag"),
+		Algorithm:  str(body, "algorithm"),
+		DigestType: str(body, "digestType"),
+		Digest:     str(body, "digest"),
+		PublicKey:  str(body, "publicKey"),
+		Flags:      str(body, "flags"),
+	}
+	if rec.KeyTag == "" || rec.Algorithm == "" || rec.DigestType == "" || rec.Digest == "" {
+		return nil, errorf("keyTag, algorithm, digestType and digest are required.")
+	}
+	if slices.ContainsFunc(d.dnssec, func(e DNSSEC) bool { return e.KeyTag == rec.KeyTag }) {
+		return nil, errorf("A DS record with this key tag already exists.")
+	}
+	d.dnssec = append(d.dnssec, rec)
+	return map[string]any{}, nil
+}
+
+func (s *Server) dnssecDelete(d *Domain, args []string) (map[string]any, *apiError) {
+	if len(args) == 0 {
+		return nil, errorf("Key tag is required.")
+	}
+	i := slices.IndexFunc(d.dnssec, func(e DNSSEC) bool { return e.KeyTag == args[0] })
+	if i < 0 {
+		return nil, errorf("Could not find a DS record with that key tag.")
+	}
+	d.dnssec = slices.Delete(d.dnssec, i, i+1)
+	return map[string]any{}, nil
+}
+
+func (s *Server) updateNS(d *Domain, body map[string]any) (map[string]any, *apiError) {
+	ns := strs(body, "ns")
+	if len(ns) == 0 {
+		return nil, errorf("At least one nameserver is required.")
+	}
+	d.Nameservers = ns
+	return map[string]any{}, nil
+}
+
+func (s *Server) updateAutoRenew(d *Domain, body map[string]any) (map[string]any, *apiError) {
+	switch str(body, "autoRenew") {
+	case "enable":
+		d.AutoRenew = true
+	case "disable":
+		d.AutoRenew = false
+	default:
+		return nil, errorf("autoRenew must be enable or disable.")
+	}
+	return map[string]any{}, nil
+}
+
+func (s *Server) addForward(d *Domain, body map[string]any) (map[string]any, *apiError) {
+	f := Forward{
+		ID:          s.newID(),
+		Subdomain:   str(body, "subdomain"),
+		Location:    str(body, "location"),
+		Type:        str(body, "type"),
+		IncludePath: orDefault(str(body, "includePath"), "no"),
+		Wildcard:    orDefault(str(body, "wildcard"), "no"),
+	}
+	if f.Location == "" {
+		return nil, errorf("Location is required.")
+	}
+	if f.Type == "" {
+		f.Type = "temporary"
+	} else if f.Type != "temporary" && f.Type != "permanent" {
+		return nil, errorf("Type must be temporary or permanent.")
+	}
+	d.forwards = append(d.forwards, f)
+	return map[string]any{}, nil
+}
+
+func (s *Server) deleteForward(d *Domain, args []string) (map[string]any, *apiError) {
+	if len(args) == 0 {
+		return nil, errInvalidID
+	}
+	i := slices.IndexFunc(d.forwards, func(f Forward) bool { return f.ID == args[0] })
+	if i < 0 {
+		return nil, errInvalidID
+	}
+	d.forwards = slices.Delete(d.forwards, i, i+1)
+	return map[string]any{}, nil
+}
+
+func (s *Server) setGlue(d *Domain, args []string, body map[string]any, create bool) (map[string]any, *apiError) {
+	if len(args) == 0 || args[0] == "" {
+		return nil, errorf("Subdomain is required.")
+	}
+	ips := strs(body, "ip")
+	if len(ips) == 0 {
+		return nil, errorf("At least one IP address is required.")
+	}
+	sub := strings.ToLower(args[0])
+	i := slices.IndexFunc(d.glue, func(g Glue) bool { return g.Subdomain == sub })
+	switch {
+	case create && i >= 0:
+		return nil, errorf("Glue record already exists.")
+	case !create && i < 0:
+		return nil, errorf("Could not find glue record.")
+	case create:
+		d.glue = append(d.glue, Glue{Subdomain: sub, IPs: ips})
+	default:
+		d.glue[i].IPs = ips
+	}
+	return map[string]any{}, nil
+}
+
+func (s *Server) deleteGlue(d *Domain, args []string) (map[string]any, *apiError) {
+	if len(args) == 0 {
+		return nil, errorf("Subdomain is required.")
+	}
+	i := slices.IndexFunc(d.glue, func(g Glue) bool { return g.Subdomain == strings.ToLower(args[0]) })
+	if i < 0 {
+		return nil, errorf("Could not find glue record.")
+	}
+	d.glue = slices.Delete(d.glue, i, i+1)
+	return map[string]any{}, nil
+}
+
+var recordTypes = []string{"A", "MX", "CNAME", "ALIAS", "TXT", "NS", "AAAA", "SRV", "TLSA", "CAA", "HTTPS", "SVCB", "SSHFP"}
+
+func validType(t string) bool {
+	return slices.Contains(recordTypes, strings.ToUpper(t))
+}
+
+func fqdn(sub, domain string) string {
+	sub = strings.ToLower(strings.TrimSuffix(sub, "."))
+	if sub == "" || sub == "@" || sub == domain {
+		return domain
+	}
+	if strings.HasSuffix(sub, "."+domain) {
+		return sub
+	}
+	return sub + "." + domain
+}
+
+func normalizeTTL(ttl string) string {
+	if ttl == "" {
+		return strconv.Itoa(minTTL)
+	}
+	if n, err := strconv.Atoi(ttl); err == nil && n < minTTL {
+		return strconv.Itoa(minTTL)
+	}
+	return ttl
+}
+
+func tldOf(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+func boolString(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+// nonNil keeps empty lists as [] rather than null in responses.
+func nonNil[T any](v []T) []T {
+	if v == nil {
+		return []T{}
+	}
+	return v
+}
+
+func str(body map[string]any, key string) string {
+	switch v := body[key].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func strs(body map[string]any, key string) []string {
+	list, _ := body[key].([]any)
+	var out []string
+	for _, v := range list {
+		if s, ok := v.(string); ok && s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
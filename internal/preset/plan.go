@@ -0,0 +1,99 @@
+package preset
+
+import (
+	"strings"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"github.com/OverseedAI/overpork/internal/zone"
+)
+
+// Conflict is a live record that would clash with a preset record, such as
+// an MX of another provider or a second SPF policy.
+type Conflict struct {
+	ID     string      `json:"id"`
+	Record zone.Record `json:"record"`
+	Reason string      `json:"reason"`
+}
+
+// Plan works out the records to create on domain. Records that already
+// exist are skipped. Live records that conflict with the preset are
+// returned; with replace they are also deleted by the plan.
+func Plan(domain string, records []zone.Record, live []api.DNSRecord, replace bool) (*zone.Plan, []Conflict) {
+	plan := &zone.Plan{Domain: domain, Changes: []zone.Change{}}
+	current := zone.FromAPI(domain, live)
+
+	var conflicts []Conflict
+	for i, l := range current {
+		if contains(records, l) {
+			continue
+		}
+		for _, r := range records {
+			if reason := conflict(r, l); reason != "" {
+				conflicts = append(conflicts, Conflict{ID: live[i].ID, Record: l, Reason: reason})
+				if replace {
+					before := l
+					plan.Changes = append(plan.Changes, zone.Change{Action: zone.ActionDelete, ID: live[i].ID, Before: &before})
+				}
+				break
+			}
+		}
+	}
+
+	for _, r := range records {
+		if contains(current, r) {
+			continue
+		}
+		after := r
+		plan.Changes = append(plan.Changes, zone.Change{Action: zone.ActionCreate, After: &after})
+	}
+	return plan, conflicts
+}
+
+// conflict returns why live clashes with the preset record r, or "".
+func conflict(r, live zone.Record) string {
+	if r.Name != live.Name {
+		return ""
+	}
+	switch {
+	case r.Type == "CNAME" || live.Type == "CNAME":
+		return "a CNAME cannot coexist with other records"
+	case r.Type == "MX" && live.Type == "MX":
+		return "existing MX record"
+	case r.Type == "TXT" && live.Type == "TXT":
+		if kind := txtKind(r.Content); kind != "" && kind == txtKind(live.Content) {
+			return "existing " + kind + " record"
+		}
+	}
+	return ""
+}
+
+// txtKind recognizes TXT records of which a name may only have one.
+func txtKind(content string) string {
+	v := strings.ToLower(strings.TrimLeft(content, "\" "))
+	switch {
+	case strings.HasPrefix(v, "v=spf1"):
+		return "SPF"
+	case strings.HasPrefix(v, "v=dmarc1"):
+		return "DMARC"
+	case strings.HasPrefix(v, "v=dkim1"):
+		return "DKIM"
+	}
+	return ""
+}
+
+func contains(records []zone.Record, r zone.Record) bool {
+	for _, c := range records {
+		if c.Name == r.Name && c.Type == r.Type && sameContent(c.Type, c.Content, r.Content) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameContent(recordType, a, b string) bool {
+	switch recordType {
+	case "CNAME", "ALIAS", "MX", "NS", "SRV":
+		return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+	}
+	return strings.Trim(a, "\"") == strings.Trim(b, "\"")
+}
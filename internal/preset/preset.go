@@ -0,0 +1,222 @@
+// Package preset provides templates of the DNS records that hosted email
+// providers ask for, and plans adding them to a domain.
+package preset
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/OverseedAI/overpork/internal/zone"
+	"go.yaml.in/yaml/v3"
+)
+
+//go:embed presets/*.yaml
+var builtin embed.FS
+
+// Preset is a named set of records. Record names and content are Go
+// templates that see the domain as {{.domain}} and every parameter by name.
+type Preset struct {
+	Name        string   `yaml:"name" json:"name"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Params      []Param  `yaml:"params,omitempty" json:"params,omitempty"`
+	Records     []Record `yaml:"records" json:"records"`
+	// Source is "builtin" or the path of a user-defined preset.
+	Source string `yaml:"-" json:"source"`
+}
+
+// Param is a value supplied with the preset, such as a DKIM key.
+type Param struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Default     string `yaml:"default,omitempty" json:"default,omitempty"`
+	Required    bool   `yaml:"required,omitempty" json:"required,omitempty"`
+}
+
+// Record is a record template. When names a parameter; the record is only
+// added if that parameter is set.
+type Record struct {
+	zone.Record `yaml:",inline"`
+	When        string `yaml:"when,omitempty" json:"when,omitempty"`
+}
+
+// Builtin returns the presets shipped with overpork.
+func Builtin() ([]*Preset, error) {
+	files, err := fs.Glob(builtin, "presets/*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	var presets []*Preset
+	for _, name := range files {
+		data, err := builtin.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		p, err := Parse(data, strings.TrimSuffix(filepath.Base(name), ".yaml"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		p.Source = "builtin"
+		presets = append(presets, p)
+	}
+	return presets, nil
+}
+
+// Load returns the built-in presets together with the *.yaml and *.yml
+// files in dir. A user-defined preset replaces the built-in one of the
+// same name. A missing dir is not an error.
+func Load(dir string) ([]*Preset, error) {
+	presets, err := Builtin()
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]int, len(presets))
+	for i, p := range presets {
+		byName[p.Name] = i
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read preset directory: %w", err)
+	}
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read preset: %w", err)
+		}
+		p, err := Parse(data, strings.TrimSuffix(e.Name(), ext))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		p.Source = path
+		if i, ok := byName[p.Name]; ok {
+			presets[i] = p
+		} else {
+			byName[p.Name] = len(presets)
+			presets = append(presets, p)
+		}
+	}
+
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets, nil
+}
+
+// Find returns the preset called name, or nil.
+func Find(presets []*Preset, name string) *Preset {
+	for _, p := range presets {
+		if strings.EqualFold(p.Name, name) {
+			return p
+		}
+	}
+	return nil
+}
+
+// Parse parses a YAML preset. name is used when the file doesn't set one.
+func Parse(data []byte, name string) (*Preset, error) {
+	var p Preset
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse preset: %w", err)
+	}
+	if p.Name == "" {
+		p.Name = name
+	}
+
+	params := map[string]bool{"domain": true}
+	for _, param := range p.Params {
+		if param.Name == "" {
+			return nil, fmt.Errorf("parameter without a name")
+		}
+		if params[param.Name] {
+			return nil, fmt.Errorf("duplicate parameter %q", param.Name)
+		}
+		params[param.Name] = true
+	}
+	if len(p.Records) == 0 {
+		return nil, fmt.Errorf("preset has no records")
+	}
+	for i := range p.Records {
+		r := &p.Records[i]
+		r.Type = strings.ToUpper(strings.TrimSpace(r.Type))
+		if r.Type == "" || r.Content == "" {
+			return nil, fmt.Errorf("record %d: type and content are required", i+1)
+		}
+		if r.When != "" && !params[r.When] {
+			return nil, fmt.Errorf("record %d: unknown parameter %q in when", i+1, r.When)
+		}
+	}
+	return &p, nil
+}
+
+// Render fills in the record templates for domain. values may only name
+// declared parameters; defaults apply to the ones left out.
+func (p *Preset) Render(domain string, values map[string]string) ([]zone.Record, error) {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	data := map[string]string{"domain": domain}
+	declared := map[string]bool{}
+	for _, param := range p.Params {
+		declared[param.Name] = true
+		data[param.Name] = param.Default
+	}
+	for k, v := range values {
+		if !declared[k] {
+			return nil, fmt.Errorf("unknown parameter %q for preset %s", k, p.Name)
+		}
+		data[k] = v
+	}
+	for _, param := range p.Params {
+		if param.Required && data[param.Name] == "" {
+			return nil, fmt.Errorf("preset %s requires parameter %q (%s)", p.Name, param.Name, param.Description)
+		}
+	}
+
+	var records []zone.Record
+	for i, tr := range p.Records {
+		if tr.When != "" && data[tr.When] == "" {
+			continue
+		}
+		r := tr.Record
+		var err error
+		if r.Name, err = render(r.Name, data); err != nil {
+			return nil, fmt.Errorf("record %d name: %w", i+1, err)
+		}
+		if r.Content, err = render(r.Content, data); err != nil {
+			return nil, fmt.Errorf("record %d content: %w", i+1, err)
+		}
+		r.Name = strings.ToLower(strings.TrimSpace(r.Name))
+		if r.Name == "@" {
+			r.Name = ""
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+var funcs = template.FuncMap{
+	"dashes": func(s string) string { return strings.ReplaceAll(s, ".", "-") },
+}
+
+func render(text string, data map[string]string) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	t, err := template.New("").Option("missingkey=error").Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,175 @@
+package preset
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"github.com/OverseedAI/overpork/internal/zone"
+)
+
+func TestBuiltin(t *testing.T) {
+	presets, err := Builtin()
+	if err != nil {
+		t.Fatalf("Builtin() error = %v", err)
+	}
+	for _, name := range []string{"google-workspace", "microsoft-365", "fastmail", "proton", "ses"} {
+		p := Find(presets, name)
+		if p == nil {
+			t.Errorf("missing built-in preset %s", name)
+			continue
+		}
+		values := map[string]string{}
+		for _, param := range p.Params {
+			if param.Required {
+				values[param.Name] = "x1"
+			}
+		}
+		records, err := p.Render("example.com", values)
+		if err != nil {
+			t.Errorf("%s: Render() error = %v", name, err)
+			continue
+		}
+		for _, r := range records {
+			if strings.Contains(r.Name+r.Content, "{{") || strings.Contains(r.Content, "<no value>") {
+				t.Errorf("%s: unrendered record %+v", name, r)
+			}
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	p, err := Parse([]byte(`
+params:
+  - name: selector
+    default: google
+  - name: key
+  - name: code
+    required: true
+records:
+  - type: TXT
+    content: verify={{.code}}
+  - name: "{{.selector}}._domainkey"
+    type: txt
+    content: v=DKIM1; p={{.key}}
+    when: key
+  - name: "@"
+    type: MX
+    content: "{{dashes .domain}}.mail.example.net"
+    prio: "0"
+`), "custom")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if p.Name != "custom" {
+		t.Errorf("Name = %q, want custom", p.Name)
+	}
+
+	records, err := p.Render("Example.COM", map[string]string{"code": "abc"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if len(records) != 2 || records[0].Content != "verify=abc" || records[1].Name != "" || records[1].Content != "example-com.mail.example.net" {
+		t.Errorf("Render() = %+v", records)
+	}
+
+	records, err = p.Render("example.com", map[string]string{"code": "abc", "key": "MIIB", "selector": "s1"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if len(records) != 3 || records[1].Name != "s1._domainkey" || records[1].Type != "TXT" || records[1].Content != "v=DKIM1; p=MIIB" {
+		t.Errorf("Render() with key = %+v", records)
+	}
+
+	for _, values := range []map[string]string{
+		{},
+		{"code": "abc", "bogus": "1"},
+	} {
+		if _, err := p.Render("example.com", values); err == nil {
+			t.Errorf("Render(%v) succeeded, want error", values)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, data := range []string{
+		"records: []",
+		"records:\n  - type: A\n",
+		"records:\n  - type: A\n    content: 1.2.3.4\n    when: nope\n",
+		"params:\n  - name: a\n  - name: a\nrecords:\n  - type: A\n    content: 1.2.3.4\n",
+	} {
+		if _, err := Parse([]byte(data), "x"); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", data)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("fastmail.yaml", "records:\n  - type: MX\n    content: mx.example.net\n")
+	write("mine.yml", "name: in-house\nrecords:\n  - type: MX\n    content: mx.example.org\n")
+	write("notes.txt", "ignored")
+
+	presets, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if p := Find(presets, "fastmail"); p == nil || p.Source != filepath.Join(dir, "fastmail.yaml") || len(p.Records) != 1 {
+		t.Errorf("fastmail not overridden: %+v", p)
+	}
+	if p := Find(presets, "in-house"); p == nil {
+		t.Error("user preset in-house not loaded")
+	}
+	if p := Find(presets, "proton"); p == nil || p.Source != "builtin" {
+		t.Errorf("proton = %+v, want builtin", p)
+	}
+
+	if _, err := Load(filepath.Join(dir, "missing")); err != nil {
+		t.Errorf("Load(missing dir) error = %v", err)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	records := []zone.Record{
+		{Type: "MX", Content: "in1.example.net", Prio: "10"},
+		{Type: "MX", Content: "in2.example.net", Prio: "20"},
+		{Type: "TXT", Content: "v=spf1 include:example.net ~all"},
+		{Name: "_dmarc", Type: "TXT", Content: "v=DMARC1; p=none"},
+		{Name: "autodiscover", Type: "CNAME", Content: "auto.example.net"},
+	}
+	live := []api.DNSRecord{
+		{ID: "1", Name: "example.com", Type: "MX", Content: "IN1.example.net.", Prio: "10"},
+		{ID: "2", Name: "example.com", Type: "MX", Content: "old-mx.example.org", Prio: "5"},
+		{ID: "3", Name: "example.com", Type: "TXT", Content: "v=spf1 include:old.example.org -all"},
+		{ID: "4", Name: "example.com", Type: "TXT", Content: "google-site-verification=abc"},
+		{ID: "5", Name: "autodiscover.example.com", Type: "A", Content: "203.0.113.1"},
+		{ID: "6", Name: "www.example.com", Type: "A", Content: "203.0.113.1"},
+	}
+
+	plan, conflicts := Plan("example.com", records, live, false)
+	var ids []string
+	for _, c := range conflicts {
+		ids = append(ids, c.ID)
+	}
+	if strings.Join(ids, ",") != "2,3,5" {
+		t.Errorf("conflicts = %v, want 2,3,5", ids)
+	}
+	if c, _, d := plan.Counts(); c != 4 || d != 0 {
+		t.Errorf("plan without replace: %d creates, %d deletes; want 4, 0", c, d)
+	}
+
+	plan, _ = Plan("example.com", records, live, true)
+	if c, _, d := plan.Counts(); c != 4 || d != 3 {
+		t.Errorf("plan with replace: %d creates, %d deletes; want 4, 3", c, d)
+	}
+	if plan.Changes[0].Action != zone.ActionDelete {
+		t.Errorf("deletes should come first, got %s", plan.Changes[0].Action)
+	}
+}
@@ -0,0 +1,253 @@
+// Package query filters and sorts lists client-side by the fields of their
+// JSON form, for list endpoints that cannot do it themselves.
+package query
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Operators accepted in filter expressions, longest first so that "<="
+// is not read as "<".
+var operators = []string{"!~", "~=", "!=", "<=", ">=", "=", "<", ">"}
+
+// Filter is one "field op value" condition.
+//
+//	=   equal, or a glob match when value contains * or ?
+//	!=  the negation of =
+//	~=  regular expression match
+//	!~  the negation of ~=
+//	< <= > >=  numeric comparison when both sides are numbers, else string
+//
+// String comparisons ignore case.
+type Filter struct {
+	Field string
+	Op    string
+	Value string
+
+	re *regexp.Regexp
+}
+
+// ParseFilter parses an expression such as "ttl<3600" or "name=mail*".
+func ParseFilter(expr string) (Filter, error) {
+	for i := range expr {
+		for _, op := range operators {
+			if !strings.HasPrefix(expr[i:], op) {
+				continue
+			}
+			f := Filter{
+				Field: strings.TrimSpace(expr[:i]),
+				Op:    op,
+				Value: strings.TrimSpace(expr[i+len(op):]),
+			}
+			if f.Field == "" {
+				return Filter{}, fmt.Errorf("invalid filter %q: missing field name", expr)
+			}
+			if op == "~=" || op == "!~" {
+				re, err := regexp.Compile("(?i)" + f.Value)
+				if err != nil {
+					return Filter{}, fmt.Errorf("invalid filter %q: %w", expr, err)
+				}
+				f.re = re
+			}
+			return f, nil
+		}
+	}
+	return Filter{}, fmt.Errorf("invalid filter %q (expected field, operator and value, e.g. ttl<3600)", expr)
+}
+
+func (f Filter) match(got string) bool {
+	switch f.Op {
+	case "=":
+		return equal(got, f.Value)
+	case "!=":
+		return !equal(got, f.Value)
+	case "~=":
+		return f.re.MatchString(got)
+	case "!~":
+		return !f.re.MatchString(got)
+	}
+	c := compare(got, f.Value)
+	switch f.Op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	}
+	return c >= 0
+}
+
+func equal(got, want string) bool {
+	got, want = strings.ToLower(got), strings.ToLower(want)
+	if strings.ContainsAny(want, "*?[") {
+		ok, err := path.Match(want, got)
+		return err == nil && ok
+	}
+	return got == want
+}
+
+// compare orders numbers numerically and everything else as strings.
+func compare(a, b string) int {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		return cmp.Compare(x, y)
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// SortKey orders by one field, descending when written as "-field".
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+// ParseSort parses a key such as "ttl" or "-expireDate".
+func ParseSort(s string) (SortKey, error) {
+	s = strings.TrimSpace(s)
+	key := SortKey{Field: strings.TrimPrefix(s, "-"), Descending: strings.HasPrefix(s, "-")}
+	if key.Field == "" {
+		return SortKey{}, fmt.Errorf("invalid sort key %q", s)
+	}
+	return key, nil
+}
+
+// Query is a set of filters, all of which must match, and sort keys.
+type Query struct {
+	Filters []Filter
+	Sort    []SortKey
+}
+
+// Parse builds a query from --filter expressions and --sort keys.
+func Parse(filters, sorts []string) (*Query, error) {
+	q := &Query{}
+	for _, expr := range filters {
+		f, err := ParseFilter(expr)
+		if err != nil {
+			return nil, err
+		}
+		q.Filters = append(q.Filters, f)
+	}
+	for _, s := range sorts {
+		key, err := ParseSort(s)
+		if err != nil {
+			return nil, err
+		}
+		q.Sort = append(q.Sort, key)
+	}
+	return q, nil
+}
+
+// Empty reports whether the query leaves a list unchanged.
+func (q *Query) Empty() bool {
+	return len(q.Filters) == 0 && len(q.Sort) == 0
+}
+
+// Apply returns the items that match every filter, sorted by the sort
+// keys. Fields are looked up by JSON name, ignoring case; a field no item
+// has is an error.
+func Apply[T any](q *Query, items []T) ([]T, error) {
+	if q.Empty() {
+		return items, nil
+	}
+
+	type row struct {
+		item   T
+		fields map[string]string
+	}
+	rows := make([]row, 0, len(items))
+	known := map[string]bool{}
+	for _, item := range items {
+		fields, err := flatten(item)
+		if err != nil {
+			return nil, err
+		}
+		for k := range fields {
+			known[k] = true
+		}
+		rows = append(rows, row{item, fields})
+	}
+	if len(rows) > 0 {
+		for _, f := range q.Filters {
+			if err := checkField(known, f.Field); err != nil {
+				return nil, err
+			}
+		}
+		for _, key := range q.Sort {
+			if err := checkField(known, key.Field); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	kept := rows[:0]
+	for _, r := range rows {
+		if slices.IndexFunc(q.Filters, func(f Filter) bool { return !f.match(r.fields[strings.ToLower(f.Field)]) }) < 0 {
+			kept = append(kept, r)
+		}
+	}
+	slices.SortStableFunc(kept, func(a, b row) int {
+		for _, key := range q.Sort {
+			field := strings.ToLower(key.Field)
+			c := compare(a.fields[field], b.fields[field])
+			if key.Descending {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+
+	out := make([]T, len(kept))
+	for i, r := range kept {
+		out[i] = r.item
+	}
+	return out, nil
+}
+
+func checkField(known map[string]bool, field string) error {
+	if known[strings.ToLower(field)] {
+		return nil
+	}
+	names := make([]string, 0, len(known))
+	for k := range known {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+	return fmt.Errorf("unknown field %q (available: %s)", field, strings.Join(names, ", "))
+}
+
+// flatten returns the top-level JSON fields of v as strings, keyed by
+// lowercased name.
+func flatten(v any) (map[string]string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("cannot filter %T: not an object", v)
+	}
+	fields := make(map[string]string, len(raw))
+	for k, msg := range raw {
+		var s string
+		if err := json.Unmarshal(msg, &s); err != nil {
+			s = strings.Trim(string(msg), `"`)
+			if s == "null" {
+				s = ""
+			}
+		}
+		fields[strings.ToLower(k)] = s
+	}
+	return fields, nil
+}
@@ -0,0 +1,93 @@
+package query
+
+import (
+	"slices"
+	"testing"
+)
+
+type record struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Content string `json:"content"`
+	TTL     string `json:"ttl"`
+	Prio    int    `json:"prio"`
+}
+
+var records = []record{
+	{Name: "www.example.com", Type: "A", Content: "1.2.3.4", TTL: "600", Prio: 0},
+	{Name: "mail.example.com", Type: "A", Content: "1.2.3.5", TTL: "3600", Prio: 0},
+	{Name: "example.com", Type: "MX", Content: "mail.example.com", TTL: "86400", Prio: 10},
+	{Name: "example.com", Type: "MX", Content: "backup.example.com", TTL: "600", Prio: 20},
+	{Name: "mail2.example.com", Type: "AAAA", Content: "2001:db8::1", TTL: "600", Prio: 0},
+}
+
+func names(rs []record) []string {
+	var out []string
+	for _, r := range rs {
+		out = append(out, r.Name+"/"+r.Content)
+	}
+	return out
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters []string
+		sorts   []string
+		want    []string
+	}{
+		{"none", nil, nil, names(records)},
+		{"glob", []string{"name=mail*"}, nil, []string{"mail.example.com/1.2.3.5", "mail2.example.com/2001:db8::1"}},
+		{"regexp", []string{"content~=1.2.3.*"}, nil, []string{"www.example.com/1.2.3.4", "mail.example.com/1.2.3.5"}},
+		{"numeric", []string{"ttl<3600"}, nil, []string{"www.example.com/1.2.3.4", "example.com/backup.example.com", "mail2.example.com/2001:db8::1"}},
+		{"not equal, case-insensitive", []string{"type!=a", "Type!=aaaa"}, nil, []string{"example.com/mail.example.com", "example.com/backup.example.com"}},
+		{"not regexp", []string{"content!~example"}, []string{"-content"}, []string{"mail2.example.com/2001:db8::1", "mail.example.com/1.2.3.5", "www.example.com/1.2.3.4"}},
+		{"sort numeric then name", nil, []string{"-prio", "ttl", "name"}, []string{
+			"example.com/backup.example.com", "example.com/mail.example.com",
+			"mail2.example.com/2001:db8::1", "www.example.com/1.2.3.4", "mail.example.com/1.2.3.5",
+		}},
+		{"range", []string{"ttl>=600", "ttl<=600", "prio>0"}, nil, []string{"example.com/backup.example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Parse(tt.filters, tt.sorts)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got, err := Apply(q, records)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if !slices.Equal(names(got), tt.want) {
+				t.Errorf("Apply() = %v, want %v", names(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		filters []string
+		sorts   []string
+	}{
+		{filters: []string{"ttl"}},
+		{filters: []string{"=600"}},
+		{filters: []string{"content~=("}},
+		{sorts: []string{"-"}},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.filters, tt.sorts); err == nil {
+			t.Errorf("Parse(%v, %v) error = nil, want error", tt.filters, tt.sorts)
+		}
+	}
+}
+
+func TestApplyUnknownField(t *testing.T) {
+	q, _ := Parse([]string{"notes=x"}, nil)
+	if _, err := Apply(q, records); err == nil {
+		t.Error("Apply() with unknown field error = nil, want error")
+	}
+	if got, err := Apply(q, []record{}); err != nil || len(got) != 0 {
+		t.Errorf("Apply(empty) = %v, %v; want no error", got, err)
+	}
+}
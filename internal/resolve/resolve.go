@@ -0,0 +1,444 @@
+// Package resolve queries specific DNS servers directly, which the system
+// resolver cannot do, to check what authoritative nameservers are serving.
+package resolve
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DefaultTimeout bounds a single query when Resolver.Timeout is zero.
+const DefaultTimeout = 5 * time.Second
+
+// Record is one resource record in Porkbun's content format: names without
+// a trailing dot, MX and SRV priorities split out into Prio, and TXT strings
+// concatenated.
+type Record struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	TTL     uint32 `json:"ttl"`
+	Content string `json:"content"`
+	Prio    string `json:"prio,omitempty"`
+}
+
+// Server is a nameserver and the address it is queried at.
+type Server struct {
+	Name string `json:"name"`
+	Addr string `json:"addr"`
+}
+
+// Resolver sends queries to nameservers.
+type Resolver struct {
+	// Recursive is a host:port resolver used to look up nameservers. Empty
+	// uses the system resolver.
+	Recursive string
+	Timeout   time.Duration
+}
+
+// ErrNXDomain is returned when a server says the name does not exist.
+var ErrNXDomain = errors.New("no such domain")
+
+var types = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"CNAME": dnsmessage.TypeCNAME,
+	"MX":    dnsmessage.TypeMX,
+	"NS":    dnsmessage.TypeNS,
+	"TXT":   dnsmessage.TypeTXT,
+	"SRV":   dnsmessage.TypeSRV,
+	"PTR":   dnsmessage.TypePTR,
+	"SOA":   dnsmessage.TypeSOA,
+	"CAA":   dnsmessage.Type(257),
+	"HTTPS": dnsmessage.TypeHTTPS,
+	"SVCB":  dnsmessage.TypeSVCB,
+	"TLSA":  dnsmessage.Type(52),
+	"SSHFP": dnsmessage.Type(44),
+}
+
+// Supported reports whether records of the given type can be queried.
+func Supported(recordType string) bool {
+	_, ok := types[strings.ToUpper(recordType)]
+	return ok
+}
+
+func typeName(t dnsmessage.Type) string {
+	for name, v := range types {
+		if v == t {
+			return name
+		}
+	}
+	return strconv.Itoa(int(t))
+}
+
+func (r *Resolver) timeout() time.Duration {
+	if r.Timeout > 0 {
+		return r.Timeout
+	}
+	return DefaultTimeout
+}
+
+func (r *Resolver) netResolver() *net.Resolver {
+	if r.Recursive == "" {
+		return net.DefaultResolver
+	}
+	d := net.Dialer{Timeout: r.timeout()}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return d.DialContext(ctx, network, r.Recursive)
+		},
+	}
+}
+
+// Nameservers returns the authoritative nameservers of a zone, each with
+// one address to query on port 53.
+func (r *Resolver) Nameservers(ctx context.Context, zone string) ([]Server, error) {
+	nr := r.netResolver()
+	nss, err := nr.LookupNS(ctx, Fqdn(zone))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up nameservers for %s: %w", zone, err)
+	}
+
+	var servers []Server
+	for _, ns := range nss {
+		s, err := r.ServerAddr(ctx, strings.TrimSuffix(ns.Host, "."))
+		if err != nil {
+			continue
+		}
+		servers = append(servers, s)
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("no reachable nameservers found for %s", zone)
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Name < servers[j].Name })
+	return servers, nil
+}
+
+func splitHostPort(server string) (host, port string, err error) {
+	if isIP(server) {
+		return server, "53", nil
+	}
+	if h, p, err := net.SplitHostPort(server); err == nil {
+		return h, p, nil
+	}
+	if strings.Contains(server, ":") {
+		return "", "", fmt.Errorf("invalid server address %q", server)
+	}
+	return server, "53", nil
+}
+
+func isIP(s string) bool {
+	return net.ParseIP(s) != nil
+}
+
+func preferIPv4(addrs []string) string {
+	for _, a := range addrs {
+		if ip := net.ParseIP(a); ip != nil && ip.To4() != nil {
+			return a
+		}
+	}
+	return addrs[0]
+}
+
+// Lookup asks server (host:port) for records of the given type. Answers of
+// other types, such as a CNAME in front of the requested name, are dropped.
+// A name that does not exist yields ErrNXDomain.
+func (r *Resolver) Lookup(ctx context.Context, server, name, recordType string) ([]Record, error) {
+	qtype, ok := types[strings.ToUpper(recordType)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported record type %q", recordType)
+	}
+
+	msg, err := r.Exchange(ctx, server, name, qtype)
+	if err != nil {
+		return nil, err
+	}
+	switch msg.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, fmt.Errorf("%s: %w", name, ErrNXDomain)
+	default:
+		return nil, fmt.Errorf("%s returned %s for %s", server, msg.RCode, name)
+	}
+
+	var records []Record
+	for _, rr := range msg.Answers {
+		if rr.Header.Type != qtype || rr.Header.Class != dnsmessage.ClassINET {
+			continue
+		}
+		rec, err := convert(rr)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// Exchange sends a single query over UDP, retrying over TCP when the answer
+// is truncated.
+func (r *Resolver) Exchange(ctx context.Context, server, name string, qtype dnsmessage.Type) (*dnsmessage.Message, error) {
+	qname, err := dnsmessage.NewName(Fqdn(name))
+	if err != nil {
+		return nil, fmt.Errorf("invalid name %q: %w", name, err)
+	}
+
+	id := uint16(rand.Uint32())
+	b := dnsmessage.NewBuilder(make([]byte, 2, 514), dnsmessage.Header{ID: id})
+	b.EnableCompression()
+	_ = b.StartQuestions()
+	_ = b.Question(dnsmessage.Question{Name: qname, Type: qtype, Class: dnsmessage.ClassINET})
+	_ = b.StartAdditionals()
+	var opt dnsmessage.ResourceHeader
+	_ = opt.SetEDNS0(4096, dnsmessage.RCodeSuccess, false)
+	_ = b.OPTResource(opt, dnsmessage.OPTResource{})
+	query, err := b.Finish()
+	if err != nil {
+		return nil, err
+	}
+	// The first two bytes hold the TCP length prefix.
+	binary.BigEndian.PutUint16(query, uint16(len(query)-2))
+
+	msg, err := r.exchange(ctx, "udp", server, query, id)
+	if err == nil && msg.Truncated {
+		msg, err = r.exchange(ctx, "tcp", server, query, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query %s %s at %s failed: %w", name, typeName(qtype), server, err)
+	}
+	return msg, nil
+}
+
+func (r *Resolver) exchange(ctx context.Context, network, server string, query []byte, id uint16) (*dnsmessage.Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout())
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	buf := make([]byte, 65535)
+	var n int
+	if network == "tcp" {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+			return nil, err
+		}
+		n = int(binary.BigEndian.Uint16(buf[:2]))
+		if _, err := io.ReadFull(conn, buf[:n]); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := conn.Write(query[2:]); err != nil {
+			return nil, err
+		}
+		for {
+			if n, err = conn.Read(buf); err != nil {
+				return nil, err
+			}
+			// Skip stray datagrams with the wrong ID.
+			if n >= 2 && binary.BigEndian.Uint16(buf[:2]) == id {
+				break
+			}
+		}
+	}
+
+	var msg dnsmessage.Message
+	if err := msg.Unpack(buf[:n]); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	if msg.ID != id || !msg.Response {
+		return nil, fmt.Errorf("mismatched response")
+	}
+	return &msg, nil
+}
+
+func convert(rr dnsmessage.Resource) (Record, error) {
+	rec := Record{
+		Name: strings.TrimSuffix(strings.ToLower(rr.Header.Name.String()), "."),
+		Type: typeName(rr.Header.Type),
+		TTL:  rr.Header.TTL,
+	}
+	switch body := rr.Body.(type) {
+	case *dnsmessage.AResource:
+		rec.Content = net.IP(body.A[:]).String()
+	case *dnsmessage.AAAAResource:
+		rec.Content = net.IP(body.AAAA[:]).String()
+	case *dnsmessage.CNAMEResource:
+		rec.Content = trimName(body.CNAME)
+	case *dnsmessage.NSResource:
+		rec.Content = trimName(body.NS)
+	case *dnsmessage.PTRResource:
+		rec.Content = trimName(body.PTR)
+	case *dnsmessage.MXResource:
+		rec.Content = trimName(body.MX)
+		rec.Prio = strconv.Itoa(int(body.Pref))
+	case *dnsmessage.SRVResource:
+		rec.Content = fmt.Sprintf("%d %d %s", body.Weight, body.Port, trimName(body.Target))
+		rec.Prio = strconv.Itoa(int(body.Priority))
+	case *dnsmessage.TXTResource:
+		rec.Content = strings.Join(body.TXT, "")
+	case *dnsmessage.SVCBResource:
+		return svcbRecord(rec, body)
+	case *dnsmessage.HTTPSResource:
+		return svcbRecord(rec, &body.SVCBResource)
+	case *dnsmessage.SOAResource:
+		rec.Content = fmt.Sprintf("%s %s %d %d %d %d %d", trimName(body.NS), trimName(body.MBox), body.Serial, body.Refresh, body.Retry, body.Expire, body.MinTTL)
+	case *dnsmessage.UnknownResource:
+		var err error
+		switch rec.Type {
+		case "CAA":
+			rec.Content, err = parseCAA(body.Data)
+		case "TLSA":
+			rec.Content, err = parseDigest(rec.Type, body.Data, 3)
+		case "SSHFP":
+			rec.Content, err = parseDigest(rec.Type, body.Data, 2)
+		default:
+			rec.Content = fmt.Sprintf("\\# %d %s", len(body.Data), hex.EncodeToString(body.Data))
+		}
+		if err != nil {
+			return rec, err
+		}
+	default:
+		return rec, fmt.Errorf("unexpected %s record body", rec.Type)
+	}
+	return rec, nil
+}
+
+// parseCAA renders RFC 8659 CAA data as `flags tag "value"`.
+func parseCAA(data []byte) (string, error) {
+	if len(data) < 2 || len(data) < 2+int(data[1]) {
+		return "", fmt.Errorf("malformed CAA record")
+	}
+	flags, tagLen := data[0], int(data[1])
+	tag := string(data[2 : 2+tagLen])
+	value := string(data[2+tagLen:])
+	return fmt.Sprintf("%d %s %q", flags, tag, value), nil
+}
+
+// parseDigest renders TLSA (RFC 6698) and SSHFP (RFC 4255) data: the
+// leading one-byte fields as numbers, then the digest in hex.
+func parseDigest(recordType string, data []byte, numeric int) (string, error) {
+	if len(data) <= numeric {
+		return "", fmt.Errorf("malformed %s record", recordType)
+	}
+	fields := make([]string, 0, numeric+1)
+	for _, b := range data[:numeric] {
+		fields = append(fields, strconv.Itoa(int(b)))
+	}
+	return strings.Join(append(fields, hex.EncodeToString(data[numeric:])), " "), nil
+}
+
+// svcParamKeys names the SvcParamKeys of RFC 9460 by number; others are
+// written keyNNNNN.
+var svcParamKeys = []string{"mandatory", "alpn", "no-default-alpn", "port", "ipv4hint", "ech", "ipv6hint", "dohpath", "ohttp"}
+
+func svcParamKey(key dnsmessage.SVCParamKey) string {
+	if int(key) < len(svcParamKeys) {
+		return svcParamKeys[key]
+	}
+	return "key" + strconv.Itoa(int(key))
+}
+
+// svcbRecord fills in an SVCB or HTTPS record (RFC 9460): the priority,
+// and content as `target key=value...`, the target "." standing for the
+// owner name.
+func svcbRecord(rec Record, body *dnsmessage.SVCBResource) (Record, error) {
+	fields := []string{"."}
+	if target := trimName(body.Target); target != "" {
+		fields[0] = target
+	}
+	for _, p := range body.Params {
+		param, err := svcParam(p.Key, p.Value)
+		if err != nil {
+			return rec, err
+		}
+		fields = append(fields, param)
+	}
+	rec.Prio = strconv.Itoa(int(body.Priority))
+	rec.Content = strings.Join(fields, " ")
+	return rec, nil
+}
+
+// svcParam renders one SvcParam as key=value, or just the key when it has
+// no value.
+func svcParam(key dnsmessage.SVCParamKey, value []byte) (string, error) {
+	name := svcParamKey(key)
+	if len(value) == 0 {
+		return name, nil
+	}
+	malformed := fmt.Errorf("malformed SVCB %s parameter", name)
+	var values []string
+	switch key {
+	case dnsmessage.SVCParamMandatory:
+		if len(value)%2 != 0 {
+			return "", malformed
+		}
+		for j := 0; j < len(value); j += 2 {
+			values = append(values, svcParamKey(dnsmessage.SVCParamKey(binary.BigEndian.Uint16(value[j:]))))
+		}
+	case dnsmessage.SVCParamALPN:
+		for j := 0; j < len(value); {
+			n := int(value[j])
+			if j+1+n > len(value) {
+				return "", malformed
+			}
+			values = append(values, string(value[j+1:j+1+n]))
+			j += 1 + n
+		}
+	case dnsmessage.SVCParamPort:
+		if len(value) != 2 {
+			return "", malformed
+		}
+		values = append(values, strconv.Itoa(int(binary.BigEndian.Uint16(value))))
+	case dnsmessage.SVCParamIPv4Hint, dnsmessage.SVCParamIPv6Hint:
+		size := net.IPv4len
+		if key == dnsmessage.SVCParamIPv6Hint {
+			size = net.IPv6len
+		}
+		if len(value)%size != 0 {
+			return "", malformed
+		}
+		for j := 0; j < len(value); j += size {
+			values = append(values, net.IP(value[j:j+size]).String())
+		}
+	case dnsmessage.SVCParamECH:
+		values = append(values, base64.StdEncoding.EncodeToString(value))
+	default:
+		values = append(values, string(value))
+	}
+	return name + "=" + strings.Join(values, ","), nil
+}
+
+func trimName(n dnsmessage.Name) string {
+	return strings.TrimSuffix(strings.ToLower(n.String()), ".")
+}
+
+// Fqdn returns name with a trailing dot.
+func Fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
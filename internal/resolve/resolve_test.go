@@ -0,0 +1,164 @@
+package resolve
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// testServer answers queries on UDP and TCP at the same address. answer
+// returns the records for a question, or nil for NXDOMAIN.
+type testServer struct {
+	addr     string
+	truncate atomic.Bool
+	answer   func(q dnsmessage.Question) []dnsmessage.Resource
+	udp      net.PacketConn
+	tcp      net.Listener
+}
+
+func newTestServer(t *testing.T, answer func(q dnsmessage.Question) []dnsmessage.Resource) *testServer {
+	t.Helper()
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	if err != nil {
+		udp.Close()
+		t.Skipf("cannot listen on TCP at %s: %v", udp.LocalAddr(), err)
+	}
+	s := &testServer{addr: udp.LocalAddr().String(), answer: answer, udp: udp, tcp: tcp}
+	t.Cleanup(func() { udp.Close(); tcp.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, from, err := udp.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = udp.WriteTo(s.reply(buf[:n], s.truncate.Load()), from)
+		}
+	}()
+	go func() {
+		for {
+			conn, err := tcp.Accept()
+			if err != nil {
+				return
+			}
+			var l [2]byte
+			if _, err := io.ReadFull(conn, l[:]); err == nil {
+				q := make([]byte, binary.BigEndian.Uint16(l[:]))
+				if _, err := io.ReadFull(conn, q); err == nil {
+					resp := s.reply(q, false)
+					binary.BigEndian.PutUint16(l[:], uint16(len(resp)))
+					_, _ = conn.Write(append(l[:], resp...))
+				}
+			}
+			conn.Close()
+		}
+	}()
+	return s
+}
+
+func (s *testServer) reply(query []byte, truncate bool) []byte {
+	var q dnsmessage.Message
+	if err := q.Unpack(query); err != nil {
+		return nil
+	}
+	resp := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: q.ID, Response: true, Authoritative: true},
+		Questions: q.Questions,
+	}
+	answers := s.answer(q.Questions[0])
+	switch {
+	case answers == nil:
+		resp.RCode = dnsmessage.RCodeNameError
+	case truncate:
+		resp.Truncated = true
+	default:
+		resp.Answers = answers
+	}
+	data, _ := resp.Pack()
+	return data
+}
+
+func hdr(name string, t dnsmessage.Type) dnsmessage.ResourceHeader {
+	return dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Type: t, Class: dnsmessage.ClassINET, TTL: 300}
+}
+
+func TestLookup(t *testing.T) {
+	srv := newTestServer(t, func(q dnsmessage.Question) []dnsmessage.Resource {
+		if q.Name.String() == "missing.example.com." {
+			return nil
+		}
+		switch q.Type {
+		case dnsmessage.TypeTXT:
+			return []dnsmessage.Resource{
+				{Header: hdr("_acme-challenge.example.com.", dnsmessage.TypeTXT), Body: &dnsmessage.TXTResource{TXT: []string{"abc", "def"}}},
+			}
+		case dnsmessage.TypeMX:
+			return []dnsmessage.Resource{
+				{Header: hdr("example.com.", dnsmessage.TypeMX), Body: &dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("Mail.Example.com.")}},
+			}
+		case dnsmessage.TypeA:
+			return []dnsmessage.Resource{
+				{Header: hdr("www.example.com.", dnsmessage.TypeCNAME), Body: &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName("example.com.")}},
+				{Header: hdr("example.com.", dnsmessage.TypeA), Body: &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}}},
+			}
+		case 257:
+			return []dnsmessage.Resource{
+				{Header: hdr("example.com.", 257), Body: &dnsmessage.UnknownResource{Type: 257, Data: []byte("\x00\x05issueletsencrypt.org")}},
+			}
+		}
+		return []dnsmessage.Resource{}
+	})
+	r := &Resolver{Timeout: 2 * time.Second}
+	ctx := context.Background()
+
+	tests := []struct {
+		name, recordType string
+		want             []Record
+	}{
+		{"_acme-challenge.example.com", "TXT", []Record{{Name: "_acme-challenge.example.com", Type: "TXT", TTL: 300, Content: "abcdef"}}},
+		{"example.com", "mx", []Record{{Name: "example.com", Type: "MX", TTL: 300, Content: "mail.example.com", Prio: "10"}}},
+		{"www.example.com", "A", []Record{{Name: "example.com", Type: "A", TTL: 300, Content: "192.0.2.1"}}},
+		{"example.com", "CAA", []Record{{Name: "example.com", Type: "CAA", TTL: 300, Content: `0 issue "letsencrypt.org"`}}},
+		{"example.com", "AAAA", nil},
+	}
+	for _, tt := range tests {
+		got, err := r.Lookup(ctx, srv.addr, tt.name, tt.recordType)
+		if err != nil {
+			t.Fatalf("Lookup(%s %s) error = %v", tt.name, tt.recordType, err)
+		}
+		if len(got) != len(tt.want) {
+			t.Fatalf("Lookup(%s %s) = %+v, want %+v", tt.name, tt.recordType, got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Lookup(%s %s)[%d] = %+v, want %+v", tt.name, tt.recordType, i, got[i], tt.want[i])
+			}
+		}
+	}
+
+	if _, err := r.Lookup(ctx, srv.addr, "missing.example.com", "A"); !errors.Is(err, ErrNXDomain) {
+		t.Errorf("Lookup(missing) error = %v, want ErrNXDomain", err)
+	}
+	if _, err := r.Lookup(ctx, srv.addr, "example.com", "BOGUS"); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("Lookup(BOGUS) error = %v, want unsupported type", err)
+	}
+
+	srv.truncate.Store(true)
+	got, err := r.Lookup(ctx, srv.addr, "example.com", "MX")
+	if err != nil || len(got) != 1 {
+		t.Errorf("Lookup() over TCP fallback = %+v, %v; want one MX record", got, err)
+	}
+}
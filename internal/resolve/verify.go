@@ -0,0 +1,199 @@
+package resolve
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Status is the outcome of checking one record set on one server.
+type Status string
+
+const (
+	StatusMatch    Status = "match"
+	StatusMismatch Status = "mismatch"
+	StatusMissing  Status = "missing"
+	StatusError    Status = "error"
+	StatusSkipped  Status = "skipped"
+)
+
+// Expected is a record set the servers should serve: all values of one
+// name and type, formatted like Porkbun content ("10 mail.example.com" for
+// records with a priority).
+type Expected struct {
+	Name   string
+	Type   string
+	Values []string
+}
+
+// Check is the result for one record set on one server.
+type Check struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Server   string   `json:"server"`
+	Status   Status   `json:"status"`
+	Expected []string `json:"expected"`
+	Got      []string `json:"got,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// Value formats a record's content for comparison, prefixing the priority
+// for MX, SRV, HTTPS and SVCB and normalizing names, quoting and hex.
+func Value(recordType, content, prio string) string {
+	recordType = strings.ToUpper(recordType)
+	switch recordType {
+	case "TXT":
+		if len(content) >= 2 && strings.HasPrefix(content, `"`) && strings.HasSuffix(content, `"`) {
+			content = content[1 : len(content)-1]
+		}
+		return content
+	case "CNAME", "NS", "PTR":
+		return strings.TrimSuffix(strings.ToLower(content), ".")
+	case "MX":
+		return prioOrZero(prio) + " " + strings.TrimSuffix(strings.ToLower(content), ".")
+	case "SRV":
+		fields := strings.Fields(content)
+		if n := len(fields); n > 0 {
+			fields[n-1] = strings.TrimSuffix(strings.ToLower(fields[n-1]), ".")
+		}
+		return prioOrZero(prio) + " " + strings.Join(fields, " ")
+	case "AAAA":
+		return strings.ToLower(content)
+	case "TLSA", "SSHFP":
+		numeric := 3
+		if recordType == "SSHFP" {
+			numeric = 2
+		}
+		if fields := strings.Fields(content); len(fields) > numeric {
+			return strings.Join(fields[:numeric], " ") + " " + strings.ToLower(strings.Join(fields[numeric:], ""))
+		}
+	case "HTTPS", "SVCB":
+		return svcbValue(content, prio)
+	}
+	return content
+}
+
+// svcbValue formats HTTPS and SVCB content as "priority target params",
+// taking the priority from content when it is written there, with quotes
+// dropped and the parameters sorted.
+func svcbValue(content, prio string) string {
+	fields := strings.Fields(content)
+	if len(fields) > 1 {
+		if _, err := strconv.ParseUint(fields[0], 10, 16); err == nil {
+			prio, fields = fields[0], fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return prioOrZero(prio)
+	}
+	if fields[0] != "." {
+		fields[0] = strings.TrimSuffix(strings.ToLower(fields[0]), ".")
+	}
+	params := fields[1:]
+	for i, param := range params {
+		key, value, hasValue := strings.Cut(param, "=")
+		key = strings.ToLower(key)
+		if !hasValue {
+			params[i] = key
+			continue
+		}
+		values := strings.Split(strings.Trim(value, `"`), ",")
+		if key == "ipv4hint" || key == "ipv6hint" {
+			for j, v := range values {
+				if addr, err := netip.ParseAddr(v); err == nil {
+					values[j] = addr.String()
+				}
+			}
+		}
+		params[i] = key + "=" + strings.Join(values, ",")
+	}
+	slices.Sort(params)
+	return prioOrZero(prio) + " " + strings.Join(fields, " ")
+}
+
+func prioOrZero(prio string) string {
+	if prio == "" {
+		return "0"
+	}
+	return prio
+}
+
+// Verify checks every expected record set against every server
+// concurrently. Checks are returned in input order, server by server.
+func (r *Resolver) Verify(ctx context.Context, servers []Server, want []Expected) []Check {
+	checks := make([]Check, len(servers)*len(want))
+	var wg sync.WaitGroup
+	for si, s := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for wi, w := range want {
+				checks[wi*len(servers)+si] = r.check(ctx, s, w)
+			}
+		}()
+	}
+	wg.Wait()
+	return checks
+}
+
+func (r *Resolver) check(ctx context.Context, s Server, w Expected) Check {
+	c := Check{Name: w.Name, Type: w.Type, Server: s.Name, Expected: sorted(w.Values)}
+	if !Supported(w.Type) {
+		c.Status = StatusSkipped
+		c.Error = "record type cannot be queried directly"
+		return c
+	}
+
+	records, err := r.Lookup(ctx, s.Addr, w.Name, w.Type)
+	if err != nil && !errors.Is(err, ErrNXDomain) {
+		c.Status = StatusError
+		c.Error = err.Error()
+		return c
+	}
+	for _, rec := range records {
+		c.Got = append(c.Got, Value(rec.Type, rec.Content, rec.Prio))
+	}
+	c.Got = sorted(c.Got)
+
+	switch {
+	case len(c.Got) == 0:
+		c.Status = StatusMissing
+	case slices.Equal(c.Expected, c.Got):
+		c.Status = StatusMatch
+	default:
+		c.Status = StatusMismatch
+	}
+	return c
+}
+
+func sorted(values []string) []string {
+	out := slices.Clone(values)
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// ServerAddr turns a nameserver given as an IP, host, "ip:port" or
+// "host:port" into a Server, looking up hostnames and defaulting to port 53.
+func (r *Resolver) ServerAddr(ctx context.Context, server string) (Server, error) {
+	host, port, err := splitHostPort(server)
+	if err != nil {
+		return Server{}, err
+	}
+	addr := host
+	if !isIP(host) {
+		addrs, err := r.netResolver().LookupHost(ctx, Fqdn(host))
+		if err != nil {
+			return Server{}, err
+		}
+		if len(addrs) == 0 {
+			return Server{}, errors.New("no addresses for " + host)
+		}
+		addr = preferIPv4(addrs)
+	}
+	return Server{Name: strings.TrimSuffix(host, "."), Addr: net.JoinHostPort(addr, port)}, nil
+}
@@ -0,0 +1,113 @@
+package resolve
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestValue(t *testing.T) {
+	tests := []struct {
+		recordType, content, prio string
+		want                      string
+	}{
+		{"TXT", `"v=spf1 -all"`, "", "v=spf1 -all"},
+		{"CNAME", "Example.com.", "", "example.com"},
+		{"MX", "mail.example.com", "10", "10 mail.example.com"},
+		{"MX", "mail.example.com", "", "0 mail.example.com"},
+		{"SRV", "5 5060 SIP.example.com.", "10", "10 5 5060 sip.example.com"},
+		{"AAAA", "2001:DB8::1", "", "2001:db8::1"},
+		{"A", "192.0.2.1", "0", "192.0.2.1"},
+		{"TLSA", "3 1 1 ABCD ef01", "", "3 1 1 abcdef01"},
+		{"SSHFP", "4 2 ABCDEF", "", "4 2 abcdef"},
+		{"HTTPS", `. port=443 ALPN="h2,h3"`, "1", "1 . alpn=h2,h3 port=443"},
+		{"HTTPS", "1 Svc.Example.net. ipv6hint=2001:DB8::1", "", "1 svc.example.net ipv6hint=2001:db8::1"},
+		{"SVCB", "foo.example.com", "0", "0 foo.example.com"},
+	}
+	for _, tt := range tests {
+		if got := Value(tt.recordType, tt.content, tt.prio); got != tt.want {
+			t.Errorf("Value(%s, %q, %q) = %q, want %q", tt.recordType, tt.content, tt.prio, got, tt.want)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	srv := newTestServer(t, func(q dnsmessage.Question) []dnsmessage.Resource {
+		switch {
+		case q.Name.String() == "gone.example.com.":
+			return nil
+		case q.Type == dnsmessage.TypeA && q.Name.String() == "example.com.":
+			return []dnsmessage.Resource{
+				{Header: hdr("example.com.", dnsmessage.TypeA), Body: &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}}},
+				{Header: hdr("example.com.", dnsmessage.TypeA), Body: &dnsmessage.AResource{A: [4]byte{192, 0, 2, 2}}},
+			}
+		case q.Type == 52:
+			return []dnsmessage.Resource{
+				{Header: hdr("_443._tcp.example.com.", 52), Body: &dnsmessage.UnknownResource{Type: 52, Data: []byte{3, 1, 1, 0xab, 0xcd, 0xef}}},
+			}
+		case q.Type == dnsmessage.TypeHTTPS:
+			https := &dnsmessage.HTTPSResource{SVCBResource: dnsmessage.SVCBResource{Priority: 1, Target: dnsmessage.MustNewName("."), Params: []dnsmessage.SVCParam{
+				{Key: dnsmessage.SVCParamALPN, Value: []byte("\x02h2\x02h3")},
+				{Key: dnsmessage.SVCParamPort, Value: []byte{1, 187}},
+			}}}
+			return []dnsmessage.Resource{{Header: hdr("example.com.", dnsmessage.TypeHTTPS), Body: https}}
+		case q.Type == dnsmessage.TypeMX:
+			return []dnsmessage.Resource{
+				{Header: hdr("example.com.", dnsmessage.TypeMX), Body: &dnsmessage.MXResource{Pref: 20, MX: dnsmessage.MustNewName("mail.example.com.")}},
+			}
+		}
+		return []dnsmessage.Resource{}
+	})
+	r := &Resolver{Timeout: 2 * time.Second}
+	servers := []Server{{Name: "ns1", Addr: srv.addr}, {Name: "dead", Addr: "127.0.0.1:1"}}
+	want := []Expected{
+		{Name: "example.com", Type: "A", Values: []string{"192.0.2.2", "192.0.2.1"}},
+		{Name: "example.com", Type: "MX", Values: []string{"10 mail.example.com"}},
+		{Name: "gone.example.com", Type: "TXT", Values: []string{"hello"}},
+		{Name: "example.com", Type: "ALIAS", Values: []string{"target.example.net"}},
+		{Name: "_443._tcp.example.com", Type: "TLSA", Values: []string{Value("TLSA", "3 1 1 ABCDEF", "")}},
+		{Name: "example.com", Type: "HTTPS", Values: []string{Value("HTTPS", `. alpn="h2,h3" port=443`, "1")}},
+	}
+
+	checks := r.Verify(context.Background(), servers, want)
+	if len(checks) != 12 {
+		t.Fatalf("len(checks) = %d, want 12", len(checks))
+	}
+	wantStatus := []Status{
+		StatusMatch, StatusError,
+		StatusMismatch, StatusError,
+		StatusMissing, StatusError,
+		StatusSkipped, StatusSkipped,
+		StatusMatch, StatusError,
+		StatusMatch, StatusError,
+	}
+	for i, c := range checks {
+		if c.Status != wantStatus[i] {
+			t.Errorf("checks[%d] (%s %s on %s) = %s, want %s (%+v)", i, c.Name, c.Type, c.Server, c.Status, wantStatus[i], c)
+		}
+	}
+	if got := checks[2].Got; len(got) != 1 || got[0] != "20 mail.example.com" {
+		t.Errorf("mismatch Got = %v, want [20 mail.example.com]", got)
+	}
+}
+
+func TestServerAddr(t *testing.T) {
+	r := &Resolver{}
+	tests := []struct {
+		in   string
+		want Server
+	}{
+		{"192.0.2.53", Server{Name: "192.0.2.53", Addr: "192.0.2.53:53"}},
+		{"127.0.0.1:5353", Server{Name: "127.0.0.1", Addr: "127.0.0.1:5353"}},
+		{"2001:db8::53", Server{Name: "2001:db8::53", Addr: "[2001:db8::53]:53"}},
+		{"[2001:db8::53]:5353", Server{Name: "2001:db8::53", Addr: "[2001:db8::53]:5353"}},
+	}
+	for _, tt := range tests {
+		got, err := r.ServerAddr(context.Background(), tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ServerAddr(%q) = %+v, %v; want %+v", tt.in, got, err, tt.want)
+		}
+	}
+}
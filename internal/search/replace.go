@@ -0,0 +1,129 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"github.com/OverseedAI/overpork/internal/zone"
+)
+
+// Replacement changes the content of one record.
+type Replacement struct {
+	Domain string        `json:"domain"`
+	Record api.DNSRecord `json:"record"`
+	// Content is the new content of the record.
+	Content string `json:"content"`
+}
+
+// Outcome is the result of applying a Replacement.
+type Outcome struct {
+	Replacement
+	Err error `json:"-"`
+}
+
+// Updater updates records by ID.
+type Updater interface {
+	DNSUpdateContext(ctx context.Context, domain, recordID, recordType, content string, opts api.DNSCreateOpts) error
+}
+
+// Replacements pairs hits with their rewritten content, dropping hits the
+// rewrite leaves unchanged.
+func Replacements(hits []Hit, rewrite func(content string) string) []Replacement {
+	reps := []Replacement{}
+	for _, h := range hits {
+		if content := rewrite(h.Content); content != h.Content {
+			reps = append(reps, Replacement{Domain: h.Domain, Record: h.DNSRecord, Content: content})
+		}
+	}
+	return reps
+}
+
+// Apply updates each record in turn, keeping its name, TTL and priority.
+// A failed update does not stop the others; cancellation does, and the
+// remaining replacements are reported with the context's error.
+func Apply(ctx context.Context, c Updater, reps []Replacement) []Outcome {
+	outcomes := make([]Outcome, len(reps))
+	for i, rep := range reps {
+		outcomes[i].Replacement = rep
+		if err := ctx.Err(); err != nil {
+			outcomes[i].Err = err
+			continue
+		}
+		r := rep.Record
+		opts := api.DNSCreateOpts{Name: zone.RelativeName(r.Name, rep.Domain), TTL: r.TTL, Prio: r.Prio}
+		outcomes[i].Err = c.DNSUpdateContext(ctx, rep.Domain, r.ID, r.Type, rep.Content, opts)
+	}
+	return outcomes
+}
+
+// RollbackVersion is the format version of rollback files.
+const RollbackVersion = 1
+
+// Rollback records the replacements made by a bulk update so they can be
+// undone with Invert.
+type Rollback struct {
+	Version      int           `json:"version"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	Replacements []Replacement `json:"replacements"`
+}
+
+// WriteRollback saves the replacements about to be applied.
+func WriteRollback(w io.Writer, reps []Replacement) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(Rollback{Version: RollbackVersion, CreatedAt: time.Now().UTC().Truncate(time.Second), Replacements: reps})
+}
+
+// ReadRollback loads a file written by WriteRollback.
+func ReadRollback(r io.Reader) (*Rollback, error) {
+	var rb Rollback
+	if err := json.NewDecoder(r).Decode(&rb); err != nil {
+		return nil, fmt.Errorf("failed to read rollback file: %w", err)
+	}
+	if rb.Version != RollbackVersion {
+		return nil, fmt.Errorf("unsupported rollback file version %d", rb.Version)
+	}
+	return &rb, nil
+}
+
+// Invert returns the replacements that restore the previous content.
+func Invert(reps []Replacement) []Replacement {
+	inverted := make([]Replacement, len(reps))
+	for i, rep := range reps {
+		r := rep.Record
+		r.Content = rep.Content
+		inverted[i] = Replacement{Domain: rep.Domain, Record: r, Content: rep.Record.Content}
+	}
+	return inverted
+}
+
+// Unchanged keeps the replacements whose records in zones still have the
+// content they are to be changed from, each with the live record, and
+// describes the rest: records edited or deleted since, which must not be
+// overwritten by an old rollback file.
+func Unchanged(reps []Replacement, zones []Zone) (kept []Replacement, skipped []string) {
+	for _, rep := range reps {
+		var live []api.DNSRecord
+		if i := slices.IndexFunc(zones, func(z Zone) bool { return strings.EqualFold(z.Domain, rep.Domain) }); i >= 0 {
+			live = zones[i].Records
+		}
+		r := rep.Record
+		i := slices.IndexFunc(live, func(l api.DNSRecord) bool { return l.ID == r.ID })
+		switch {
+		case i < 0:
+			skipped = append(skipped, fmt.Sprintf("%s: %s %s (%s) no longer exists", rep.Domain, r.Type, r.Name, r.ID))
+		case live[i].Content != r.Content:
+			skipped = append(skipped, fmt.Sprintf("%s: %s %s (%s) changed to %q since", rep.Domain, r.Type, r.Name, r.ID, live[i].Content))
+		default:
+			rep.Record = live[i]
+			kept = append(kept, rep)
+		}
+	}
+	return kept, skipped
+}
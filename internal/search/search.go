@@ -0,0 +1,167 @@
+// Package search finds DNS records across many domains.
+package search
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/OverseedAI/overpork/internal/api"
+)
+
+// Pattern syntaxes accepted by NewMatcher.
+const (
+	ModeExact     = "exact"
+	ModeSubstring = "substring"
+	ModeGlob      = "glob"
+	ModeRegex     = "regex"
+)
+
+// Record fields a Matcher can look at.
+const (
+	FieldName    = "name"
+	FieldContent = "content"
+	FieldAny     = "any"
+)
+
+// Matcher tests record names and content against a pattern, ignoring case.
+type Matcher struct {
+	mode    string
+	pattern string
+	field   string
+	re      *regexp.Regexp
+}
+
+// NewMatcher compiles a pattern in the given mode, matched against field.
+func NewMatcher(pattern, mode, field string) (*Matcher, error) {
+	m := &Matcher{mode: mode, pattern: strings.ToLower(pattern), field: field}
+	switch mode {
+	case ModeExact, ModeSubstring:
+	case ModeGlob:
+		if _, err := path.Match(m.pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+	case ModeRegex:
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+		}
+		m.re = re
+	default:
+		return nil, fmt.Errorf("unknown match mode %q (use %s, %s, %s or %s)", mode, ModeExact, ModeSubstring, ModeGlob, ModeRegex)
+	}
+	switch field {
+	case FieldName, FieldContent, FieldAny:
+	default:
+		return nil, fmt.Errorf("unknown field %q (use %s, %s or %s)", field, FieldName, FieldContent, FieldAny)
+	}
+	return m, nil
+}
+
+// Match reports whether the record's name or content matches.
+func (m *Matcher) Match(r api.DNSRecord) bool {
+	switch m.field {
+	case FieldName:
+		return m.matchString(r.Name)
+	case FieldContent:
+		return m.matchString(r.Content)
+	}
+	return m.matchString(r.Name) || m.matchString(r.Content)
+}
+
+func (m *Matcher) matchString(s string) bool {
+	switch m.mode {
+	case ModeExact:
+		return strings.ToLower(s) == m.pattern
+	case ModeGlob:
+		ok, _ := path.Match(m.pattern, strings.ToLower(s))
+		return ok
+	case ModeRegex:
+		return m.re.MatchString(s)
+	}
+	return strings.Contains(strings.ToLower(s), m.pattern)
+}
+
+// Replace rewrites the matching part of content: every occurrence for
+// substring and regex patterns (with $1-style expansion for the latter),
+// or the whole value for exact and glob patterns.
+func (m *Matcher) Replace(content, with string) string {
+	switch m.mode {
+	case ModeExact, ModeGlob:
+		if m.matchString(content) {
+			return with
+		}
+		return content
+	case ModeRegex:
+		return m.re.ReplaceAllString(content, with)
+	}
+	re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(m.pattern))
+	return re.ReplaceAllLiteralString(content, with)
+}
+
+// Lister fetches the records of a domain.
+type Lister interface {
+	DNSListContext(ctx context.Context, domain string) ([]api.DNSRecord, error)
+}
+
+// Zone is the records of one domain, or the error that prevented reading
+// them.
+type Zone struct {
+	Domain  string
+	Records []api.DNSRecord
+	Err     error
+}
+
+// Fetch reads the records of every domain with at most concurrency
+// requests in flight. Zones are returned in the order of domains.
+func Fetch(ctx context.Context, c Lister, domains []string, concurrency int) []Zone {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	zones := make([]Zone, len(domains))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, domain := range domains {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				zones[i] = Zone{Domain: domain, Err: ctx.Err()}
+				return
+			}
+			defer func() { <-sem }()
+			records, err := c.DNSListContext(ctx, domain)
+			zones[i] = Zone{Domain: domain, Records: records, Err: err}
+		}()
+	}
+	wg.Wait()
+	return zones
+}
+
+// Hit is a matching record and the domain it belongs to.
+type Hit struct {
+	Domain string `json:"domain"`
+	api.DNSRecord
+}
+
+// Find returns the records in zones that match and, when recordType is
+// set, have that type.
+func Find(zones []Zone, m *Matcher, recordType string) []Hit {
+	hits := []Hit{}
+	for _, z := range zones {
+		for _, r := range z.Records {
+			if recordType != "" && !strings.EqualFold(r.Type, recordType) {
+				continue
+			}
+			if m.Match(r) {
+				hits = append(hits, Hit{Domain: z.Domain, DNSRecord: r})
+			}
+		}
+	}
+	return hits
+}
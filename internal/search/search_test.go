@@ -0,0 +1,204 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"github.com/OverseedAI/overpork/internal/porkbuntest"
+)
+
+func TestMatcher(t *testing.T) {
+	rec := api.DNSRecord{Name: "Mail.example.com", Type: "A", Content: "203.0.113.10"}
+
+	tests := []struct {
+		pattern, mode, field string
+		want                 bool
+	}{
+		{"203.0.113.10", ModeExact, FieldContent, true},
+		{"203.0.113", ModeExact, FieldContent, false},
+		{"203.0.113", ModeSubstring, FieldAny, true},
+		{"mail.", ModeSubstring, FieldAny, true},
+		{"mail.", ModeSubstring, FieldContent, false},
+		{"203.0.113.*", ModeGlob, FieldContent, true},
+		{"203.0.113", ModeGlob, FieldContent, false},
+		{"mail.*", ModeGlob, FieldName, true},
+		{`^203\.0\.113\.1\d$`, ModeRegex, FieldContent, true},
+		{`^MAIL\.`, ModeRegex, FieldName, true},
+		{`^www`, ModeRegex, FieldAny, false},
+	}
+	for _, tt := range tests {
+		m, err := NewMatcher(tt.pattern, tt.mode, tt.field)
+		if err != nil {
+			t.Fatalf("NewMatcher(%q, %s) error = %v", tt.pattern, tt.mode, err)
+		}
+		if got := m.Match(rec); got != tt.want {
+			t.Errorf("Match(%q, %s, %s) = %v, want %v", tt.pattern, tt.mode, tt.field, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct{ pattern, mode, field string }{
+		{"(", ModeRegex, FieldAny},
+		{"[", ModeGlob, FieldAny},
+		{"x", "fuzzy", FieldAny},
+		{"x", ModeSubstring, "ttl"},
+	} {
+		if _, err := NewMatcher(tt.pattern, tt.mode, tt.field); err == nil {
+			t.Errorf("NewMatcher(%q, %s, %s) error = nil, want error", tt.pattern, tt.mode, tt.field)
+		}
+	}
+}
+
+func TestReplace(t *testing.T) {
+	tests := []struct {
+		pattern, mode, content, with, want string
+	}{
+		{"203.0.113.10", ModeExact, "203.0.113.10", "198.51.100.7", "198.51.100.7"},
+		{"203.0.113.10", ModeSubstring, "v=spf1 ip4:203.0.113.10 ip4:203.0.113.10 -all", "198.51.100.7", "v=spf1 ip4:198.51.100.7 ip4:198.51.100.7 -all"},
+		{"OLD.example", ModeSubstring, "old.example.com", "new.example", "new.example.com"},
+		{`^(\w+)\.old\.net$`, ModeRegex, "mx1.old.net", "$1.new.net", "mx1.new.net"},
+		{"*.old.net", ModeGlob, "mx1.old.net", "mx.new.net", "mx.new.net"},
+		{"*.old.net", ModeGlob, "mx1.other.net", "mx.new.net", "mx1.other.net"},
+	}
+	for _, tt := range tests {
+		m, err := NewMatcher(tt.pattern, tt.mode, FieldContent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.Replace(tt.content, tt.with); got != tt.want {
+			t.Errorf("Replace(%q, %q) = %q, want %q", tt.content, tt.with, got, tt.want)
+		}
+	}
+}
+
+type fakeLister struct {
+	inFlight, peak atomic.Int32
+}
+
+func (f *fakeLister) DNSListContext(ctx context.Context, domain string) ([]api.DNSRecord, error) {
+	n := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for {
+		p := f.peak.Load()
+		if n <= p || f.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	if domain == "broken.com" {
+		return nil, errors.New("boom")
+	}
+	return []api.DNSRecord{{ID: "1", Name: "www." + domain, Type: "A", Content: "203.0.113.10"}}, nil
+}
+
+func TestFetchAndFind(t *testing.T) {
+	domains := []string{"a.com", "b.com", "broken.com", "c.com", "d.com", "e.com"}
+	lister := &fakeLister{}
+
+	zones := Fetch(context.Background(), lister, domains, 2)
+
+	if peak := lister.peak.Load(); peak > 2 {
+		t.Errorf("peak concurrency = %d, want at most 2", peak)
+	}
+	for i, z := range zones {
+		if z.Domain != domains[i] {
+			t.Fatalf("zones[%d] = %s, want %s", i, z.Domain, domains[i])
+		}
+	}
+	if zones[2].Err == nil {
+		t.Error("broken.com error = nil")
+	}
+
+	m, _ := NewMatcher("203.0.113.10", ModeSubstring, FieldContent)
+	if hits := Find(zones, m, ""); len(hits) != 5 || hits[0].Domain != "a.com" || hits[0].ID != "1" {
+		t.Errorf("Find() = %+v, want 5 hits starting with a.com", hits)
+	}
+	if hits := Find(zones, m, "AAAA"); len(hits) != 0 {
+		t.Errorf("Find(AAAA) = %+v, want none", hits)
+	}
+}
+
+func TestApply(t *testing.T) {
+	srv := porkbuntest.NewServer()
+	defer srv.Close()
+	srv.AddDomain(porkbuntest.Domain{Name: "example.com"})
+	www := srv.AddRecord("example.com", porkbuntest.Record{Name: "www", Type: "A", Content: "203.0.113.10", TTL: "3600"})
+	mx := srv.AddRecord("example.com", porkbuntest.Record{Type: "MX", Content: "mail.example.com", TTL: "600", Prio: "10"})
+	c := api.NewClient(srv.Config())
+
+	hits := []Hit{
+		{Domain: "example.com", DNSRecord: api.DNSRecord{ID: www, Name: "www.example.com", Type: "A", Content: "203.0.113.10", TTL: "3600"}},
+		{Domain: "example.com", DNSRecord: api.DNSRecord{ID: mx, Name: "example.com", Type: "MX", Content: "mail.example.com", TTL: "600", Prio: "10"}},
+		{Domain: "example.com", DNSRecord: api.DNSRecord{ID: "999", Name: "gone.example.com", Type: "A", Content: "203.0.113.10"}},
+	}
+	m, _ := NewMatcher("203.0.113.10", ModeSubstring, FieldContent)
+	reps := Replacements(hits, func(s string) string { return m.Replace(s, "198.51.100.7") })
+	if len(reps) != 2 {
+		t.Fatalf("Replacements() = %+v, want 2 (MX unchanged)", reps)
+	}
+
+	outcomes := Apply(context.Background(), c, reps)
+	if outcomes[0].Err != nil {
+		t.Errorf("www error = %v", outcomes[0].Err)
+	}
+	if outcomes[1].Err == nil {
+		t.Error("missing record error = nil, want error")
+	}
+
+	for _, r := range srv.Records("example.com") {
+		if r.ID == www && (r.Content != "198.51.100.7" || r.Name != "www.example.com" || r.TTL != "3600") {
+			t.Errorf("www after Apply = %+v", r)
+		}
+	}
+}
+
+func TestRollback(t *testing.T) {
+	reps := []Replacement{{
+		Domain:  "example.com",
+		Record:  api.DNSRecord{ID: "1", Name: "www.example.com", Type: "A", Content: "203.0.113.10", TTL: "600"},
+		Content: "198.51.100.7",
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteRollback(&buf, reps); err != nil {
+		t.Fatalf("WriteRollback() error = %v", err)
+	}
+	rb, err := ReadRollback(&buf)
+	if err != nil {
+		t.Fatalf("ReadRollback() error = %v", err)
+	}
+	undo := Invert(rb.Replacements)
+	if len(undo) != 1 || undo[0].Record.Content != "198.51.100.7" || undo[0].Content != "203.0.113.10" || undo[0].Record.ID != "1" {
+		t.Errorf("Invert() = %+v", undo)
+	}
+
+	if _, err := ReadRollback(bytes.NewBufferString(`{"version": 2}`)); err == nil {
+		t.Error("ReadRollback(version 2) error = nil, want error")
+	}
+}
+
+func TestUnchanged(t *testing.T) {
+	undo := Invert([]Replacement{
+		{Domain: "example.com", Record: api.DNSRecord{ID: "1", Name: "www.example.com", Type: "A", Content: "203.0.113.10"}, Content: "198.51.100.7"},
+		{Domain: "example.com", Record: api.DNSRecord{ID: "2", Name: "api.example.com", Type: "A", Content: "203.0.113.10"}, Content: "198.51.100.7"},
+		{Domain: "example.com", Record: api.DNSRecord{ID: "3", Name: "old.example.com", Type: "A", Content: "203.0.113.10"}, Content: "198.51.100.7"},
+		{Domain: "example.net", Record: api.DNSRecord{ID: "4", Name: "example.net", Type: "A", Content: "203.0.113.10"}, Content: "198.51.100.7"},
+	})
+	zones := []Zone{{Domain: "Example.com", Records: []api.DNSRecord{
+		{ID: "1", Name: "www.example.com", Type: "A", Content: "198.51.100.7", TTL: "3600"},
+		{ID: "2", Name: "api.example.com", Type: "A", Content: "192.0.2.99"},
+	}}}
+
+	kept, skipped := Unchanged(undo, zones)
+	if len(kept) != 1 || kept[0].Record.ID != "1" || kept[0].Record.TTL != "3600" || kept[0].Content != "203.0.113.10" {
+		t.Errorf("Unchanged() kept = %+v, want record 1 with its live TTL", kept)
+	}
+	if len(skipped) != 3 || !strings.Contains(skipped[0], `changed to "192.0.2.99"`) || !strings.Contains(skipped[1], "no longer exists") {
+		t.Errorf("Unchanged() skipped = %q", skipped)
+	}
+}
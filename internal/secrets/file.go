@@ -0,0 +1,163 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	fileVersion = 1
+	kdfIters    = 600000
+	keyLen      = 32
+	saltLen     = 16
+)
+
+// fileStore keeps credentials for all accounts in a single file encrypted
+// with AES-256-GCM under a key derived from a passphrase with PBKDF2.
+type fileStore struct {
+	path       string
+	passphrase func() (string, error)
+}
+
+type encryptedFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// NewFileStore returns a store backed by the encrypted file at path.
+// passphrase is called whenever the file is read or written.
+func NewFileStore(path string, passphrase func() (string, error)) Store {
+	return &fileStore{path: path, passphrase: passphrase}
+}
+
+func (s *fileStore) Get(account string) (Credentials, error) {
+	all, _, err := s.load()
+	if err != nil {
+		return Credentials{}, err
+	}
+	c, ok := all[account]
+	if !ok {
+		return Credentials{}, fmt.Errorf("%w in %s for profile %s", ErrNotFound, s.path, account)
+	}
+	return c, nil
+}
+
+func (s *fileStore) Set(account string, c Credentials) error {
+	all, pass, err := s.load()
+	if err != nil {
+		return err
+	}
+	all[account] = c
+	return s.save(all, pass)
+}
+
+func (s *fileStore) Delete(account string) error {
+	all, pass, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := all[account]; !ok {
+		return fmt.Errorf("%w in %s for profile %s", ErrNotFound, s.path, account)
+	}
+	delete(all, account)
+	return s.save(all, pass)
+}
+
+// load decrypts the file and returns its contents and the passphrase used.
+// A missing file yields an empty set.
+func (s *fileStore) load() (map[string]Credentials, string, error) {
+	pass, err := s.passphrase()
+	if err != nil {
+		return nil, "", err
+	}
+	if pass == "" {
+		return nil, "", fmt.Errorf("a passphrase is required for the encrypted secrets file")
+	}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]Credentials{}, pass, nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read secrets file: %w", err)
+	}
+
+	var ef encryptedFile
+	if err := json.Unmarshal(data, &ef); err != nil {
+		return nil, "", fmt.Errorf("failed to parse secrets file: %w", err)
+	}
+	if ef.Version != fileVersion {
+		return nil, "", fmt.Errorf("unsupported secrets file version %d", ef.Version)
+	}
+
+	gcm, err := newGCM(pass, ef.Salt)
+	if err != nil {
+		return nil, "", err
+	}
+	plain, err := gcm.Open(nil, ef.Nonce, ef.Data, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decrypt secrets file (wrong passphrase?)")
+	}
+
+	all := map[string]Credentials{}
+	if err := json.Unmarshal(plain, &all); err != nil {
+		return nil, "", fmt.Errorf("failed to parse decrypted secrets: %w", err)
+	}
+	return all, pass, nil
+}
+
+func (s *fileStore) save(all map[string]Credentials, pass string) error {
+	plain, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+
+	ef := encryptedFile{Version: fileVersion, Salt: make([]byte, saltLen)}
+	if _, err := rand.Read(ef.Salt); err != nil {
+		return err
+	}
+	gcm, err := newGCM(pass, ef.Salt)
+	if err != nil {
+		return err
+	}
+	ef.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(ef.Nonce); err != nil {
+		return err
+	}
+	ef.Data = gcm.Seal(nil, ef.Nonce, plain, nil)
+
+	data, err := json.MarshalIndent(ef, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	return nil
+}
+
+func newGCM(pass string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, pass, salt, kdfIters, keyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
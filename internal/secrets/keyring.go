@@ -0,0 +1,138 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// runner executes a program with optional stdin and returns its stdout.
+type runner func(stdin string, name string, args ...string) (string, error)
+
+func execRunner(stdin string, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && strings.TrimSpace(stderr.String()) == "" {
+			return stdout.String(), errExit
+		}
+		return stdout.String(), fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// errExit marks a tool exiting non-zero without an error message, which is
+// how secret-tool and security report a missing item.
+var errExit = errors.New("exited with non-zero status")
+
+// keyring stores credentials as a JSON blob in the OS keyring, using
+// secret-tool (Secret Service) on Linux and security (Keychain) on macOS.
+type keyring struct {
+	goos string
+	run  runner
+	look func(string) (string, error)
+}
+
+// Keyring returns the OS keyring store. Operations fail with
+// ErrUnavailable when the platform tool is missing or no keyring daemon is
+// reachable.
+func Keyring() Store {
+	return &keyring{goos: runtime.GOOS, run: execRunner, look: exec.LookPath}
+}
+
+func (k *keyring) tool() (string, error) {
+	var name string
+	switch k.goos {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		name = "secret-tool"
+	case "darwin":
+		name = "security"
+	default:
+		return "", fmt.Errorf("%w: no keyring support on %s", ErrUnavailable, k.goos)
+	}
+	if _, err := k.look(name); err != nil {
+		return "", fmt.Errorf("%w: %s not found", ErrUnavailable, name)
+	}
+	return name, nil
+}
+
+func (k *keyring) Get(account string) (Credentials, error) {
+	tool, err := k.tool()
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	var out string
+	if tool == "security" {
+		out, err = k.run("", tool, "find-generic-password", "-s", Service, "-a", account, "-w")
+	} else {
+		out, err = k.run("", tool, "lookup", "service", Service, "account", account)
+	}
+	if errors.Is(err, errExit) || (err == nil && strings.TrimSpace(out) == "") {
+		return Credentials{}, fmt.Errorf("%w in keyring for profile %s", ErrNotFound, account)
+	}
+	if err != nil {
+		return Credentials{}, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	var c Credentials
+	if err := json.Unmarshal([]byte(strings.TrimSpace(out)), &c); err != nil {
+		return Credentials{}, fmt.Errorf("failed to parse keyring item: %w", err)
+	}
+	return c, nil
+}
+
+func (k *keyring) Set(account string, c Credentials) error {
+	tool, err := k.tool()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	if tool == "security" {
+		// Interactive mode reads the command from stdin, keeping the
+		// secret out of the process arguments; -X takes it hex-encoded.
+		command := fmt.Sprintf("add-generic-password -U -s %s -a %s -X %x\n", quoteArg(Service), quoteArg(account), data)
+		_, err = k.run(command, tool, "-i")
+	} else {
+		_, err = k.run(string(data), tool, "store", "--label", Service+" ("+account+")", "service", Service, "account", account)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return nil
+}
+
+// quoteArg quotes an argument for a security -i command line.
+func quoteArg(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func (k *keyring) Delete(account string) error {
+	tool, err := k.tool()
+	if err != nil {
+		return err
+	}
+	if tool == "security" {
+		_, err = k.run("", tool, "delete-generic-password", "-s", Service, "-a", account)
+	} else {
+		_, err = k.run("", tool, "clear", "service", Service, "account", account)
+	}
+	if errors.Is(err, errExit) {
+		return fmt.Errorf("%w in keyring for profile %s", ErrNotFound, account)
+	}
+	return err
+}
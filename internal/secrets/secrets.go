@@ -0,0 +1,66 @@
+// Package secrets stores Porkbun credentials outside config.yaml: in the OS
+// keyring, in a passphrase-encrypted file, or behind an external command.
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Service is the keyring service name credentials are stored under.
+const Service = "overpork"
+
+var (
+	// ErrNotFound is returned when no credentials exist for an account.
+	ErrNotFound = errors.New("credentials not found")
+	// ErrUnavailable is returned when a store cannot be used on this system.
+	ErrUnavailable = errors.New("secret store unavailable")
+)
+
+// Credentials is the API key pair kept in a store.
+type Credentials struct {
+	APIKey    string `json:"api_key"`
+	SecretKey string `json:"secret_key"`
+}
+
+// Store keeps credentials per account (profile name).
+type Store interface {
+	Get(account string) (Credentials, error)
+	Set(account string, c Credentials) error
+	Delete(account string) error
+}
+
+// RunCommand runs a shell command and returns its trimmed standard output,
+// e.g. "pass show porkbun".
+func RunCommand(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%q failed: %w: %s", command, err, msg)
+		}
+		return "", fmt.Errorf("%q failed: %w", command, err)
+	}
+
+	// Tools like pass print the secret on the first line and metadata after.
+	out := strings.TrimSpace(stdout.String())
+	if i := strings.IndexByte(out, '\n'); i >= 0 {
+		out = strings.TrimSpace(out[:i])
+	}
+	if out == "" {
+		return "", fmt.Errorf("%q printed nothing", command)
+	}
+	return out, nil
+}
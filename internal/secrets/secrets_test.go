@@ -0,0 +1,149 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	pass := func() (string, error) { return "hunter2", nil }
+	s := NewFileStore(path, pass)
+
+	if _, err := s.Get("default"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() on missing file error = %v, want ErrNotFound", err)
+	}
+	want := Credentials{APIKey: "pk1_abc", SecretKey: "sk1_xyz"}
+	if err := s.Set("default", want); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := s.Set("work", Credentials{APIKey: "pk1_work", SecretKey: "sk1_work"}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "sk1_xyz") {
+		t.Error("secrets file contains the plaintext secret key")
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("secrets file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	got, err := NewFileStore(path, pass).Get("default")
+	if err != nil || got != want {
+		t.Errorf("Get() = %+v, %v; want %+v", got, err, want)
+	}
+
+	wrong := NewFileStore(path, func() (string, error) { return "nope", nil })
+	if _, err := wrong.Get("default"); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("Get() with wrong passphrase error = %v, want decryption error", err)
+	}
+
+	if err := s.Delete("work"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Get("work"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() error = %v, want ErrNotFound", err)
+	}
+
+	empty := NewFileStore(path, func() (string, error) { return "", nil })
+	if _, err := empty.Get("default"); err == nil {
+		t.Error("Get() with empty passphrase error = nil, want error")
+	}
+}
+
+type fakeTool struct {
+	items map[string]string
+	calls []string
+}
+
+func (f *fakeTool) run(stdin string, name string, args ...string) (string, error) {
+	f.calls = append(f.calls, name+" "+strings.Join(args, " "))
+	account := args[len(args)-1]
+	switch args[0] {
+	case "store":
+		f.items[account] = stdin
+	case "lookup":
+		item, ok := f.items[account]
+		if !ok {
+			return "", errExit
+		}
+		return item + "\n", nil
+	case "clear":
+		delete(f.items, account)
+	}
+	return "", nil
+}
+
+func TestKeyring(t *testing.T) {
+	tool := &fakeTool{items: map[string]string{}}
+	k := &keyring{goos: "linux", run: tool.run, look: func(string) (string, error) { return "/usr/bin/secret-tool", nil }}
+
+	if _, err := k.Get("default"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() error = %v, want ErrNotFound", err)
+	}
+	want := Credentials{APIKey: "pk1_abc", SecretKey: "sk1_xyz"}
+	if err := k.Set("default", want); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if strings.Contains(strings.Join(tool.calls, " "), "sk1_xyz") {
+		t.Error("secret key passed on the secret-tool command line")
+	}
+	got, err := k.Get("default")
+	if err != nil || got != want {
+		t.Errorf("Get() = %+v, %v; want %+v", got, err, want)
+	}
+	if err := k.Delete("default"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	var stdin string
+	darwin := &keyring{goos: "darwin", look: func(string) (string, error) { return "/usr/bin/security", nil },
+		run: func(in string, name string, args ...string) (string, error) {
+			stdin = in
+			if strings.Contains(strings.Join(args, " "), "sk1_xyz") {
+				t.Error("secret key passed on the security command line")
+			}
+			return "", nil
+		}}
+	if err := darwin.Set("work", want); err != nil {
+		t.Fatalf("Set() on darwin error = %v", err)
+	}
+	if !strings.HasPrefix(stdin, `add-generic-password -U -s "`+Service+`" -a "work" -X 7b`) || strings.Contains(stdin, "sk1_xyz") {
+		t.Errorf("security stdin = %q, want a hex-encoded add-generic-password command", stdin)
+	}
+
+	missing := &keyring{goos: "linux", run: tool.run, look: func(string) (string, error) { return "", errors.New("not found") }}
+	if err := missing.Set("default", want); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Set() without secret-tool error = %v, want ErrUnavailable", err)
+	}
+	windows := &keyring{goos: "windows", run: tool.run, look: missing.look}
+	if _, err := windows.Get("default"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Get() on windows error = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+		wantErr bool
+	}{
+		{"echo sk1_secret", "sk1_secret", false},
+		{"printf 'sk1_secret\\nlogin: me\\n'", "sk1_secret", false},
+		{"true", "", true},
+		{"echo oops >&2; exit 1", "", true},
+	}
+	for _, tt := range tests {
+		got, err := RunCommand(tt.command)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("RunCommand(%q) = %q, %v; want %q (error %v)", tt.command, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
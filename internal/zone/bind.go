@@ -0,0 +1,443 @@
+package zone
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+)
+
+// ParseBIND parses an RFC 1035 master file into records relative to domain.
+// $ORIGIN and $TTL directives, relative owner names, "@", blank owners,
+// parenthesized multi-line records and multi-string TXT data are supported.
+// SOA records are skipped since the registrar manages them.
+func ParseBIND(r io.Reader, domain string) ([]Record, error) {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	p := &bindParser{origin: domain, domain: domain}
+
+	lines, err := bindLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, l := range lines {
+		rec, ok, err := p.parse(l)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", l.num, err)
+		}
+		if ok {
+			records = append(records, rec)
+		}
+	}
+	return records, nil
+}
+
+// WriteBIND writes records as an RFC 1035 master file for domain.
+func WriteBIND(w io.Writer, domain string, records []Record) error {
+	domain = strings.TrimSuffix(domain, ".")
+	if _, err := fmt.Fprintf(w, "$ORIGIN %s.\n", domain); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	for _, r := range records {
+		rdata, err := bindRdata(r)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(tw, "%s\t%s\tIN\t%s\t%s\n", displayName(r.Name), r.TTL, strings.ToUpper(r.Type), rdata)
+	}
+	return tw.Flush()
+}
+
+func bindRdata(r Record) (string, error) {
+	switch strings.ToUpper(r.Type) {
+	case "CNAME", "ALIAS", "NS", "PTR":
+		return absolute(r.Content), nil
+	case "MX":
+		return prioOrZero(r.Prio) + " " + absolute(r.Content), nil
+	case "SRV":
+		fields := strings.Fields(r.Content)
+		if len(fields) != 3 {
+			return "", fmt.Errorf("SRV record %s: content %q is not \"weight port target\"", displayName(r.Name), r.Content)
+		}
+		return fmt.Sprintf("%s %s %s %s", prioOrZero(r.Prio), fields[0], fields[1], absolute(fields[2])), nil
+	case "TXT", "SPF":
+		return quoteTXT(r.Content), nil
+	}
+	return r.Content, nil
+}
+
+func absolute(name string) string {
+	if name == "" || strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+func prioOrZero(prio string) string {
+	if prio == "" {
+		return "0"
+	}
+	return prio
+}
+
+// quoteTXT splits TXT data into quoted character-strings of at most 255
+// bytes each.
+func quoteTXT(s string) string {
+	var parts []string
+	for {
+		chunk := s
+		if len(chunk) > 255 {
+			chunk = chunk[:255]
+		}
+		s = s[len(chunk):]
+		chunk = strings.ReplaceAll(chunk, `\`, `\\`)
+		chunk = strings.ReplaceAll(chunk, `"`, `\"`)
+		parts = append(parts, `"`+chunk+`"`)
+		if s == "" {
+			break
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+type bindToken struct {
+	text   string
+	quoted bool
+}
+
+type bindLine struct {
+	num        int
+	blankOwner bool
+	tokens     []bindToken
+}
+
+// bindLines splits master file input into logical lines of tokens, joining
+// parenthesized continuations and dropping comments.
+func bindLines(r io.Reader) ([]bindLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []bindLine
+	var cur *bindLine
+	depth := 0
+	num := 0
+
+	for scanner.Scan() {
+		num++
+		text := scanner.Text()
+		if cur == nil {
+			cur = &bindLine{num: num}
+			cur.blankOwner = len(text) > 0 && (text[0] == ' ' || text[0] == '\t')
+		}
+
+		i := 0
+		for i < len(text) {
+			c := text[i]
+			switch {
+			case c == ';':
+				i = len(text)
+			case c == '(':
+				depth++
+				i++
+			case c == ')':
+				if depth == 0 {
+					return nil, fmt.Errorf("line %d: unbalanced parenthesis", num)
+				}
+				depth--
+				i++
+			case c == '"':
+				var sb strings.Builder
+				i++
+				closed := false
+				for i < len(text) {
+					if text[i] == '\\' && i+1 < len(text) {
+						n, skip := unescape(text[i+1:])
+						sb.WriteString(n)
+						i += 1 + skip
+						continue
+					}
+					if text[i] == '"' {
+						closed = true
+						i++
+						break
+					}
+					sb.WriteByte(text[i])
+					i++
+				}
+				if !closed {
+					return nil, fmt.Errorf("line %d: unterminated quoted string", num)
+				}
+				cur.tokens = append(cur.tokens, bindToken{text: sb.String(), quoted: true})
+			case unicode.IsSpace(rune(c)):
+				i++
+			default:
+				start := i
+				for i < len(text) && !unicode.IsSpace(rune(text[i])) && !strings.ContainsRune(`;()"`, rune(text[i])) {
+					i++
+				}
+				cur.tokens = append(cur.tokens, bindToken{text: text[start:i]})
+			}
+		}
+
+		if depth == 0 {
+			if len(cur.tokens) > 0 {
+				lines = append(lines, *cur)
+			}
+			cur = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read zone file: %w", err)
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parenthesis at end of file")
+	}
+	return lines, nil
+}
+
+// unescape decodes the escape sequence following a backslash and returns the
+// decoded text and the number of bytes consumed.
+func unescape(s string) (string, int) {
+	if len(s) >= 3 && isDigits(s[:3]) {
+		n, _ := strconv.Atoi(s[:3])
+		if n < 256 {
+			return string([]byte{byte(n)}), 3
+		}
+	}
+	return s[:1], 1
+}
+
+type bindParser struct {
+	origin    string
+	domain    string
+	defTTL    string
+	lastTTL   string
+	lastOwner string
+}
+
+func (p *bindParser) parse(l bindLine) (Record, bool, error) {
+	toks := l.tokens
+	first := toks[0]
+
+	if !first.quoted && strings.HasPrefix(first.text, "$") {
+		switch strings.ToUpper(first.text) {
+		case "$ORIGIN":
+			if len(toks) < 2 {
+				return Record{}, false, fmt.Errorf("$ORIGIN requires a name")
+			}
+			p.origin = p.absoluteName(toks[1].text)
+		case "$TTL":
+			if len(toks) < 2 {
+				return Record{}, false, fmt.Errorf("$TTL requires a value")
+			}
+			ttl, err := parseTTL(toks[1].text)
+			if err != nil {
+				return Record{}, false, err
+			}
+			p.defTTL = ttl
+		default:
+			return Record{}, false, fmt.Errorf("unsupported directive %s", first.text)
+		}
+		return Record{}, false, nil
+	}
+
+	owner := p.lastOwner
+	if !l.blankOwner {
+		owner = p.absoluteName(first.text)
+		toks = toks[1:]
+	}
+	if owner == "" {
+		return Record{}, false, fmt.Errorf("record has no owner name")
+	}
+	p.lastOwner = owner
+
+	ttl := ""
+	var recordType string
+	for len(toks) > 0 && recordType == "" {
+		t := toks[0].text
+		toks = toks[1:]
+		switch {
+		case isClass(t):
+			if !strings.EqualFold(t, "IN") {
+				return Record{}, false, fmt.Errorf("unsupported class %s", t)
+			}
+		case ttl == "" && len(t) > 0 && unicode.IsDigit(rune(t[0])):
+			v, err := parseTTL(t)
+			if err != nil {
+				return Record{}, false, err
+			}
+			ttl = v
+		default:
+			recordType = strings.ToUpper(t)
+		}
+	}
+	if recordType == "" {
+		return Record{}, false, fmt.Errorf("missing record type")
+	}
+
+	if ttl == "" {
+		ttl = p.defTTL
+		if ttl == "" {
+			ttl = p.lastTTL
+		}
+	} else {
+		p.lastTTL = ttl
+	}
+
+	if recordType == "SOA" {
+		if p.defTTL == "" && ttl == "" && len(toks) == 7 {
+			// RFC 2308: without $TTL, the SOA minimum is the default TTL.
+			if v, err := parseTTL(toks[6].text); err == nil {
+				p.defTTL = v
+			}
+		}
+		return Record{}, false, nil
+	}
+
+	if owner != p.domain && !strings.HasSuffix(owner, "."+p.domain) {
+		return Record{}, false, fmt.Errorf("owner %s is outside %s", owner, p.domain)
+	}
+
+	rec := Record{Name: RelativeName(owner, p.domain), Type: recordType, TTL: ttl}
+	if err := p.rdata(&rec, toks); err != nil {
+		return Record{}, false, fmt.Errorf("%s record %s: %w", recordType, displayName(rec.Name), err)
+	}
+	return rec, true, nil
+}
+
+func (p *bindParser) rdata(rec *Record, toks []bindToken) error {
+	want := func(n int) error {
+		if len(toks) != n {
+			return fmt.Errorf("expected %d fields, got %d", n, len(toks))
+		}
+		return nil
+	}
+
+	switch rec.Type {
+	case "A", "AAAA":
+		if err := want(1); err != nil {
+			return err
+		}
+		rec.Content = toks[0].text
+	case "CNAME", "ALIAS", "NS", "PTR":
+		if err := want(1); err != nil {
+			return err
+		}
+		rec.Content = p.absoluteName(toks[0].text)
+	case "MX":
+		if err := want(2); err != nil {
+			return err
+		}
+		rec.Prio = toks[0].text
+		rec.Content = p.absoluteName(toks[1].text)
+	case "SRV":
+		if err := want(4); err != nil {
+			return err
+		}
+		rec.Prio = toks[0].text
+		rec.Content = fmt.Sprintf("%s %s %s", toks[1].text, toks[2].text, p.absoluteName(toks[3].text))
+	case "TXT", "SPF":
+		if len(toks) == 0 {
+			return fmt.Errorf("missing text")
+		}
+		var sb strings.Builder
+		for _, t := range toks {
+			sb.WriteString(t.text)
+		}
+		rec.Content = sb.String()
+	default:
+		if len(toks) == 0 {
+			return fmt.Errorf("missing data")
+		}
+		parts := make([]string, len(toks))
+		for i, t := range toks {
+			if t.quoted {
+				parts[i] = strconv.Quote(t.text)
+			} else {
+				parts[i] = t.text
+			}
+		}
+		rec.Content = strings.Join(parts, " ")
+	}
+	return nil
+}
+
+// absoluteName resolves a possibly relative name against the current origin
+// and returns it without the trailing dot.
+func (p *bindParser) absoluteName(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case name == "@":
+		return p.origin
+	case strings.HasSuffix(name, "."):
+		return strings.TrimSuffix(name, ".")
+	case p.origin == "":
+		return name
+	}
+	return name + "." + p.origin
+}
+
+func isClass(s string) bool {
+	switch strings.ToUpper(s) {
+	case "IN", "CH", "HS", "CS":
+		return true
+	}
+	return false
+}
+
+// parseTTL parses a TTL in seconds or with BIND unit suffixes such as 1h30m.
+func parseTTL(s string) (string, error) {
+	if isDigits(s) {
+		return s, nil
+	}
+	total := 0
+	num := ""
+	for _, c := range strings.ToLower(s) {
+		if unicode.IsDigit(c) {
+			num += string(c)
+			continue
+		}
+		if num == "" {
+			return "", fmt.Errorf("invalid TTL %q", s)
+		}
+		n, _ := strconv.Atoi(num)
+		switch c {
+		case 's':
+			total += n
+		case 'm':
+			total += n * 60
+		case 'h':
+			total += n * 3600
+		case 'd':
+			total += n * 86400
+		case 'w':
+			total += n * 604800
+		default:
+			return "", fmt.Errorf("invalid TTL %q", s)
+		}
+		num = ""
+	}
+	if num != "" {
+		n, _ := strconv.Atoi(num)
+		total += n
+	}
+	return strconv.Itoa(total), nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package zone
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testZone = `$ORIGIN example.com.
+$TTL 1h
+@	IN	SOA	ns1.example.com. hostmaster.example.com. (
+		2024010101 ; serial
+		7200       ; refresh
+		3600       ; retry
+		1209600    ; expire
+		3600 )     ; minimum
+@		IN	NS	ns1.example.com.
+@		IN	A	192.0.2.1
+		IN	MX	10 mail
+www	600	IN	CNAME	@
+mail	IN	600	A	192.0.2.2
+_sip._tcp	IN	SRV	10 60 5060 sip.example.com.
+@	IN	TXT	"v=spf1 include:_spf.example.net" " ~all"
+long	IN	TXT	( "part one; "
+		  "part \"two\"" )
+$ORIGIN sub.example.com.
+host	IN	AAAA	2001:db8::1
+`
+
+func TestParseBIND(t *testing.T) {
+	records, err := ParseBIND(strings.NewReader(testZone), "example.com")
+	if err != nil {
+		t.Fatalf("ParseBIND() error = %v", err)
+	}
+
+	want := []Record{
+		{Name: "", Type: "NS", Content: "ns1.example.com", TTL: "3600"},
+		{Name: "", Type: "A", Content: "192.0.2.1", TTL: "3600"},
+		{Name: "", Type: "MX", Content: "mail.example.com", TTL: "3600", Prio: "10"},
+		{Name: "www", Type: "CNAME", Content: "example.com", TTL: "600"},
+		{Name: "mail", Type: "A", Content: "192.0.2.2", TTL: "600"},
+		{Name: "_sip._tcp", Type: "SRV", Content: "60 5060 sip.example.com", TTL: "3600", Prio: "10"},
+		{Name: "", Type: "TXT", Content: "v=spf1 include:_spf.example.net ~all", TTL: "3600"},
+		{Name: "long", Type: "TXT", Content: `part one; part "two"`, TTL: "3600"},
+		{Name: "host.sub", Type: "AAAA", Content: "2001:db8::1", TTL: "3600"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("ParseBIND() returned %d records, want %d: %+v", len(records), len(want), records)
+	}
+	for i, w := range want {
+		if records[i] != w {
+			t.Errorf("record %d = %+v, want %+v", i, records[i], w)
+		}
+	}
+}
+
+func TestParseBINDErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"outside domain", "other.org. IN A 192.0.2.1\n"},
+		{"unbalanced parens", "@ IN TXT ( \"a\"\n"},
+		{"unterminated quote", "@ IN TXT \"abc\n"},
+		{"bad mx", "@ IN MX mail.example.com.\n"},
+		{"include", "$INCLUDE other.zone\n"},
+		{"chaos class", "@ CH TXT \"x\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseBIND(strings.NewReader(tt.data), "example.com"); err == nil {
+				t.Error("ParseBIND() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestWriteBINDRoundTrip(t *testing.T) {
+	records := []Record{
+		{Name: "", Type: "A", Content: "192.0.2.1", TTL: "600"},
+		{Name: "", Type: "MX", Content: "mail.example.com", TTL: "600", Prio: "10"},
+		{Name: "_sip._tcp", Type: "SRV", Content: "60 5060 sip.example.com", TTL: "600", Prio: "10"},
+		{Name: "txt", Type: "TXT", Content: strings.Repeat("a", 300) + `"quoted"`, TTL: "600"},
+		{Name: "www", Type: "CNAME", Content: "example.com", TTL: "600"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBIND(&buf, "example.com", records); err != nil {
+		t.Fatalf("WriteBIND() error = %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "$ORIGIN example.com.\n") {
+		t.Errorf("WriteBIND() output missing $ORIGIN: %q", buf.String())
+	}
+
+	got, err := ParseBIND(&buf, "example.com")
+	if err != nil {
+		t.Fatalf("ParseBIND() of exported zone error = %v", err)
+	}
+	if len(got) != len(records) {
+		t.Fatalf("round trip returned %d records, want %d", len(got), len(records))
+	}
+	for i := range records {
+		if got[i] != records[i] {
+			t.Errorf("round trip record %d = %+v, want %+v", i, got[i], records[i])
+		}
+	}
+}
+
+func TestParseTTL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"300", "300"},
+		{"1h", "3600"},
+		{"1h30m", "5400"},
+		{"1w2d", "777600"},
+	}
+	for _, tt := range tests {
+		got, err := parseTTL(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("parseTTL(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := parseTTL("1x"); err == nil {
+		t.Error("parseTTL(\"1x\") error = nil, want error")
+	}
+}
@@ -0,0 +1,59 @@
+package zone
+
+import "strings"
+
+// Rebase rewrites references to the domain from in record content to the
+// domain to, e.g. CNAME and MX targets or SPF includes. Only whole names
+// are rewritten: with from "example.com", "mail.example.com" changes but
+// "myexample.com" and "example.com.au" do not. Names are relative, so they
+// carry over unchanged.
+func Rebase(records []Record, from, to string) []Record {
+	out := make([]Record, len(records))
+	for i, r := range records {
+		r.Content = RebaseContent(r.Content, from, to)
+		out[i] = r
+	}
+	return out
+}
+
+// RebaseContent rewrites whole-name references to from in s.
+func RebaseContent(s, from, to string) string {
+	from = strings.TrimSuffix(strings.ToLower(from), ".")
+	if from == "" {
+		return s
+	}
+	lower := strings.ToLower(s)
+
+	var b strings.Builder
+	last := 0
+	for i := 0; i <= len(lower)-len(from); {
+		j := strings.Index(lower[i:], from)
+		if j < 0 {
+			break
+		}
+		start, end := i+j, i+j+len(from)
+		if (start == 0 || !isLabelChar(lower[start-1])) && endsName(lower, end) {
+			b.WriteString(s[last:start])
+			b.WriteString(to)
+			last = end
+			i = end
+			continue
+		}
+		i = start + 1
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func isLabelChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
+
+// endsName reports whether a name ending at i is complete: nothing or a
+// non-name character follows, or a single trailing dot.
+func endsName(s string, i int) bool {
+	if i < len(s) && s[i] == '.' {
+		i++
+	}
+	return i == len(s) || !isLabelChar(s[i]) && s[i] != '.'
+}
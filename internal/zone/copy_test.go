@@ -0,0 +1,41 @@
+package zone
+
+import "testing"
+
+func TestRebaseContent(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"example.com", "example.net"},
+		{"example.com.", "example.net."},
+		{"mail.example.com", "mail.example.net"},
+		{"Mail.Example.COM", "Mail.example.net"},
+		{"v=spf1 include:_spf.example.com include:example.com ~all", "v=spf1 include:_spf.example.net include:example.net ~all"},
+		{"10 20 5060 sip.example.com.", "10 20 5060 sip.example.net."},
+		{"myexample.com", "myexample.com"},
+		{"example.com.au", "example.com.au"},
+		{"my-example.com", "my-example.com"},
+		{"192.0.2.1", "192.0.2.1"},
+		{"0 issue \"letsencrypt.org\"", "0 issue \"letsencrypt.org\""},
+		{"mailto:dmarc@example.com", "mailto:dmarc@example.net"},
+	}
+	for _, tt := range tests {
+		if got := RebaseContent(tt.in, "example.com", "example.net"); got != tt.want {
+			t.Errorf("RebaseContent(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRebase(t *testing.T) {
+	records := []Record{
+		{Name: "www", Type: "CNAME", Content: "example.com"},
+		{Type: "MX", Content: "mail.example.com", Prio: "10"},
+	}
+	got := Rebase(records, "example.com", "example.org")
+	if got[0].Content != "example.org" || got[0].Name != "www" || got[1].Content != "mail.example.org" || got[1].Prio != "10" {
+		t.Errorf("Rebase() = %+v", got)
+	}
+	if records[0].Content != "example.com" {
+		t.Error("Rebase() modified its input")
+	}
+}
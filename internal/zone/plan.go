@@ -0,0 +1,245 @@
+package zone
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/OverseedAI/overpork/internal/api"
+)
+
+// Action is the kind of change a plan makes to a record.
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Change is a single planned record change. Before is nil for creates and
+// After is nil for deletes.
+type Change struct {
+	Action Action  `json:"action"`
+	ID     string  `json:"id,omitempty"`
+	Before *Record `json:"before,omitempty"`
+	After  *Record `json:"after,omitempty"`
+}
+
+// Plan is the set of changes needed to make live records match a zone file.
+type Plan struct {
+	Domain  string   `json:"domain"`
+	Changes []Change `json:"changes"`
+}
+
+// DiffOptions controls how a plan is computed.
+type DiffOptions struct {
+	// NoDelete keeps live records that are absent from the desired set.
+	NoDelete bool
+	// CreateOnly only creates desired records that don't exist yet: live
+	// records are never updated or deleted, even when a desired record has
+	// the same name and type.
+	CreateOnly bool
+}
+
+// Diff computes the changes needed to turn live into desired. Records are
+// matched by name and type; within such a set, records with identical
+// content are kept (or updated in place if TTL or priority differ),
+// leftover records are paired up as updates, and the remainder become
+// creates or deletes.
+func Diff(domain string, desired []Record, live []api.DNSRecord, opts DiffOptions) *Plan {
+	plan := &Plan{Domain: domain, Changes: []Change{}}
+
+	type liveRecord struct {
+		id  string
+		rec Record
+	}
+	liveByKey := make(map[string][]*liveRecord)
+	var liveOrder []*liveRecord
+	for i, r := range FromAPI(domain, live) {
+		lr := &liveRecord{id: live[i].ID, rec: r}
+		k := key(r)
+		liveByKey[k] = append(liveByKey[k], lr)
+		liveOrder = append(liveOrder, lr)
+	}
+	used := make(map[*liveRecord]bool)
+
+	desiredByKey := make(map[string][]Record)
+	var keys []string
+	for _, r := range desired {
+		k := key(r)
+		if _, ok := desiredByKey[k]; !ok {
+			keys = append(keys, k)
+		}
+		desiredByKey[k] = append(desiredByKey[k], r)
+	}
+
+	for _, k := range keys {
+		var pending []Record
+		for _, d := range desiredByKey[k] {
+			var match *liveRecord
+			for _, l := range liveByKey[k] {
+				if !used[l] && sameContent(d.Type, d.Content, l.rec.Content) {
+					match = l
+					break
+				}
+			}
+			if match == nil {
+				pending = append(pending, d)
+				continue
+			}
+			used[match] = true
+			if !sameAttrs(d, match.rec) && !opts.CreateOnly {
+				plan.Changes = append(plan.Changes, update(match.id, match.rec, d))
+			}
+		}
+
+		for _, d := range pending {
+			var match *liveRecord
+			for _, l := range liveByKey[k] {
+				if !used[l] && !opts.CreateOnly {
+					match = l
+					break
+				}
+			}
+			if match == nil {
+				after := d
+				plan.Changes = append(plan.Changes, Change{Action: ActionCreate, After: &after})
+				continue
+			}
+			used[match] = true
+			plan.Changes = append(plan.Changes, update(match.id, match.rec, d))
+		}
+	}
+
+	if !opts.NoDelete && !opts.CreateOnly {
+		for _, l := range liveOrder {
+			if used[l] {
+				continue
+			}
+			before := l.rec
+			plan.Changes = append(plan.Changes, Change{Action: ActionDelete, ID: l.id, Before: &before})
+		}
+	}
+
+	sort.SliceStable(plan.Changes, func(i, j int) bool {
+		return actionOrder(plan.Changes[i].Action) < actionOrder(plan.Changes[j].Action)
+	})
+	return plan
+}
+
+// Empty reports whether the plan makes no changes.
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// Counts returns the number of creates, updates and deletes in the plan.
+func (p *Plan) Counts() (create, update, del int) {
+	for _, c := range p.Changes {
+		switch c.Action {
+		case ActionCreate:
+			create++
+		case ActionUpdate:
+			update++
+		case ActionDelete:
+			del++
+		}
+	}
+	return create, update, del
+}
+
+// Summary returns a one-line human readable description of the plan.
+func (p *Plan) Summary() string {
+	c, u, d := p.Counts()
+	return fmt.Sprintf("Plan: %d to create, %d to update, %d to delete", c, u, d)
+}
+
+// Client is the subset of api.Client used to apply a plan.
+type Client interface {
+	DNSCreateContext(ctx context.Context, domain, recordType, content string, opts api.DNSCreateOpts) (int64, error)
+	DNSUpdateContext(ctx context.Context, domain, recordID, recordType, content string, opts api.DNSCreateOpts) error
+	DNSDeleteContext(ctx context.Context, domain, recordID string) error
+}
+
+// Apply executes the plan: deletes first, then updates, then creates, so
+// that replacing conflicting records (such as a CNAME) succeeds. It stops at
+// the first failure and returns the number of changes applied.
+func (p *Plan) Apply(ctx context.Context, c Client) (int, error) {
+	applied := 0
+	for _, ch := range p.Changes {
+		var err error
+		switch ch.Action {
+		case ActionDelete:
+			err = c.DNSDeleteContext(ctx, p.Domain, ch.ID)
+		case ActionUpdate:
+			err = c.DNSUpdateContext(ctx, p.Domain, ch.ID, ch.After.Type, ch.After.Content, createOpts(*ch.After))
+		case ActionCreate:
+			_, err = c.DNSCreateContext(ctx, p.Domain, ch.After.Type, ch.After.Content, createOpts(*ch.After))
+		}
+		if err != nil {
+			return applied, fmt.Errorf("%s %s %s: %w", ch.Action, ch.record().Type, displayName(ch.record().Name), err)
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+func (c Change) record() Record {
+	if c.After != nil {
+		return *c.After
+	}
+	return *c.Before
+}
+
+func update(id string, before, after Record) Change {
+	b := before
+	a := after
+	// Carry over live attributes the zone file leaves unspecified so the
+	// API call doesn't reset them.
+	if a.TTL == "" {
+		a.TTL = b.TTL
+	}
+	if a.Prio == "" {
+		a.Prio = b.Prio
+	}
+	return Change{Action: ActionUpdate, ID: id, Before: &b, After: &a}
+}
+
+func createOpts(r Record) api.DNSCreateOpts {
+	return api.DNSCreateOpts{Name: r.Name, TTL: r.TTL, Prio: r.Prio}
+}
+
+func key(r Record) string {
+	return strings.ToLower(r.Name) + "|" + strings.ToUpper(r.Type)
+}
+
+func sameAttrs(desired, live Record) bool {
+	if desired.TTL != "" && desired.TTL != live.TTL {
+		return false
+	}
+	if desired.Prio != "" && desired.Prio != live.Prio {
+		return false
+	}
+	return true
+}
+
+// sameContent compares record content, ignoring case and trailing dots for
+// types whose content is a hostname.
+func sameContent(recordType, a, b string) bool {
+	switch strings.ToUpper(recordType) {
+	case "CNAME", "ALIAS", "MX", "NS":
+		return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+	}
+	return a == b
+}
+
+func actionOrder(a Action) int {
+	switch a {
+	case ActionDelete:
+		return 0
+	case ActionUpdate:
+		return 1
+	}
+	return 2
+}
@@ -0,0 +1,134 @@
+package zone
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/OverseedAI/overpork/internal/api"
+	"go.yaml.in/yaml/v3"
+)
+
+// File is a declarative description of the DNS records of a single domain.
+type File struct {
+	Domain  string   `yaml:"domain" json:"domain"`
+	Records []Record `yaml:"records" json:"records"`
+}
+
+// Record is a DNS record as written in a zone file. Name is relative to the
+// domain, with "" or "@" meaning the apex. Empty TTL and Prio values mean
+// "whatever is live" when diffing; Notes are informational only.
+type Record struct {
+	Name    string `yaml:"name,omitempty" json:"name"`
+	Type    string `yaml:"type" json:"type"`
+	Content string `yaml:"content" json:"content"`
+	TTL     string `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+	Prio    string `yaml:"prio,omitempty" json:"prio,omitempty"`
+	Notes   string `yaml:"notes,omitempty" json:"notes,omitempty"`
+}
+
+// Load reads and parses a zone file from disk. See Parse for domain.
+func Load(path, domain string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read zone file: %w", err)
+	}
+	return Parse(data, domain)
+}
+
+// Parse parses a YAML zone file and normalizes its records. A non-empty
+// domain overrides the file's "domain:"; fully qualified record names are
+// made relative to the result.
+func Parse(data []byte, domain string) (*File, error) {
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse zone file: %w", err)
+	}
+	if domain != "" {
+		f.Domain = domain
+	}
+	f.Domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(f.Domain)), ".")
+
+	for i := range f.Records {
+		r := &f.Records[i]
+		r.Type = strings.ToUpper(strings.TrimSpace(r.Type))
+		r.Name = normalizeName(r.Name, f.Domain)
+		if r.Type == "" {
+			return nil, fmt.Errorf("record %d: type is required", i+1)
+		}
+		if r.Content == "" {
+			return nil, fmt.Errorf("record %d (%s %s): content is required", i+1, r.Type, displayName(r.Name))
+		}
+	}
+	return &f, nil
+}
+
+// Marshal renders a zone file as YAML.
+func (f *File) Marshal() ([]byte, error) {
+	return yaml.Marshal(f)
+}
+
+// RelativeName converts a fully qualified record name as returned by the API
+// into a name relative to domain ("" for the apex).
+func RelativeName(fqdn, domain string) string {
+	fqdn = strings.TrimSuffix(strings.ToLower(fqdn), ".")
+	domain = strings.ToLower(domain)
+	if fqdn == domain {
+		return ""
+	}
+	return strings.TrimSuffix(fqdn, "."+domain)
+}
+
+// FromAPI converts live records into zone file records.
+func FromAPI(domain string, records []api.DNSRecord) []Record {
+	out := make([]Record, len(records))
+	for i, r := range records {
+		out[i] = Record{
+			Name:    RelativeName(r.Name, domain),
+			Type:    strings.ToUpper(r.Type),
+			Content: r.Content,
+			TTL:     r.TTL,
+			Prio:    normalizePrio(r.Type, r.Prio),
+			Notes:   r.Notes,
+		}
+	}
+	return out
+}
+
+func normalizeName(name, domain string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "@" {
+		return ""
+	}
+	if strings.HasSuffix(name, ".") {
+		return RelativeName(name, domain)
+	}
+	return name
+}
+
+// normalizePrio drops the "0" priority the API reports for record types that
+// don't carry one.
+func normalizePrio(recordType, prio string) string {
+	if usesPrio(recordType) {
+		return prio
+	}
+	if prio == "0" {
+		return ""
+	}
+	return prio
+}
+
+func usesPrio(recordType string) bool {
+	switch strings.ToUpper(recordType) {
+	case "MX", "SRV", "HTTPS", "SVCB":
+		return true
+	}
+	return false
+}
+
+func displayName(name string) string {
+	if name == "" {
+		return "@"
+	}
+	return name
+}
@@ -0,0 +1,213 @@
+package zone
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/OverseedAI/overpork/internal/api"
+)
+
+func TestParse(t *testing.T) {
+	data := []byte(`
+domain: Example.com.
+records:
+  - name: "@"
+    type: a
+    content: 192.168.1.1
+    ttl: 600
+  - name: www.example.com.
+    type: CNAME
+    content: example.com
+  - type: MX
+    content: mail.example.com
+    prio: 10
+`)
+	f, err := Parse(data, "")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if f.Domain != "example.com" {
+		t.Errorf("Domain = %q, want %q", f.Domain, "example.com")
+	}
+	if len(f.Records) != 3 {
+		t.Fatalf("len(Records) = %d, want 3", len(f.Records))
+	}
+	want := []Record{
+		{Name: "", Type: "A", Content: "192.168.1.1", TTL: "600"},
+		{Name: "www", Type: "CNAME", Content: "example.com"},
+		{Name: "", Type: "MX", Content: "mail.example.com", Prio: "10"},
+	}
+	for i, w := range want {
+		if f.Records[i] != w {
+			t.Errorf("Records[%d] = %+v, want %+v", i, f.Records[i], w)
+		}
+	}
+}
+
+func TestParseDomainOverride(t *testing.T) {
+	data := []byte(`
+records:
+  - name: www.example.net.
+    type: A
+    content: 192.0.2.1
+  - name: example.net.
+    type: TXT
+    content: hello
+`)
+	f, err := Parse(data, "Example.net")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if f.Domain != "example.net" || f.Records[0].Name != "www" || f.Records[1].Name != "" {
+		t.Errorf("Parse() = %+v, want names relative to example.net", f)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"missing type", "records:\n  - content: 1.2.3.4\n"},
+		{"missing content", "records:\n  - type: A\n"},
+		{"invalid yaml", "records: [\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.data), ""); err == nil {
+				t.Error("Parse() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestRelativeName(t *testing.T) {
+	tests := []struct {
+		fqdn string
+		want string
+	}{
+		{"example.com", ""},
+		{"www.example.com", "www"},
+		{"a.b.Example.com.", "a.b"},
+	}
+	for _, tt := range tests {
+		if got := RelativeName(tt.fqdn, "example.com"); got != tt.want {
+			t.Errorf("RelativeName(%q) = %q, want %q", tt.fqdn, got, tt.want)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	live := []api.DNSRecord{
+		{ID: "1", Name: "example.com", Type: "A", Content: "1.1.1.1", TTL: "600", Prio: "0"},
+		{ID: "2", Name: "www.example.com", Type: "CNAME", Content: "example.com", TTL: "600", Prio: "0"},
+		{ID: "3", Name: "old.example.com", Type: "A", Content: "3.3.3.3", TTL: "600", Prio: "0"},
+		{ID: "4", Name: "example.com", Type: "MX", Content: "mail.example.com", TTL: "600", Prio: "10"},
+	}
+	desired := []Record{
+		{Name: "", Type: "A", Content: "2.2.2.2"},
+		{Name: "www", Type: "CNAME", Content: "example.com."},
+		{Name: "", Type: "MX", Content: "mail.example.com", TTL: "3600", Prio: "10"},
+		{Name: "new", Type: "TXT", Content: "hello"},
+	}
+
+	plan := Diff("example.com", desired, live, DiffOptions{})
+
+	c, u, d := plan.Counts()
+	if c != 1 || u != 2 || d != 1 {
+		t.Fatalf("Counts() = %d/%d/%d, want 1/2/1; changes = %+v", c, u, d, plan.Changes)
+	}
+	if plan.Changes[0].Action != ActionDelete || plan.Changes[0].ID != "3" {
+		t.Errorf("first change = %+v, want delete of record 3", plan.Changes[0])
+	}
+	for _, ch := range plan.Changes {
+		if ch.Action == ActionUpdate && ch.ID == "1" {
+			if ch.After.Content != "2.2.2.2" || ch.After.TTL != "600" {
+				t.Errorf("update of record 1 = %+v, want content 2.2.2.2 with live TTL kept", ch.After)
+			}
+		}
+	}
+
+	plan = Diff("example.com", desired, live, DiffOptions{NoDelete: true})
+	if _, _, d := plan.Counts(); d != 0 {
+		t.Errorf("Counts() deletes with NoDelete = %d, want 0", d)
+	}
+
+	// Record 1 has another apex A and the MX only differs in TTL: neither
+	// may be touched.
+	plan = Diff("example.com", desired, live, DiffOptions{CreateOnly: true})
+	if c, u, d := plan.Counts(); c != 2 || u != 0 || d != 0 {
+		t.Errorf("Counts() with CreateOnly = %d/%d/%d, want 2/0/0; changes = %+v", c, u, d, plan.Changes)
+	}
+}
+
+func TestDiffNoChanges(t *testing.T) {
+	live := []api.DNSRecord{
+		{ID: "1", Name: "example.com", Type: "A", Content: "1.1.1.1", TTL: "600", Prio: "0"},
+		{ID: "2", Name: "example.com", Type: "A", Content: "2.2.2.2", TTL: "600", Prio: "0"},
+	}
+	desired := []Record{
+		{Type: "A", Content: "2.2.2.2"},
+		{Type: "A", Content: "1.1.1.1", TTL: "600"},
+	}
+	if plan := Diff("example.com", desired, live, DiffOptions{}); !plan.Empty() {
+		t.Errorf("Diff() = %+v, want no changes", plan.Changes)
+	}
+}
+
+type fakeClient struct {
+	calls []string
+	fail  string
+}
+
+func (f *fakeClient) DNSCreateContext(ctx context.Context, domain, recordType, content string, opts api.DNSCreateOpts) (int64, error) {
+	f.calls = append(f.calls, "create "+content)
+	if f.fail == content {
+		return 0, errors.New("boom")
+	}
+	return 1, nil
+}
+
+func (f *fakeClient) DNSUpdateContext(ctx context.Context, domain, recordID, recordType, content string, opts api.DNSCreateOpts) error {
+	f.calls = append(f.calls, "update "+recordID)
+	return nil
+}
+
+func (f *fakeClient) DNSDeleteContext(ctx context.Context, domain, recordID string) error {
+	f.calls = append(f.calls, "delete "+recordID)
+	return nil
+}
+
+func TestApply(t *testing.T) {
+	live := []api.DNSRecord{
+		{ID: "1", Name: "example.com", Type: "A", Content: "1.1.1.1"},
+		{ID: "2", Name: "old.example.com", Type: "A", Content: "3.3.3.3"},
+	}
+	desired := []Record{
+		{Type: "A", Content: "2.2.2.2"},
+		{Name: "new", Type: "A", Content: "4.4.4.4"},
+	}
+	plan := Diff("example.com", desired, live, DiffOptions{})
+
+	fc := &fakeClient{}
+	n, err := plan.Apply(context.Background(), fc)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	want := []string{"delete 2", "update 1", "create 4.4.4.4"}
+	if n != len(want) || len(fc.calls) != len(want) {
+		t.Fatalf("Apply() calls = %v, want %v", fc.calls, want)
+	}
+	for i := range want {
+		if fc.calls[i] != want[i] {
+			t.Errorf("call %d = %q, want %q", i, fc.calls[i], want[i])
+		}
+	}
+
+	fc = &fakeClient{fail: "4.4.4.4"}
+	n, err = plan.Apply(context.Background(), fc)
+	if err == nil || n != 2 {
+		t.Errorf("Apply() = %d, %v; want 2 applied and an error", n, err)
+	}
+}
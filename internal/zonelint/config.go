@@ -0,0 +1,123 @@
+package zonelint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Config tunes a lint run. Rules are named by code (ZL001) or name
+// (dangling-cname).
+//
+//	disable: [missing-caa]
+//	severity:
+//	  private-ip: error
+//	ignore:
+//	  - rule: private-ip
+//	    name: "vpn*"
+type Config struct {
+	Disable  []string            `yaml:"disable,omitempty" json:"disable,omitempty"`
+	Severity map[string]Severity `yaml:"severity,omitempty" json:"severity,omitempty"`
+	Ignore   []Ignore            `yaml:"ignore,omitempty" json:"ignore,omitempty"`
+}
+
+// Ignore suppresses a rule's findings for names matching a glob ("@" is
+// the apex). An empty Rule or Name matches everything.
+type Ignore struct {
+	Rule string `yaml:"rule,omitempty" json:"rule,omitempty"`
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+}
+
+// LoadConfig reads a YAML lint configuration.
+func LoadConfig(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lint config: %w", err)
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig parses a YAML lint configuration and checks that it only
+// refers to known rules.
+func ParseConfig(data []byte) (*Config, error) {
+	var c Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse lint config: %w", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Validate checks rule references, severities and name patterns.
+func (c *Config) Validate() error {
+	for _, id := range c.Disable {
+		if FindRule(id) == nil {
+			return fmt.Errorf("unknown rule %q in disable", id)
+		}
+	}
+	for id, s := range c.Severity {
+		if FindRule(id) == nil {
+			return fmt.Errorf("unknown rule %q in severity", id)
+		}
+		if !s.valid() {
+			return fmt.Errorf("invalid severity %q for %s: use error, warning or info", s, id)
+		}
+	}
+	for _, ig := range c.Ignore {
+		if ig.Rule != "" && FindRule(ig.Rule) == nil {
+			return fmt.Errorf("unknown rule %q in ignore", ig.Rule)
+		}
+		if _, err := path.Match(ig.Name, ""); err != nil {
+			return fmt.Errorf("invalid name pattern %q in ignore: %w", ig.Name, err)
+		}
+	}
+	return nil
+}
+
+func (c *Config) disabled(r Rule) bool {
+	for _, id := range c.Disable {
+		if FindRule(id).Code == r.Code {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) apply(findings []Finding) []Finding {
+	var out []Finding
+	for _, f := range findings {
+		if c.ignored(f) {
+			continue
+		}
+		for id, s := range c.Severity {
+			if FindRule(id).Code == f.Rule {
+				f.Severity = s
+			}
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+func (c *Config) ignored(f Finding) bool {
+	name := displayName(f.Name)
+	for _, ig := range c.Ignore {
+		if ig.Rule != "" && FindRule(ig.Rule).Code != f.Rule {
+			continue
+		}
+		if ok, _ := path.Match(strings.ToLower(ig.Name), name); ok || ig.Name == "" {
+			return true
+		}
+	}
+	return false
+}
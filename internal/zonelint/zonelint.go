@@ -0,0 +1,403 @@
+// Package zonelint runs whole-zone checks over a domain's records: things
+// that are valid record by record but wrong together, such as a CNAME next
+// to other records or an MX pointing at a CNAME.
+package zonelint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"strings"
+
+	"github.com/OverseedAI/overpork/internal/zone"
+)
+
+// Severity ranks findings.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+func (s Severity) valid() bool {
+	return s == SeverityError || s == SeverityWarning || s == SeverityInfo
+}
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityError:
+		return 0
+	case SeverityWarning:
+		return 1
+	}
+	return 2
+}
+
+// Rule describes a check. Findings refer to rules by Code; configuration
+// may use the code or the name.
+type Rule struct {
+	Code        string   `json:"code"`
+	Name        string   `json:"name"`
+	Severity    Severity `json:"severity"`
+	Description string   `json:"description"`
+}
+
+// Rules are the checks in the order they run.
+var Rules = []Rule{
+	{"ZL001", "dangling-cname", SeverityError, "CNAME target does not resolve"},
+	{"ZL002", "cname-conflict", SeverityError, "CNAME shares its name with other records"},
+	{"ZL003", "duplicate-record", SeverityWarning, "Same name, type and content appear more than once"},
+	{"ZL004", "mx-target-cname", SeverityError, "MX target is a CNAME (RFC 2181, section 10.3)"},
+	{"ZL005", "ns-target-cname", SeverityError, "NS target is a CNAME (RFC 2181, section 10.3)"},
+	{"ZL006", "missing-caa", SeverityInfo, "No CAA record restricts which CAs may issue certificates"},
+	{"ZL007", "ttl-mismatch", SeverityWarning, "Records of one name and type have different TTLs"},
+	{"ZL008", "wildcard-shadow", SeverityWarning, "A name covered by a wildcard exists, so the wildcard no longer applies to it"},
+	{"ZL009", "private-ip", SeverityWarning, "A or AAAA record points at a private, loopback or link-local address"},
+}
+
+// FindRule returns the rule with the given code or name, or nil.
+func FindRule(id string) *Rule {
+	for i := range Rules {
+		if strings.EqualFold(Rules[i].Code, id) || strings.EqualFold(Rules[i].Name, id) {
+			return &Rules[i]
+		}
+	}
+	return nil
+}
+
+// Finding is a problem found by a rule.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	RuleName string   `json:"ruleName"`
+	Severity Severity `json:"severity"`
+	Name     string   `json:"name"` // relative record name, "" for the apex
+	Type     string   `json:"type,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// Resolver looks up names outside the zone. net.Resolver implements it.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// Options controls a lint run.
+type Options struct {
+	// Resolver looks up targets outside the zone; without one, only
+	// in-zone targets are checked.
+	Resolver Resolver
+	Config   *Config
+}
+
+// Lint checks the records of domain. Findings are sorted by severity,
+// then by name.
+func Lint(ctx context.Context, domain string, records []zone.Record, opts Options) []Finding {
+	l := &linter{ctx: ctx, domain: strings.ToLower(strings.TrimSuffix(domain, ".")), resolver: opts.Resolver}
+	l.index(records)
+
+	checks := map[string]func(){
+		"ZL001": l.danglingCNAME,
+		"ZL002": l.cnameConflict,
+		"ZL003": l.duplicates,
+		"ZL004": func() { l.targetCNAME("ZL004", "MX") },
+		"ZL005": func() { l.targetCNAME("ZL005", "NS") },
+		"ZL006": l.missingCAA,
+		"ZL007": l.ttlMismatch,
+		"ZL008": l.wildcardShadow,
+		"ZL009": l.privateIP,
+	}
+	for _, r := range Rules {
+		if opts.Config != nil && opts.Config.disabled(r) {
+			continue
+		}
+		checks[r.Code]()
+	}
+
+	findings := l.findings
+	if opts.Config != nil {
+		findings = opts.Config.apply(findings)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if a, b := findings[i].Severity.rank(), findings[j].Severity.rank(); a != b {
+			return a < b
+		}
+		return findings[i].Name < findings[j].Name
+	})
+	return findings
+}
+
+type linter struct {
+	ctx      context.Context
+	domain   string
+	resolver Resolver
+	records  []zone.Record
+	byName   map[string][]zone.Record
+	names    []string
+	findings []Finding
+}
+
+func (l *linter) index(records []zone.Record) {
+	l.byName = map[string][]zone.Record{}
+	for _, r := range records {
+		r.Name = strings.ToLower(r.Name)
+		r.Type = strings.ToUpper(r.Type)
+		if _, ok := l.byName[r.Name]; !ok {
+			l.names = append(l.names, r.Name)
+		}
+		l.byName[r.Name] = append(l.byName[r.Name], r)
+		l.records = append(l.records, r)
+	}
+	sort.Strings(l.names)
+}
+
+func (l *linter) add(code, name, recordType, format string, args ...any) {
+	r := FindRule(code)
+	l.findings = append(l.findings, Finding{
+		Rule: r.Code, RuleName: r.Name, Severity: r.Severity,
+		Name: name, Type: recordType, Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// relative returns the in-zone name of a target hostname, or false if the
+// target is outside the zone.
+func (l *linter) relative(target string) (string, bool) {
+	target = strings.ToLower(strings.TrimSuffix(target, "."))
+	if target == l.domain {
+		return "", true
+	}
+	if name, ok := strings.CutSuffix(target, "."+l.domain); ok {
+		return name, true
+	}
+	return "", false
+}
+
+// exists reports whether name has records, directly or through a wildcard.
+func (l *linter) exists(name string) bool {
+	if len(l.byName[name]) > 0 {
+		return true
+	}
+	for _, n := range l.names {
+		if strings.HasSuffix(n, "."+name) {
+			return true // empty non-terminal
+		}
+	}
+	return l.wildcardFor(name) != ""
+}
+
+// wildcardFor returns the wildcard name that covers name, or "".
+func (l *linter) wildcardFor(name string) string {
+	for parent := name; parent != ""; {
+		_, rest, found := strings.Cut(parent, ".")
+		if !found {
+			rest = ""
+		}
+		wildcard := "*"
+		if rest != "" {
+			wildcard += "." + rest
+		}
+		if len(l.byName[wildcard]) > 0 && wildcard != name {
+			return wildcard
+		}
+		parent = rest
+	}
+	return ""
+}
+
+func (l *linter) danglingCNAME() {
+	for _, r := range l.records {
+		if r.Type != "CNAME" {
+			continue
+		}
+		if name, ok := l.relative(r.Content); ok {
+			if !l.exists(name) {
+				l.add("ZL001", r.Name, r.Type, "target %s has no records in the zone", r.Content)
+			}
+			continue
+		}
+		if l.resolver == nil {
+			continue
+		}
+		if !l.resolves(r.Content) {
+			l.add("ZL001", r.Name, r.Type, "target %s does not resolve", r.Content)
+		}
+	}
+}
+
+// resolves reports whether a name outside the zone exists. DKIM and ACME
+// delegation targets often have TXT records but no addresses, so a name
+// only counts as missing when lookups of both come back not found; other
+// failures, such as timeouts, don't count either.
+func (l *linter) resolves(name string) bool {
+	if _, err := l.resolver.LookupHost(l.ctx, name); !notFound(err) {
+		return true
+	}
+	_, err := l.resolver.LookupTXT(l.ctx, name)
+	return !notFound(err)
+}
+
+func notFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+func (l *linter) cnameConflict() {
+	for _, name := range l.names {
+		records := l.byName[name]
+		cnames := 0
+		for _, r := range records {
+			if r.Type == "CNAME" {
+				cnames++
+			}
+		}
+		if cnames > 0 && len(records) > 1 {
+			l.add("ZL002", name, "CNAME", "CNAME shares the name with %d other records", len(records)-1)
+		}
+	}
+}
+
+func (l *linter) duplicates() {
+	seen := map[string]int{}
+	for _, r := range l.records {
+		k := r.Name + "|" + r.Type + "|" + strings.ToLower(strings.TrimSuffix(r.Content, "."))
+		seen[k]++
+		if seen[k] == 2 {
+			l.add("ZL003", r.Name, r.Type, "%q appears more than once", r.Content)
+		}
+	}
+}
+
+func (l *linter) targetCNAME(code, recordType string) {
+	for _, r := range l.records {
+		if r.Type != recordType || r.Content == "." {
+			continue
+		}
+		if name, ok := l.relative(r.Content); ok {
+			for _, t := range l.byName[name] {
+				if t.Type == "CNAME" {
+					l.add(code, r.Name, r.Type, "target %s is a CNAME", r.Content)
+					break
+				}
+			}
+			continue
+		}
+		if l.resolver == nil {
+			continue
+		}
+		canonical, err := l.resolver.LookupCNAME(l.ctx, r.Content)
+		if err == nil && !strings.EqualFold(strings.TrimSuffix(canonical, "."), strings.TrimSuffix(r.Content, ".")) {
+			l.add(code, r.Name, r.Type, "target %s is a CNAME for %s", r.Content, strings.TrimSuffix(canonical, "."))
+		}
+	}
+}
+
+func (l *linter) missingCAA() {
+	for _, r := range l.byName[""] {
+		if r.Type == "CAA" {
+			return
+		}
+	}
+	l.add("ZL006", "", "CAA", "no CAA record at the apex; any CA may issue certificates for %s", l.domain)
+}
+
+func (l *linter) ttlMismatch() {
+	for _, name := range l.names {
+		ttls := map[string][]string{}
+		var types []string
+		for _, r := range l.byName[name] {
+			if r.TTL == "" {
+				continue
+			}
+			if _, ok := ttls[r.Type]; !ok {
+				types = append(types, r.Type)
+			}
+			if !contains(ttls[r.Type], r.TTL) {
+				ttls[r.Type] = append(ttls[r.Type], r.TTL)
+			}
+		}
+		for _, t := range types {
+			if len(ttls[t]) > 1 {
+				l.add("ZL007", name, t, "records have TTLs %s; resolvers use the lowest for the whole set", strings.Join(ttls[t], ", "))
+			}
+		}
+	}
+}
+
+func (l *linter) wildcardShadow() {
+	for _, name := range l.names {
+		if strings.HasPrefix(name, "*") {
+			continue
+		}
+		wildcard := l.wildcardFor(name)
+		if wildcard == "" {
+			continue
+		}
+		var missing []string
+		for _, w := range l.byName[wildcard] {
+			has := false
+			for _, r := range l.byName[name] {
+				if r.Type == w.Type || r.Type == "CNAME" {
+					has = true
+					break
+				}
+			}
+			if !has && !contains(missing, w.Type) {
+				missing = append(missing, w.Type)
+			}
+		}
+		if len(missing) > 0 {
+			l.add("ZL008", name, "", "%s does not apply here: %s exists, so %s queries get no answer", wildcard, displayName(name), strings.Join(missing, "/"))
+		}
+	}
+}
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which
+// netip doesn't count as private.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+func (l *linter) privateIP() {
+	for _, r := range l.records {
+		if r.Type != "A" && r.Type != "AAAA" {
+			continue
+		}
+		addr, err := netip.ParseAddr(r.Content)
+		if err != nil {
+			continue
+		}
+		var kind string
+		switch {
+		case addr.IsLoopback():
+			kind = "loopback"
+		case addr.IsPrivate(), sharedAddressSpace.Contains(addr):
+			kind = "private"
+		case addr.IsLinkLocalUnicast():
+			kind = "link-local"
+		case addr.IsUnspecified():
+			kind = "unspecified"
+		default:
+			continue
+		}
+		l.add("ZL009", r.Name, r.Type, "%s is a %s address, unreachable from the internet", r.Content, kind)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func displayName(name string) string {
+	if name == "" {
+		return "@"
+	}
+	return name
+}
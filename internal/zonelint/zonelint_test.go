@@ -0,0 +1,173 @@
+package zonelint
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/OverseedAI/overpork/internal/zone"
+)
+
+type fakeResolver struct {
+	hosts  map[string]bool
+	txt    map[string]bool
+	cnames map[string]string
+}
+
+func (f fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if f.hosts[host] {
+		return []string{"192.0.2.1"}, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func (f fakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if f.txt[name] {
+		return []string{"v=DKIM1; p=MIIB"}, nil
+	}
+	if name == "timeout.example.net" {
+		return nil, &net.DNSError{Err: "i/o timeout", Name: name, IsTimeout: true}
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (f fakeResolver) LookupCNAME(ctx context.Context, host string) (string, error) {
+	if c, ok := f.cnames[host]; ok {
+		return c + ".", nil
+	}
+	return host + ".", nil
+}
+
+func rules(findings []Finding) []string {
+	var out []string
+	for _, f := range findings {
+		out = append(out, f.Rule+" "+displayName(f.Name))
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestLint(t *testing.T) {
+	resolver := fakeResolver{
+		hosts:  map[string]bool{"cdn.example.net": true},
+		txt:    map[string]bool{"selector1.dkim.example.net": true},
+		cnames: map[string]string{"mx.example.net": "mx.provider.example"},
+	}
+	tests := []struct {
+		name    string
+		records []zone.Record
+		want    []string
+	}{
+		{"clean", []zone.Record{
+			{Type: "A", Content: "192.0.2.1"},
+			{Type: "CAA", Content: `0 issue "letsencrypt.org"`},
+			{Name: "www", Type: "CNAME", Content: "example.com"},
+			{Name: "static", Type: "CNAME", Content: "cdn.example.net"},
+			{Name: "deep", Type: "CNAME", Content: "a.b.example.com"},
+			{Name: "x.a.b", Type: "TXT", Content: "hi"},
+			{Name: "selector1._domainkey", Type: "CNAME", Content: "selector1.dkim.example.net"},
+			{Name: "slow", Type: "CNAME", Content: "timeout.example.net"},
+		}, nil},
+		{"dangling", []zone.Record{
+			{Type: "CAA", Content: `0 issue "letsencrypt.org"`},
+			{Name: "old", Type: "CNAME", Content: "gone.example.net"},
+			{Name: "docs", Type: "CNAME", Content: "missing.example.com"},
+			{Name: "any", Type: "CNAME", Content: "foo.wild.example.com"},
+			{Name: "*.wild", Type: "A", Content: "192.0.2.1"},
+		}, []string{"ZL001 docs", "ZL001 old"}},
+		{"conflicts and duplicates", []zone.Record{
+			{Type: "CAA", Content: `0 issue "letsencrypt.org"`},
+			{Name: "www", Type: "CNAME", Content: "cdn.example.net"},
+			{Name: "www", Type: "TXT", Content: "x"},
+			{Name: "a", Type: "A", Content: "192.0.2.1", TTL: "600"},
+			{Name: "a", Type: "A", Content: "192.0.2.1", TTL: "600"},
+			{Name: "a", Type: "A", Content: "192.0.2.2", TTL: "3600"},
+		}, []string{"ZL002 www", "ZL003 a", "ZL007 a"}},
+		{"targets", []zone.Record{
+			{Type: "CAA", Content: `0 issue "letsencrypt.org"`},
+			{Type: "MX", Content: "mail.example.com", Prio: "10"},
+			{Type: "MX", Content: "mx.example.net", Prio: "20"},
+			{Name: "mail", Type: "CNAME", Content: "cdn.example.net"},
+			{Name: "sub", Type: "NS", Content: "ns.example.com"},
+			{Name: "ns", Type: "CNAME", Content: "cdn.example.net"},
+			{Name: "nomail", Type: "MX", Content: ".", Prio: "0"},
+		}, []string{"ZL004 @", "ZL004 @", "ZL005 sub"}},
+		{"wildcards and addresses", []zone.Record{
+			{Name: "*", Type: "A", Content: "192.0.2.1"},
+			{Name: "*", Type: "AAAA", Content: "2001:db8::1"},
+			{Name: "_acme-challenge", Type: "TXT", Content: "token"},
+			{Name: "www", Type: "A", Content: "192.0.2.1"},
+			{Name: "vpn", Type: "A", Content: "10.0.0.1"},
+			{Name: "vpn", Type: "AAAA", Content: "fd00::1"},
+			{Name: "cgnat", Type: "A", Content: "100.64.1.1"},
+			{Name: "local", Type: "A", Content: "127.0.0.1"},
+		}, []string{
+			"ZL006 @",
+			"ZL008 _acme-challenge", "ZL008 cgnat", "ZL008 local", "ZL008 www",
+			"ZL009 cgnat", "ZL009 local", "ZL009 vpn", "ZL009 vpn",
+		}},
+	}
+	for _, tt := range tests {
+		got := rules(Lint(context.Background(), "example.com", tt.records, Options{Resolver: resolver}))
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLintOffline(t *testing.T) {
+	records := []zone.Record{
+		{Type: "CAA", Content: `0 issue "letsencrypt.org"`},
+		{Name: "old", Type: "CNAME", Content: "gone.example.net"},
+		{Name: "docs", Type: "CNAME", Content: "missing.example.com"},
+		{Type: "MX", Content: "mx.example.net", Prio: "10"},
+	}
+	got := rules(Lint(context.Background(), "example.com", records, Options{}))
+	if strings.Join(got, ",") != "ZL001 docs" {
+		t.Errorf("offline lint = %q, want only the in-zone dangling CNAME", got)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+disable: [missing-caa]
+severity:
+  ZL009: error
+ignore:
+  - rule: private-ip
+    name: "vpn*"
+  - name: legacy
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+	records := []zone.Record{
+		{Name: "vpn1", Type: "A", Content: "10.0.0.1"},
+		{Name: "db", Type: "A", Content: "10.0.0.2"},
+		{Name: "legacy", Type: "CNAME", Content: "nowhere.example.com"},
+	}
+	findings := Lint(context.Background(), "example.com", records, Options{Config: cfg})
+	if got := rules(findings); strings.Join(got, ",") != "ZL009 db" {
+		t.Fatalf("findings = %q, want only ZL009 db", got)
+	}
+	if findings[0].Severity != SeverityError {
+		t.Errorf("severity = %s, want error", findings[0].Severity)
+	}
+
+	for _, data := range []string{
+		"disable: [nope]",
+		"severity:\n  ZL001: fatal\n",
+		"ignore:\n  - rule: ZL999\n",
+		"ignore:\n  - name: \"[\"\n",
+		"unknown: true",
+	} {
+		if _, err := ParseConfig([]byte(data)); err == nil {
+			t.Errorf("ParseConfig(%q) succeeded, want error", data)
+		}
+	}
+	if _, err := ParseConfig(nil); err != nil {
+		t.Errorf("ParseConfig(empty) error = %v", err)
+	}
+}